@@ -6,6 +6,7 @@ require (
 	github.com/go-chi/chi/v5 v5.2.3
 	github.com/kljensen/snowball v0.10.0
 	github.com/mattn/go-sqlite3 v1.14.32
+	gonum.org/v1/gonum v0.16.0
 )
 
 require (
@@ -24,5 +25,4 @@ require (
 	golang.org/x/image v0.34.0 // indirect
 	golang.org/x/mobile v0.0.0-20251209145715-2553ed8ce294 // indirect
 	golang.org/x/sys v0.39.0 // indirect
-	gonum.org/v1/gonum v0.16.0 // indirect
 )
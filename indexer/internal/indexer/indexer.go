@@ -0,0 +1,411 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/deidaraiorek/deisearch/indexer/internal/spider"
+	"github.com/deidaraiorek/deisearch/indexer/internal/storage"
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
+)
+
+// Field weights applied to term frequencies; a title word counts as much
+// as three body words
+const (
+	titleWeight       = 3
+	descriptionWeight = 2
+	contentWeight     = 1
+)
+
+// Indexed pages checked against the spider DB per query when syncing
+const syncChunkSize = 10000
+
+type Indexer struct {
+	spiderDB  *spider.SpiderDB
+	indexDB   *storage.IndexDB
+	batchSize int
+	workers   int
+	scoring   storage.Scoring
+}
+
+// batch is one transaction's worth of index changes.
+type batch struct {
+	pages   []*spider.Page // analyzed and saved
+	removed []int          // doc IDs deleted from the index
+	replace bool           // pages may already be indexed and are replaced
+}
+
+// analyzedPage is a page after tokenizing and stemming, ready to be written.
+type analyzedPage struct {
+	page *spider.Page
+	doc  textprocessor.ProcessedDocument
+}
+
+func NewIndexer(spiderDBPath, indexDBPath string, batchSize int) (*Indexer, error) {
+	spiderDB, err := spider.NewSpiderDB(spiderDBPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spider database: %w", err)
+	}
+
+	indexDB, err := storage.NewIndexDB(indexDBPath)
+	if err != nil {
+		spiderDB.Close()
+		return nil, fmt.Errorf("failed to open index database: %w", err)
+	}
+
+	scoring, err := indexDB.StoredScoring()
+	if err != nil {
+		indexDB.Close()
+		spiderDB.Close()
+		return nil, fmt.Errorf("failed to read scoring settings: %w", err)
+	}
+
+	return &Indexer{
+		spiderDB:  spiderDB,
+		indexDB:   indexDB,
+		batchSize: batchSize,
+		workers:   runtime.NumCPU(),
+		scoring:   scoring,
+	}, nil
+}
+
+// Scoring returns the BM25 parameters and default ranking model the next
+// run scores with: the ones the index was last scored with, unless
+// changed by SetScoring.
+func (idx *Indexer) Scoring() storage.Scoring {
+	return idx.scoring
+}
+
+// SetScoring changes the BM25 parameters and the default ranking model.
+// The next run rescores the index if they differ from the stored ones.
+func (idx *Indexer) SetScoring(scoring storage.Scoring) error {
+	if err := scoring.Validate(); err != nil {
+		return fmt.Errorf("invalid scoring: %w", err)
+	}
+	idx.scoring = scoring
+	return nil
+}
+
+func (idx *Indexer) Close() {
+	if idx.indexDB != nil {
+		idx.indexDB.Close()
+	}
+	if idx.spiderDB != nil {
+		idx.spiderDB.Close()
+	}
+}
+
+// IndexAll brings the index up to date with the spider DB: pages that
+// changed or disappeared since they were indexed are reindexed or removed,
+// and pages after the last indexed one are added. TF-IDF and BM25 are
+// then recomputed if anything, or the scoring settings, changed. Ctrl+C
+// stops after rolling back the current batch; the next run resumes from the
+// last committed one.
+func (idx *Indexer) IndexAll() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	totalPages, err := idx.spiderDB.GetTotalPageCount()
+	if err != nil {
+		return fmt.Errorf("failed to get total page count: %w", err)
+	}
+
+	lastIndexedID, err := idx.indexDB.GetLastIndexedPageID()
+	if err != nil {
+		return fmt.Errorf("failed to get last indexed page ID: %w", err)
+	}
+
+	alreadyIndexed, err := idx.indexDB.GetIndexedPageCount()
+	if err != nil {
+		return fmt.Errorf("failed to get indexed page count: %w", err)
+	}
+
+	log.Printf("Total pages in spider DB: %d", totalPages)
+	log.Printf("Resuming from page ID: %d", lastIndexedID)
+	log.Printf("Analyzing with %d workers", idx.workers)
+
+	if err := idx.indexDB.SetMetadata("indexing_complete", "false"); err != nil {
+		log.Printf("Warning: failed to update indexing_complete metadata: %v", err)
+	}
+
+	if alreadyIndexed > 0 {
+		syncStart := time.Now()
+		updated, removed, err := idx.syncChanges(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Printf("Interrupted while syncing changed pages, rolled back the current batch. Rerun to continue")
+				return nil
+			}
+			return fmt.Errorf("failed to sync changed pages: %w", err)
+		}
+		log.Printf("Synced with spider DB in %v: %d changed pages reindexed, %d removed", time.Since(syncStart).Round(time.Millisecond), updated, removed)
+	}
+
+	start := time.Now()
+	processedCount := 0
+	currentID := lastIndexedID
+
+	for {
+		pages, err := idx.spiderDB.GetPagesAfterID(currentID, idx.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to fetch pages: %w", err)
+		}
+
+		if len(pages) == 0 {
+			break
+		}
+
+		if err := idx.processBatch(ctx, batch{pages: pages}); err != nil {
+			if ctx.Err() != nil {
+				log.Printf("Interrupted, rolled back the current batch. Indexed %d pages this run; rerun to resume from page ID %d", processedCount, currentID)
+				return nil
+			}
+			return fmt.Errorf("failed to process batch: %w", err)
+		}
+
+		processedCount += len(pages)
+		currentID = pages[len(pages)-1].ID
+
+		if err := idx.indexDB.SetMetadata("last_indexed_page_id", fmt.Sprintf("%d", currentID)); err != nil {
+			log.Printf("Warning: failed to update last_indexed_page_id: %v", err)
+		}
+
+		log.Printf("Processed %d pages (%.2f%%) - Last ID: %d - %.0f pages/sec",
+			processedCount,
+			float64(processedCount+alreadyIndexed)/float64(max(totalPages, 1))*100,
+			currentID,
+			float64(processedCount)/time.Since(start).Seconds())
+	}
+
+	log.Printf("Pass 1 done: %d new pages indexed in %v", processedCount, time.Since(start).Round(time.Second))
+
+	outdated, err := idx.indexDB.ScoresOutdated(idx.scoring)
+	if err != nil {
+		return fmt.Errorf("failed to check scoring metadata: %w", err)
+	}
+	if outdated {
+		log.Printf("Pass 2: recalculating TF-IDF and BM25 (k1=%g, b=%g)...", idx.scoring.K1, idx.scoring.B)
+
+		scoringStart := time.Now()
+		if err := idx.indexDB.RecalculateScores(idx.scoring); err != nil {
+			return fmt.Errorf("failed to recalculate scores: %w", err)
+		}
+		log.Printf("Scores recalculated in %v; default ranking model: %s", time.Since(scoringStart).Round(time.Millisecond), idx.scoring.Model)
+	} else {
+		log.Printf("Index unchanged, skipping score recalculation")
+	}
+
+	if err := idx.indexDB.SetMetadata("indexing_complete", "true"); err != nil {
+		log.Printf("Warning: failed to update indexing_complete metadata: %v", err)
+	}
+
+	stats, err := idx.indexDB.GetIndexStats()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	} else {
+		logIndexStats(stats)
+	}
+
+	log.Printf("Indexing complete! Total pages processed: %d", processedCount)
+	return nil
+}
+
+func logIndexStats(stats storage.IndexStats) {
+	const mb = 1024 * 1024
+	log.Printf("Index: %d documents, %d terms, %d postings, %.1f MB on disk", stats.Documents, stats.Terms, stats.Postings, float64(stats.DatabaseBytes)/mb)
+	if stats.Postings > 0 && stats.DatabaseBytes > 0 {
+		log.Printf("Positions: %.1f MB (%.1f bytes per posting, %.1f%% of the index)",
+			float64(stats.PositionBytes)/mb,
+			float64(stats.PositionBytes)/float64(stats.Postings),
+			float64(stats.PositionBytes)/float64(stats.DatabaseBytes)*100)
+	}
+}
+
+// syncChanges compares the version every page was indexed at with the
+// spider DB. Pages whose content changed are reindexed; pages the spider
+// deleted or has since flagged as error pages are removed.
+func (idx *Indexer) syncChanges(ctx context.Context) (updated, removed int, err error) {
+	currentID := 0
+	for {
+		indexed, err := idx.indexDB.GetIndexedPagesAfterID(currentID, syncChunkSize)
+		if err != nil {
+			return updated, removed, fmt.Errorf("failed to list indexed pages: %w", err)
+		}
+		if len(indexed) == 0 {
+			return updated, removed, nil
+		}
+		currentID = indexed[len(indexed)-1].DocID
+
+		ids := make([]int, len(indexed))
+		for i, page := range indexed {
+			ids[i] = page.DocID
+		}
+		versions, err := idx.spiderDB.GetPageVersions(ids)
+		if err != nil {
+			return updated, removed, fmt.Errorf("failed to get page versions: %w", err)
+		}
+
+		var changedIDs, removedIDs []int
+		for _, page := range indexed {
+			version, exists := versions[page.DocID]
+			switch {
+			case !exists:
+				removedIDs = append(removedIDs, page.DocID)
+			case version != page.Version:
+				changedIDs = append(changedIDs, page.DocID)
+			}
+		}
+		if len(changedIDs) == 0 && len(removedIDs) == 0 {
+			continue
+		}
+
+		changed, err := idx.spiderDB.GetPagesByIDs(changedIDs)
+		if err != nil {
+			return updated, removed, fmt.Errorf("failed to fetch changed pages: %w", err)
+		}
+		// Pages flagged or deleted since the version check
+		if len(changed) < len(changedIDs) {
+			found := make(map[int]bool, len(changed))
+			for _, page := range changed {
+				found[page.ID] = true
+			}
+			for _, id := range changedIDs {
+				if !found[id] {
+					removedIDs = append(removedIDs, id)
+				}
+			}
+		}
+
+		if err := idx.processBatch(ctx, batch{pages: changed, removed: removedIDs, replace: true}); err != nil {
+			return updated, removed, err
+		}
+		updated += len(changed)
+		removed += len(removedIDs)
+	}
+}
+
+// processBatch tokenizes and stems pages on the worker pool while a single
+// writer saves them in one transaction, so a batch is indexed entirely or
+// not at all.
+func (idx *Indexer) processBatch(ctx context.Context, b batch) error {
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan *spider.Page)
+	results := make(chan analyzedPage, idx.workers*4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < idx.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			analyze(batchCtx, jobs, results)
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, page := range b.pages {
+			select {
+			case jobs <- page:
+			case <-batchCtx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	err := idx.writeBatch(batchCtx, b, results)
+	if err != nil {
+		// Unblock the workers so they exit
+		cancel()
+		for range results {
+		}
+		return err
+	}
+	return nil
+}
+
+// analyze is one worker. Each has its own text processor.
+func analyze(ctx context.Context, jobs <-chan *spider.Page, results chan<- analyzedPage) {
+	processor := textprocessor.NewTextProcessor()
+
+	for page := range jobs {
+		doc := processor.ProcessDocumentWithWeights(textprocessor.DocumentFields{
+			Title:       page.Title,
+			Description: page.Description,
+			Content:     page.Content,
+			URL:         page.URL,
+		}, titleWeight, descriptionWeight, contentWeight)
+
+		select {
+		case results <- analyzedPage{page: page, doc: doc}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (idx *Indexer) writeBatch(ctx context.Context, b batch, results <-chan analyzedPage) error {
+	tx, err := idx.indexDB.BeginTransaction()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts, err := idx.indexDB.PrepareStatements(tx)
+	if err != nil {
+		return err
+	}
+	defer stmts.Close()
+
+	for _, docID := range b.removed {
+		if _, err := idx.indexDB.DeleteDocumentWithStatements(stmts, docID); err != nil {
+			return fmt.Errorf("failed to delete page %d: %w", docID, err)
+		}
+	}
+
+	written := 0
+	for result := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page := result.page
+		if b.replace {
+			if _, err := idx.indexDB.DeleteDocumentWithStatements(stmts, page.ID); err != nil {
+				return fmt.Errorf("failed to delete old version of page %d: %w", page.ID, err)
+			}
+		}
+
+		err := idx.indexDB.SaveDocumentWithStatements(stmts, page.ID, page.URL, page.Version, result.doc)
+		if err != nil {
+			return fmt.Errorf("failed to save page %d: %w", page.ID, err)
+		}
+		written++
+	}
+
+	// Workers stop early only when the context is cancelled
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if written != len(b.pages) {
+		return fmt.Errorf("analyzed %d of %d pages", written, len(b.pages))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
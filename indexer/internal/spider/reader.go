@@ -3,6 +3,7 @@ package spider
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -14,10 +15,18 @@ type Page struct {
 	Description string
 	Content     string
 	StatusCode  int
+	Version     string // changes whenever the page's content does
 }
 
 type SpiderDB struct {
 	db *sql.DB
+
+	// Excludes soft 404s, error and login pages the spider flagged
+	pageFilter string
+
+	// Expression for Page.Version: the content hash, or the crawl time for
+	// databases from before pages were hashed
+	versionExpr string
 }
 
 func NewSpiderDB(dbPath string) (*SpiderDB, error) {
@@ -26,7 +35,37 @@ func NewSpiderDB(dbPath string) (*SpiderDB, error) {
 		return nil, fmt.Errorf("failed to open spider database: %w", err)
 	}
 
-	return &SpiderDB{db: db}, nil
+	sdb := &SpiderDB{db: db, versionExpr: "CAST(crawled_at AS TEXT)"}
+
+	hasErrorClass, err := sdb.hasColumn("pages", "error_class")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to inspect spider database: %w", err)
+	}
+	// Databases from before error pages were classified have no such column
+	if hasErrorClass {
+		sdb.pageFilter = " AND COALESCE(error_class, '') = ''"
+	}
+
+	hasContentHash, err := sdb.hasColumn("pages", "content_hash")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to inspect spider database: %w", err)
+	}
+	if hasContentHash {
+		sdb.versionExpr = "COALESCE(NULLIF(content_hash, ''), CAST(crawled_at AS TEXT))"
+	}
+
+	return sdb, nil
+}
+
+func (sdb *SpiderDB) hasColumn(table, column string) (bool, error) {
+	var count int
+	err := sdb.db.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?",
+		table, column,
+	).Scan(&count)
+	return count > 0, err
 }
 
 func (sdb *SpiderDB) Close() error {
@@ -36,9 +75,9 @@ func (sdb *SpiderDB) Close() error {
 func (sdb *SpiderDB) GetPageByID(id int) (*Page, error) {
 	page := &Page{}
 	err := sdb.db.QueryRow(
-		"SELECT id, url, title, description, content, status_code FROM pages WHERE id = ?",
+		"SELECT id, url, title, description, content, status_code, "+sdb.versionExpr+" FROM pages WHERE id = ?",
 		id,
-	).Scan(&page.ID, &page.URL, &page.Title, &page.Description, &page.Content, &page.StatusCode)
+	).Scan(&page.ID, &page.URL, &page.Title, &page.Description, &page.Content, &page.StatusCode, &page.Version)
 
 	if err != nil {
 		return nil, err
@@ -48,7 +87,7 @@ func (sdb *SpiderDB) GetPageByID(id int) (*Page, error) {
 
 func (sdb *SpiderDB) GetPagesAfterID(afterID int, limit int) ([]*Page, error) {
 	rows, err := sdb.db.Query(
-		"SELECT id, url, title, description, content, status_code FROM pages WHERE id > ? ORDER BY id LIMIT ?",
+		"SELECT id, url, title, description, content, status_code, "+sdb.versionExpr+" FROM pages WHERE id > ?"+sdb.pageFilter+" ORDER BY id LIMIT ?",
 		afterID, limit,
 	)
 	if err != nil {
@@ -56,10 +95,62 @@ func (sdb *SpiderDB) GetPagesAfterID(afterID int, limit int) ([]*Page, error) {
 	}
 	defer rows.Close()
 
+	return scanPages(rows)
+}
+
+// GetPagesByIDs returns the indexable pages among ids, in ID order.
+func (sdb *SpiderDB) GetPagesByIDs(ids []int) ([]*Page, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders, args := idArgs(ids)
+	rows, err := sdb.db.Query(
+		"SELECT id, url, title, description, content, status_code, "+sdb.versionExpr+" FROM pages WHERE id IN ("+placeholders+")"+sdb.pageFilter+" ORDER BY id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPages(rows)
+}
+
+// GetPageVersions maps the IDs among ids that are still indexable to their
+// current version. Pages the spider deleted or flagged since are missing.
+func (sdb *SpiderDB) GetPageVersions(ids []int) (map[int]string, error) {
+	versions := make(map[int]string, len(ids))
+	if len(ids) == 0 {
+		return versions, nil
+	}
+
+	placeholders, args := idArgs(ids)
+	rows, err := sdb.db.Query(
+		"SELECT id, "+sdb.versionExpr+" FROM pages WHERE id IN ("+placeholders+")"+sdb.pageFilter,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var version string
+		if err := rows.Scan(&id, &version); err != nil {
+			return nil, err
+		}
+		versions[id] = version
+	}
+	return versions, rows.Err()
+}
+
+func scanPages(rows *sql.Rows) ([]*Page, error) {
 	var pages []*Page
 	for rows.Next() {
 		page := &Page{}
-		err := rows.Scan(&page.ID, &page.URL, &page.Title, &page.Description, &page.Content, &page.StatusCode)
+		err := rows.Scan(&page.ID, &page.URL, &page.Title, &page.Description, &page.Content, &page.StatusCode, &page.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -69,8 +160,18 @@ func (sdb *SpiderDB) GetPagesAfterID(afterID int, limit int) ([]*Page, error) {
 	return pages, rows.Err()
 }
 
+// SQLite allows 32766 variables per statement, so callers look up IDs in
+// chunks
+func idArgs(ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
+
 func (sdb *SpiderDB) GetTotalPageCount() (int, error) {
 	var count int
-	err := sdb.db.QueryRow("SELECT COUNT(*) FROM pages").Scan(&count)
+	err := sdb.db.QueryRow("SELECT COUNT(*) FROM pages WHERE 1 = 1" + sdb.pageFilter).Scan(&count)
 	return count, err
 }
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math"
 
+	"github.com/deidaraiorek/deisearch/pkg/positions"
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -53,8 +55,74 @@ func NewIndexDB(dbPath string) (*IndexDB, error) {
 }
 
 func (idb *IndexDB) initSchema() error {
-	_, err := idb.db.Exec(Schema)
-	return err
+	if _, err := idb.db.Exec(Schema); err != nil {
+		return err
+	}
+	return idb.migrate()
+}
+
+// columns added after an index was created. Pages indexed before a
+// column marked reindex existed lose their version, so the next run
+// reindexes them to fill it in; backfill instead fills it in from what is
+// already indexed.
+var columns = []struct {
+	table, column, definition string
+	reindex                   bool
+	backfill                  string
+}{
+	{"indexed_pages", "source_version", "TEXT NOT NULL DEFAULT ''", false, ""},
+	{"postings", "positions", "BLOB", true, ""},
+	{"postings", "title_tf", "INTEGER DEFAULT 0", true, ""},
+	{"postings", "description_tf", "INTEGER DEFAULT 0", true, ""},
+	{"postings", "content_tf", "INTEGER DEFAULT 0", true, ""},
+	{"postings", "url_tf", "INTEGER DEFAULT 0", true, ""},
+	{"doc_stats", "title_length", "INTEGER DEFAULT 0", true, ""},
+	{"doc_stats", "description_length", "INTEGER DEFAULT 0", true, ""},
+	{"doc_stats", "content_length", "INTEGER DEFAULT 0", true, ""},
+	{"doc_stats", "url_length", "INTEGER DEFAULT 0", true, ""},
+	{"terms", "bm25_idf", "REAL DEFAULT 0", false, ""},
+	{"postings", "bm25", "REAL DEFAULT 0", false, ""},
+	// URL-only postings used to count toward document_frequency
+	{"terms", "url_only_frequency", "INTEGER DEFAULT 0", false, `
+		UPDATE terms SET url_only_frequency = (
+			SELECT COUNT(*) FROM postings WHERE postings.term_id = terms.term_id AND postings.term_frequency = 0
+		);
+		UPDATE terms SET document_frequency = document_frequency - url_only_frequency;
+		INSERT OR REPLACE INTO index_metadata (key, value, updated_at) VALUES ('idf_dirty', 'true', CURRENT_TIMESTAMP);`},
+	{"terms", "bm25f_idf", "REAL DEFAULT 0", false, `
+		INSERT OR REPLACE INTO index_metadata (key, value, updated_at) VALUES ('idf_dirty', 'true', CURRENT_TIMESTAMP);`},
+}
+
+func (idb *IndexDB) migrate() error {
+	for _, c := range columns {
+		var count int
+		err := idb.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", c.table, c.column).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", c.table, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := idb.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", c.table, c.column, err)
+		}
+		if c.reindex {
+			if _, err := idb.db.Exec("UPDATE indexed_pages SET source_version = ''"); err != nil {
+				return fmt.Errorf("failed to schedule reindexing: %w", err)
+			}
+		}
+		if c.backfill != "" {
+			if _, err := idb.db.Exec(c.backfill); err != nil {
+				return fmt.Errorf("failed to backfill %s.%s: %w", c.table, c.column, err)
+			}
+		}
+	}
+
+	if _, err := idb.db.Exec(MigratedIndexes); err != nil {
+		return fmt.Errorf("failed to create indexes: %w", err)
+	}
+	return nil
 }
 
 func (idb *IndexDB) IsPageIndexed(pageID int) (bool, error) {
@@ -82,6 +150,34 @@ func (idb *IndexDB) MarkPageAsIndexed(pageID int, url string) error {
 	return err
 }
 
+type IndexedPage struct {
+	DocID   int
+	Version string
+}
+
+// GetIndexedPagesAfterID lists indexed pages with the version they were
+// indexed at, in doc_id order.
+func (idb *IndexDB) GetIndexedPagesAfterID(afterID int, limit int) ([]IndexedPage, error) {
+	rows, err := idb.db.Query(
+		"SELECT doc_id, source_version FROM indexed_pages WHERE doc_id > ? ORDER BY doc_id LIMIT ?",
+		afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pages []IndexedPage
+	for rows.Next() {
+		var page IndexedPage
+		if err := rows.Scan(&page.DocID, &page.Version); err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+	return pages, rows.Err()
+}
+
 func (idb *IndexDB) GetIndexedPageCount() (int, error) {
 	var count int
 	err := idb.db.QueryRow("SELECT COUNT(*) FROM indexed_pages").Scan(&count)
@@ -153,11 +249,53 @@ func (idb *IndexDB) GetMetadata(key string) (string, error) {
 	return value, err
 }
 
+type IndexStats struct {
+	Documents     int64
+	Terms         int64
+	Postings      int64
+	PositionBytes int64 // size of the encoded positions
+	DatabaseBytes int64
+}
+
+func (idb *IndexDB) GetIndexStats() (IndexStats, error) {
+	var stats IndexStats
+
+	counts := []struct {
+		dest  *int64
+		query string
+	}{
+		{&stats.Documents, "SELECT COUNT(*) FROM indexed_pages"},
+		{&stats.Terms, "SELECT COUNT(*) FROM terms"},
+		{&stats.Postings, "SELECT COUNT(*) FROM postings"},
+		{&stats.PositionBytes, "SELECT COALESCE(SUM(LENGTH(positions)), 0) FROM postings"},
+		{&stats.DatabaseBytes, "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()"},
+	}
+	for _, c := range counts {
+		if err := idb.db.QueryRow(c.query).Scan(c.dest); err != nil {
+			return stats, fmt.Errorf("failed to get index stats: %w", err)
+		}
+	}
+	return stats, nil
+}
+
+// IsIDFDirty reports whether postings changed since TF-IDF was last
+// recalculated.
+func (idb *IndexDB) IsIDFDirty() (bool, error) {
+	value, err := idb.GetMetadata("idf_dirty")
+	if err == sql.ErrNoRows {
+		// Indexes from before the flag existed
+		return true, nil
+	}
+	return value == "true", err
+}
+
 func (idb *IndexDB) Close() error {
 	return idb.db.Close()
 }
 
-func (idb *IndexDB) RecalculateTFIDF() error {
+// RecalculateScores updates every term's IDF and every posting's TF-IDF
+// and BM25 scores, then records the scoring settings.
+func (idb *IndexDB) RecalculateScores(scoring Scoring) error {
 	totalDocs, err := idb.GetIndexedPageCount()
 	if err != nil {
 		return fmt.Errorf("failed to get total document count: %w", err)
@@ -171,12 +309,16 @@ func (idb *IndexDB) RecalculateTFIDF() error {
 
 	// Step 1: Calculate IDF values in Go and update terms table
 	// IDF = log(total_docs / document_frequency)
-	rows, err := tx.Query("SELECT term_id, document_frequency FROM terms WHERE document_frequency > 0")
+	// BM25 IDF = log(1 + (total_docs - df + 0.5) / (df + 0.5)), which stays
+	// positive for terms in most documents
+	// BM25F IDF is the BM25 IDF over documents with the term in any field,
+	// including those that only have it in their URL
+	rows, err := tx.Query("SELECT term_id, document_frequency, url_only_frequency FROM terms WHERE document_frequency > 0 OR url_only_frequency > 0")
 	if err != nil {
 		return fmt.Errorf("failed to query terms: %w", err)
 	}
 
-	updateStmt, err := tx.Prepare("UPDATE terms SET idf = ? WHERE term_id = ?")
+	updateStmt, err := tx.Prepare("UPDATE terms SET idf = ?, bm25_idf = ?, bm25f_idf = ? WHERE term_id = ?")
 	if err != nil {
 		rows.Close()
 		return fmt.Errorf("failed to prepare update statement: %w", err)
@@ -185,42 +327,74 @@ func (idb *IndexDB) RecalculateTFIDF() error {
 
 	for rows.Next() {
 		var termID int64
-		var docFreq int
-		if err := rows.Scan(&termID, &docFreq); err != nil {
+		var docFreq, urlOnlyFreq int
+		if err := rows.Scan(&termID, &docFreq, &urlOnlyFreq); err != nil {
 			rows.Close()
 			return fmt.Errorf("failed to scan term: %w", err)
 		}
 
 		// Calculate IDF = log(total_docs / document_frequency)
-		idf := math.Log(float64(totalDocs) / float64(docFreq))
+		var idf, bm25IDF float64
+		if docFreq > 0 {
+			idf = math.Log(float64(totalDocs) / float64(docFreq))
+			bm25IDF = bm25InverseFrequency(totalDocs, docFreq)
+		}
+		bm25fIDF := bm25InverseFrequency(totalDocs, docFreq+urlOnlyFreq)
 
-		if _, err := updateStmt.Exec(idf, termID); err != nil {
+		if _, err := updateStmt.Exec(idf, bm25IDF, bm25fIDF, termID); err != nil {
 			rows.Close()
 			return fmt.Errorf("failed to update IDF for term %d: %w", termID, err)
 		}
 	}
 	rows.Close()
 
-	// Step 2: Update TF and TF-IDF in postings using a single JOIN-based query
+	// Terms whose last document was deleted or changed
+	if _, err := tx.Exec("DELETE FROM terms WHERE document_frequency <= 0 AND url_only_frequency <= 0"); err != nil {
+		return fmt.Errorf("failed to delete unused terms: %w", err)
+	}
+
+	avgDocLength, err := updateAverageLengths(tx)
+	if err != nil {
+		return err
+	}
+
+	// Step 2: Update TF, TF-IDF and BM25 in postings using a single JOIN-based query
 	// TF = term_frequency / doc_length
 	// TF-IDF = TF * IDF
+	// BM25 = BM25 IDF * f * (k1 + 1) / (f + k1 * (1 - b + b * length / avg_doc_length))
+	// BM25 saturates raw occurrences, so f and length are the unweighted
+	// counts in the title, description and content; term_frequency and
+	// doc_length are weighted by field
 	_, err = tx.Exec(`
 		UPDATE postings
 		SET
 			tf = CAST(term_frequency AS REAL) / CAST(doc_stats.doc_length AS REAL),
-			tfidf = (CAST(term_frequency AS REAL) / CAST(doc_stats.doc_length AS REAL)) * terms.idf
+			tfidf = (CAST(term_frequency AS REAL) / CAST(doc_stats.doc_length AS REAL)) * terms.idf,
+			bm25 = terms.bm25_idf * `+bm25Frequency+` * (? + 1) /
+				(`+bm25Frequency+` + ? * (1 - ? + ? * `+bm25Length+` / ?))
 		FROM doc_stats, terms
 		WHERE postings.doc_id = doc_stats.doc_id
 		  AND postings.term_id = terms.term_id
-	`)
+		  AND doc_stats.doc_length > 0
+	`, scoring.K1, scoring.K1, scoring.B, scoring.B, max(avgDocLength, 1))
 	if err != nil {
-		return fmt.Errorf("failed to update TF-IDF values: %w", err)
+		return fmt.Errorf("failed to update scores: %w", err)
 	}
 
 	if _, err := tx.Exec("UPDATE index_metadata SET value = ? WHERE key = 'total_documents'", totalDocs); err != nil {
 		return fmt.Errorf("failed to update total documents: %w", err)
 	}
 
+	if _, err := tx.Exec("INSERT OR REPLACE INTO index_metadata (key, value, updated_at) VALUES ('idf_dirty', 'false', CURRENT_TIMESTAMP)"); err != nil {
+		return fmt.Errorf("failed to clear idf_dirty: %w", err)
+	}
+
+	for key, value := range scoring.metadata() {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO index_metadata (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)", key, value); err != nil {
+			return fmt.Errorf("failed to update %s: %w", key, err)
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -228,6 +402,47 @@ func (idb *IndexDB) RecalculateTFIDF() error {
 	return nil
 }
 
+func bm25InverseFrequency(totalDocs, docFreq int) float64 {
+	return math.Log(1 + (float64(totalDocs-docFreq)+0.5)/(float64(docFreq)+0.5))
+}
+
+// A posting's occurrences and its document's length as BM25 counts them
+const (
+	bm25Frequency = "(postings.title_tf + postings.description_tf + postings.content_tf)"
+	bm25Length    = "(doc_stats.title_length + doc_stats.description_length + doc_stats.content_length)"
+)
+
+// updateAverageLengths stores the average length of documents, which BM25
+// normalizes term frequencies by, and of each field, for BM25F. Returns
+// the average document length.
+func updateAverageLengths(tx *sql.Tx) (float64, error) {
+	var avgDocLength float64
+	var averages [textprocessor.NumFields]float64
+	err := tx.QueryRow(`
+		SELECT COALESCE(AVG(`+bm25Length+`), 0),
+		       COALESCE(AVG(title_length), 0), COALESCE(AVG(description_length), 0),
+		       COALESCE(AVG(content_length), 0), COALESCE(AVG(url_length), 0)
+		FROM doc_stats`,
+	).Scan(&avgDocLength, &averages[textprocessor.FieldTitle], &averages[textprocessor.FieldDescription], &averages[textprocessor.FieldContent], &averages[textprocessor.FieldURL])
+	if err != nil {
+		return 0, fmt.Errorf("failed to average document lengths: %w", err)
+	}
+
+	lengths := map[string]float64{"avg_doc_length": avgDocLength}
+	for field, average := range averages {
+		lengths["avg_"+textprocessor.Field(field).String()+"_length"] = average
+	}
+	for key, average := range lengths {
+		if _, err := tx.Exec(
+			"INSERT OR REPLACE INTO index_metadata (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)",
+			key, fmt.Sprintf("%f", average),
+		); err != nil {
+			return 0, fmt.Errorf("failed to update %s: %w", key, err)
+		}
+	}
+	return avgDocLength, nil
+}
+
 type PreparedStatements struct {
 	insertPage     *sql.Stmt
 	insertDocStats *sql.Stmt
@@ -235,98 +450,90 @@ type PreparedStatements struct {
 	insertTerm     *sql.Stmt
 	updateDF       *sql.Stmt
 	insertPosting  *sql.Stmt
+	decrementDF    *sql.Stmt
+	deletePostings *sql.Stmt
+	deleteDocStats *sql.Stmt
+	deletePage     *sql.Stmt
+	markIDFDirty   *sql.Stmt
 }
 
 func (idb *IndexDB) PrepareStatements(tx *sql.Tx) (*PreparedStatements, error) {
 	stmts := &PreparedStatements{}
-	var err error
-
-	stmts.insertPage, err = tx.Prepare("INSERT OR IGNORE INTO indexed_pages (doc_id, source_url) VALUES (?, ?)")
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare insertPage: %w", err)
-	}
-
-	stmts.insertDocStats, err = tx.Prepare("INSERT OR REPLACE INTO doc_stats (doc_id, doc_length, unique_terms) VALUES (?, ?, ?)")
-	if err != nil {
-		stmts.insertPage.Close()
-		return nil, fmt.Errorf("failed to prepare insertDocStats: %w", err)
-	}
-
-	stmts.getTerm, err = tx.Prepare("SELECT term_id FROM terms WHERE term = ?")
-	if err != nil {
-		stmts.insertPage.Close()
-		stmts.insertDocStats.Close()
-		return nil, fmt.Errorf("failed to prepare getTerm: %w", err)
-	}
-
-	stmts.insertTerm, err = tx.Prepare("INSERT INTO terms (term, document_frequency) VALUES (?, 1)")
-	if err != nil {
-		stmts.insertPage.Close()
-		stmts.insertDocStats.Close()
-		stmts.getTerm.Close()
-		return nil, fmt.Errorf("failed to prepare insertTerm: %w", err)
-	}
 
-	stmts.updateDF, err = tx.Prepare("UPDATE terms SET document_frequency = document_frequency + 1 WHERE term_id = ?")
-	if err != nil {
-		stmts.insertPage.Close()
-		stmts.insertDocStats.Close()
-		stmts.getTerm.Close()
-		stmts.insertTerm.Close()
-		return nil, fmt.Errorf("failed to prepare updateDF: %w", err)
-	}
-
-	stmts.insertPosting, err = tx.Prepare("INSERT INTO postings (term_id, doc_id, term_frequency) VALUES (?, ?, ?)")
-	if err != nil {
-		stmts.insertPage.Close()
-		stmts.insertDocStats.Close()
-		stmts.getTerm.Close()
-		stmts.insertTerm.Close()
-		stmts.updateDF.Close()
-		return nil, fmt.Errorf("failed to prepare insertPosting: %w", err)
+	queries := []struct {
+		name  string
+		stmt  **sql.Stmt
+		query string
+	}{
+		{"insertPage", &stmts.insertPage, "INSERT OR REPLACE INTO indexed_pages (doc_id, source_url, source_version) VALUES (?, ?, ?)"},
+		{"insertDocStats", &stmts.insertDocStats, "INSERT OR REPLACE INTO doc_stats (doc_id, doc_length, unique_terms, title_length, description_length, content_length, url_length) VALUES (?, ?, ?, ?, ?, ?, ?)"},
+		{"getTerm", &stmts.getTerm, "SELECT term_id FROM terms WHERE term = ?"},
+		{"insertTerm", &stmts.insertTerm, "INSERT INTO terms (term, document_frequency, url_only_frequency) VALUES (?, ?, ?)"},
+		{"updateDF", &stmts.updateDF, "UPDATE terms SET document_frequency = document_frequency + ?, url_only_frequency = url_only_frequency + ? WHERE term_id = ?"},
+		{"insertPosting", &stmts.insertPosting, "INSERT INTO postings (term_id, doc_id, term_frequency, positions, title_tf, description_tf, content_tf, url_tf) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"},
+		{"decrementDF", &stmts.decrementDF, "UPDATE terms SET document_frequency = document_frequency - (p.term_frequency > 0), url_only_frequency = url_only_frequency - (p.term_frequency = 0) FROM postings p WHERE p.term_id = terms.term_id AND p.doc_id = ?"},
+		{"deletePostings", &stmts.deletePostings, "DELETE FROM postings WHERE doc_id = ?"},
+		{"deleteDocStats", &stmts.deleteDocStats, "DELETE FROM doc_stats WHERE doc_id = ?"},
+		{"deletePage", &stmts.deletePage, "DELETE FROM indexed_pages WHERE doc_id = ?"},
+		{"markIDFDirty", &stmts.markIDFDirty, "INSERT OR REPLACE INTO index_metadata (key, value, updated_at) VALUES ('idf_dirty', 'true', CURRENT_TIMESTAMP)"},
+	}
+
+	for _, q := range queries {
+		stmt, err := tx.Prepare(q.query)
+		if err != nil {
+			stmts.Close()
+			return nil, fmt.Errorf("failed to prepare %s: %w", q.name, err)
+		}
+		*q.stmt = stmt
 	}
 
 	return stmts, nil
 }
 
 func (ps *PreparedStatements) Close() {
-	if ps.insertPage != nil {
-		ps.insertPage.Close()
-	}
-	if ps.insertDocStats != nil {
-		ps.insertDocStats.Close()
-	}
-	if ps.getTerm != nil {
-		ps.getTerm.Close()
-	}
-	if ps.insertTerm != nil {
-		ps.insertTerm.Close()
-	}
-	if ps.updateDF != nil {
-		ps.updateDF.Close()
-	}
-	if ps.insertPosting != nil {
-		ps.insertPosting.Close()
+	for _, stmt := range []*sql.Stmt{
+		ps.insertPage, ps.insertDocStats, ps.getTerm, ps.insertTerm, ps.updateDF, ps.insertPosting,
+		ps.decrementDF, ps.deletePostings, ps.deleteDocStats, ps.deletePage, ps.markIDFDirty,
+	} {
+		if stmt != nil {
+			stmt.Close()
+		}
 	}
 }
 
-func (idb *IndexDB) SaveDocumentWithStatements(stmts *PreparedStatements, docID int, url string, termFreqs map[string]int, docLength int) error {
-	_, err := stmts.insertPage.Exec(docID, url)
+// SaveDocumentWithStatements adds a document to the index, with a posting
+// for every term of any field. Terms only in the URL get a posting with a
+// term_frequency of 0 for BM25F, and count toward url_only_frequency
+// instead of document_frequency, so they don't skew TF-IDF and BM25. A
+// document that is already indexed must be deleted first.
+func (idb *IndexDB) SaveDocumentWithStatements(stmts *PreparedStatements, docID int, url, version string, doc textprocessor.ProcessedDocument) error {
+	_, err := stmts.insertPage.Exec(docID, url, version)
 	if err != nil {
 		return fmt.Errorf("failed to mark page as indexed: %w", err)
 	}
 
-	_, err = stmts.insertDocStats.Exec(docID, docLength, len(termFreqs))
+	if _, err := stmts.markIDFDirty.Exec(); err != nil {
+		return fmt.Errorf("failed to mark IDF dirty: %w", err)
+	}
+
+	lengths := doc.FieldLengths
+	_, err = stmts.insertDocStats.Exec(docID, doc.TotalTerms, doc.UniqueTerms,
+		lengths[textprocessor.FieldTitle], lengths[textprocessor.FieldDescription], lengths[textprocessor.FieldContent], lengths[textprocessor.FieldURL])
 	if err != nil {
 		return fmt.Errorf("failed to save doc stats: %w", err)
 	}
 
-	for term, freq := range termFreqs {
+	for term, fields := range doc.FieldFrequencies {
 		var termID int64
 
+		docFreq, urlOnlyFreq := 1, 0
+		if doc.TermFrequencies[term] == 0 {
+			docFreq, urlOnlyFreq = 0, 1
+		}
+
 		err := stmts.getTerm.QueryRow(term).Scan(&termID)
 		if err == sql.ErrNoRows {
-			result, err := stmts.insertTerm.Exec(term)
+			result, err := stmts.insertTerm.Exec(term, docFreq, urlOnlyFreq)
 			if err != nil {
 				return fmt.Errorf("failed to insert term %q: %w", term, err)
 			}
@@ -337,13 +544,14 @@ func (idb *IndexDB) SaveDocumentWithStatements(stmts *PreparedStatements, docID
 		} else if err != nil {
 			return fmt.Errorf("failed to query term %q: %w", term, err)
 		} else {
-			_, err = stmts.updateDF.Exec(termID)
+			_, err = stmts.updateDF.Exec(docFreq, urlOnlyFreq, termID)
 			if err != nil {
 				return fmt.Errorf("failed to update document frequency for term %q: %w", term, err)
 			}
 		}
 
-		_, err = stmts.insertPosting.Exec(termID, docID, freq)
+		_, err = stmts.insertPosting.Exec(termID, docID, doc.TermFrequencies[term], positions.Encode(doc.Positions[term]),
+			fields[textprocessor.FieldTitle], fields[textprocessor.FieldDescription], fields[textprocessor.FieldContent], fields[textprocessor.FieldURL])
 		if err != nil {
 			return fmt.Errorf("failed to insert posting for term %q: %w", term, err)
 		}
@@ -352,6 +560,63 @@ func (idb *IndexDB) SaveDocumentWithStatements(stmts *PreparedStatements, docID
 	return nil
 }
 
+// DeleteDocumentWithStatements removes a document's postings and stats
+// and takes it out of its terms' document frequencies. Returns false if
+// the document wasn't indexed.
+func (idb *IndexDB) DeleteDocumentWithStatements(stmts *PreparedStatements, docID int) (bool, error) {
+	if _, err := stmts.decrementDF.Exec(docID); err != nil {
+		return false, fmt.Errorf("failed to update document frequencies: %w", err)
+	}
+
+	if _, err := stmts.deletePostings.Exec(docID); err != nil {
+		return false, fmt.Errorf("failed to delete postings: %w", err)
+	}
+
+	if _, err := stmts.deleteDocStats.Exec(docID); err != nil {
+		return false, fmt.Errorf("failed to delete doc stats: %w", err)
+	}
+
+	result, err := stmts.deletePage.Exec(docID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete indexed page: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil || deleted == 0 {
+		return false, err
+	}
+
+	if _, err := stmts.markIDFDirty.Exec(); err != nil {
+		return false, fmt.Errorf("failed to mark IDF dirty: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteDocument removes one document from the index. IDF and the
+// postings' scores stay stale until RecalculateScores runs.
+func (idb *IndexDB) DeleteDocument(docID int) (bool, error) {
+	tx, err := idb.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts, err := idb.PrepareStatements(tx)
+	if err != nil {
+		return false, err
+	}
+	defer stmts.Close()
+
+	deleted, err := idb.DeleteDocumentWithStatements(stmts, docID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return deleted, nil
+}
+
 func (idb *IndexDB) SaveDocumentInTransaction(tx *sql.Tx, docID int, url string, termFreqs map[string]int, docLength int) error {
 	_, err := tx.Exec(
 		"INSERT OR IGNORE INTO indexed_pages (doc_id, source_url) VALUES (?, ?)",
@@ -5,8 +5,11 @@ const Schema = `
 CREATE TABLE IF NOT EXISTS terms (
     term_id INTEGER PRIMARY KEY AUTOINCREMENT,
     term TEXT UNIQUE NOT NULL,
-    document_frequency INTEGER DEFAULT 0,
-    idf REAL DEFAULT 0
+    document_frequency INTEGER DEFAULT 0, -- documents with the term in the title, description or content
+    idf REAL DEFAULT 0,
+    bm25_idf REAL DEFAULT 0,
+    url_only_frequency INTEGER DEFAULT 0, -- documents with the term only in their URL
+    bm25f_idf REAL DEFAULT 0              -- BM25 IDF over documents with the term in any field
 );
 CREATE INDEX IF NOT EXISTS idx_terms_term ON terms(term);
 
@@ -17,6 +20,12 @@ CREATE TABLE IF NOT EXISTS postings (
     term_frequency INTEGER NOT NULL,
     tf REAL DEFAULT 0,
     tfidf REAL DEFAULT 0,
+    bm25 REAL DEFAULT 0,
+    positions BLOB,                   -- word positions, delta-encoded uvarints (pkg/positions)
+    title_tf INTEGER DEFAULT 0,       -- unweighted occurrences per field, for BM25F
+    description_tf INTEGER DEFAULT 0,
+    content_tf INTEGER DEFAULT 0,
+    url_tf INTEGER DEFAULT 0,
     PRIMARY KEY (term_id, doc_id),
     FOREIGN KEY (term_id) REFERENCES terms(term_id),
     FOREIGN KEY (doc_id) REFERENCES indexed_pages(doc_id)
@@ -26,21 +35,28 @@ CREATE INDEX IF NOT EXISTS idx_postings_doc ON postings(doc_id);
 -- Composite index for fast ranked search queries
 CREATE INDEX IF NOT EXISTS idx_postings_term_tfidf ON postings(term_id, tfidf DESC, doc_id);
 
--- Document statistics: metadata for TF-IDF normalization
+-- Document statistics: metadata for TF-IDF and BM25 normalization
 CREATE TABLE IF NOT EXISTS doc_stats (
     doc_id INTEGER PRIMARY KEY,
     doc_length INTEGER NOT NULL,      -- total number of terms in document
     unique_terms INTEGER NOT NULL,    -- number of unique terms
+    title_length INTEGER DEFAULT 0,   -- terms per field; averages are kept in index_metadata
+    description_length INTEGER DEFAULT 0,
+    content_length INTEGER DEFAULT 0,
+    url_length INTEGER DEFAULT 0,
     indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (doc_id) REFERENCES indexed_pages(doc_id)
 );
 
 -- Track which pages from the spider DB have been indexed
 -- This prevents reprocessing and allows resumable indexing
+-- source_version is the page's content hash (or crawl time) when it was
+-- indexed; a different value in the spider DB means the page changed
 CREATE TABLE IF NOT EXISTS indexed_pages (
     doc_id INTEGER PRIMARY KEY,       -- references pages.id from spider DB
     source_url TEXT NOT NULL,         -- the original URL (for debugging)
-    indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    source_version TEXT NOT NULL DEFAULT ''
 );
 CREATE INDEX IF NOT EXISTS idx_indexed_pages_url ON indexed_pages(source_url);
 
@@ -56,5 +72,13 @@ INSERT OR IGNORE INTO index_metadata (key, value) VALUES
     ('total_documents', '0'),
     ('last_indexed_page_id', '0'),
     ('index_version', '1'),
-    ('indexing_complete', 'false');
+    ('indexing_complete', 'false'),
+    ('idf_dirty', 'false');
+`
+
+// MigratedIndexes cover columns that older indexes get from migrations,
+// so they are created after the columns exist.
+const MigratedIndexes = `
+-- Composite index for fast BM25-ranked search queries
+CREATE INDEX IF NOT EXISTS idx_postings_term_bm25 ON postings(term_id, bm25 DESC, doc_id);
 `
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Ranking models. Pass 2 stores both TF-IDF and BM25 scores in postings,
+// and the query engine computes BM25F from the per-field counts, so every
+// model stays available; the one recorded in index_metadata is the
+// query engine's default.
+const (
+	ModelTFIDF = "tfidf"
+	ModelBM25  = "bm25"
+	ModelBM25F = "bm25f"
+)
+
+type Scoring struct {
+	Model string
+	K1    float64 // how quickly repeated occurrences stop adding to BM25
+	B     float64 // how strongly BM25 normalizes by document length, 0 to 1
+}
+
+func DefaultScoring() Scoring {
+	return Scoring{Model: ModelBM25, K1: 1.2, B: 0.75}
+}
+
+func (s Scoring) Validate() error {
+	var errs []error
+	switch s.Model {
+	case ModelTFIDF, ModelBM25, ModelBM25F:
+	default:
+		errs = append(errs, fmt.Errorf("model must be %s, %s or %s, got %q", ModelTFIDF, ModelBM25, ModelBM25F, s.Model))
+	}
+	if s.K1 < 0 {
+		errs = append(errs, fmt.Errorf("k1 must not be negative, got %v", s.K1))
+	}
+	if s.B < 0 || s.B > 1 {
+		errs = append(errs, fmt.Errorf("b must be between 0 and 1, got %v", s.B))
+	}
+	return errors.Join(errs...)
+}
+
+// What BM25 counts occurrences and lengths from. Indexes scored before it
+// was recorded used the weighted term_frequency and doc_length, and are
+// rescored.
+const bm25Counts = "unweighted"
+
+// metadata is how the scoring settings are recorded in index_metadata.
+func (s Scoring) metadata() map[string]string {
+	return map[string]string{
+		"scoring_model": s.Model,
+		"bm25_k1":       strconv.FormatFloat(s.K1, 'g', -1, 64),
+		"bm25_b":        strconv.FormatFloat(s.B, 'g', -1, 64),
+		"bm25_counts":   bm25Counts,
+	}
+}
+
+// StoredScoring returns the scoring settings the index was last scored
+// with. Settings the index hasn't recorded are the defaults.
+func (idb *IndexDB) StoredScoring() (Scoring, error) {
+	scoring := DefaultScoring()
+
+	model, err := idb.GetMetadata("scoring_model")
+	if err != nil && err != sql.ErrNoRows {
+		return scoring, err
+	}
+	if err == nil {
+		scoring.Model = model
+	}
+
+	for key, dest := range map[string]*float64{"bm25_k1": &scoring.K1, "bm25_b": &scoring.B} {
+		value, err := idb.GetMetadata(key)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return scoring, err
+		}
+		if *dest, err = strconv.ParseFloat(value, 64); err != nil {
+			return scoring, fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+	}
+	return scoring, nil
+}
+
+// ScoresOutdated reports whether postings changed since they were last
+// scored, or were scored with different settings.
+func (idb *IndexDB) ScoresOutdated(s Scoring) (bool, error) {
+	dirty, err := idb.IsIDFDirty()
+	if err != nil || dirty {
+		return dirty, err
+	}
+
+	for key, value := range s.metadata() {
+		stored, err := idb.GetMetadata(key)
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if stored != value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"io"
 	"log"
 	"os"
@@ -9,6 +10,11 @@ import (
 )
 
 func main() {
+	model := flag.String("model", "", "model the query engine ranks with by default: tfidf, bm25 or bm25f (default: the index's, bm25 for a new index)")
+	k1 := flag.Float64("k1", 0, "BM25 k1, how quickly repeated occurrences stop adding to the score (default: the index's, 1.2 for a new index)")
+	b := flag.Float64("b", 0, "BM25 b, how strongly long documents are penalized, 0 to 1 (default: the index's, 0.75 for a new index)")
+	flag.Parse()
+
 	logFile, err := os.OpenFile("indexer.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
 		log.Fatalf("Failed to open log file: %v", err)
@@ -33,6 +39,24 @@ func main() {
 	}
 	defer idx.Close()
 
+	// Only flags given on the command line change the index's settings;
+	// changing them rescores the index
+	scoring := idx.Scoring()
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "model":
+			scoring.Model = *model
+		case "k1":
+			scoring.K1 = *k1
+		case "b":
+			scoring.B = *b
+		}
+	})
+	if err := idx.SetScoring(scoring); err != nil {
+		log.Fatalf("Failed to configure scoring: %v", err)
+	}
+	log.Printf("Scoring: %s (k1=%g, b=%g)", scoring.Model, scoring.K1, scoring.B)
+
 	if err := idx.IndexAll(); err != nil {
 		log.Fatalf("Indexing failed: %v", err)
 	}
@@ -0,0 +1,284 @@
+package indexer_test
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/deidaraiorek/deisearch/indexer/internal/indexer"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type spiderPage struct {
+	id                            int
+	url, title, description, body string
+	errorClass                    string
+}
+
+// newSpiderDB creates a spider database with the pages table the indexer
+// reads.
+func newSpiderDB(t *testing.T, pages []spiderPage) (string, *sql.DB) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "spider.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to open spider database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE pages (
+		id INTEGER PRIMARY KEY,
+		url TEXT NOT NULL,
+		title TEXT,
+		description TEXT,
+		content TEXT,
+		status_code INTEGER,
+		crawled_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		content_hash TEXT,
+		error_class TEXT
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create pages: %v", err)
+	}
+
+	for _, page := range pages {
+		addSpiderPage(t, db, page)
+	}
+	return path, db
+}
+
+func addSpiderPage(t *testing.T, db *sql.DB, page spiderPage) {
+	t.Helper()
+
+	_, err := db.Exec(
+		"INSERT INTO pages (id, url, title, description, content, status_code, content_hash, error_class) VALUES (?, ?, ?, ?, ?, 200, ?, ?)",
+		page.id, page.url, page.title, page.description, page.body, "hash-"+page.body, page.errorClass,
+	)
+	if err != nil {
+		t.Fatalf("Failed to add page %d: %v", page.id, err)
+	}
+}
+
+func runIndexer(t *testing.T, spiderPath, indexPath string, batchSize int) {
+	t.Helper()
+
+	idx, err := indexer.NewIndexer(spiderPath, indexPath, batchSize)
+	if err != nil {
+		t.Fatalf("Failed to create indexer: %v", err)
+	}
+	defer idx.Close()
+
+	if err := idx.IndexAll(); err != nil {
+		t.Fatalf("IndexAll failed: %v", err)
+	}
+}
+
+func openIndex(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to open index: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// indexContents lists every posting as "term doc_id term_frequency", and
+// every term's document frequency.
+func indexContents(t *testing.T, db *sql.DB) (postings []string, frequencies map[string]int) {
+	t.Helper()
+
+	rows, err := db.Query(`
+		SELECT t.term, p.doc_id, p.term_frequency
+		FROM postings p JOIN terms t ON t.term_id = p.term_id
+		ORDER BY t.term, p.doc_id`)
+	if err != nil {
+		t.Fatalf("Failed to list postings: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var term string
+		var docID, freq int
+		if err := rows.Scan(&term, &docID, &freq); err != nil {
+			t.Fatalf("Failed to scan posting: %v", err)
+		}
+		postings = append(postings, fmt.Sprintf("%s %d %d", term, docID, freq))
+	}
+
+	frequencies = make(map[string]int)
+	termRows, err := db.Query("SELECT term, document_frequency FROM terms")
+	if err != nil {
+		t.Fatalf("Failed to list terms: %v", err)
+	}
+	defer termRows.Close()
+	for termRows.Next() {
+		var term string
+		var df int
+		if err := termRows.Scan(&term, &df); err != nil {
+			t.Fatalf("Failed to scan term: %v", err)
+		}
+		frequencies[term] = df
+	}
+	return postings, frequencies
+}
+
+var corpus = []spiderPage{
+	{id: 1, url: "https://example.com/1", title: "Database design", body: "Normalizing tables in a relational database."},
+	{id: 2, url: "https://example.com/2", title: "Search engines", body: "An inverted index maps terms to documents."},
+	{id: 3, url: "https://example.com/3", title: "Crawlers", body: "A crawler fetches pages and follows links."},
+	{id: 4, url: "https://example.com/4", title: "Ranking", description: "BM25 and TF-IDF", body: "Ranking documents for a search query."},
+	{id: 5, url: "https://example.com/5", title: "Not found", body: "This page does not exist.", errorClass: "soft_404"},
+	{id: 6, url: "https://example.com/6", title: "Stemming", body: "Stemming maps indexing and indexed to the same term."},
+	{id: 7, url: "https://example.com/7", title: "Databases", body: "Every database engine stores an index on disk."},
+}
+
+func TestIndexAllMatchesAcrossBatchSizes(t *testing.T) {
+	spiderPath, _ := newSpiderDB(t, corpus)
+
+	var reference []string
+	tests := []struct {
+		name      string
+		batchSize int
+	}{
+		{"one page per batch", 1},
+		{"partial last batch", 3},
+		{"single batch", 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexPath := filepath.Join(t.TempDir(), "index.db")
+			runIndexer(t, spiderPath, indexPath, tt.batchSize)
+
+			index := openIndex(t, indexPath)
+			var docs int
+			if err := index.QueryRow("SELECT COUNT(*) FROM indexed_pages").Scan(&docs); err != nil {
+				t.Fatalf("Failed to count documents: %v", err)
+			}
+			if docs != 6 {
+				t.Errorf("Expected 6 documents without the soft 404, got %d", docs)
+			}
+
+			postings, frequencies := indexContents(t, index)
+			if frequencies["databas"] != 2 {
+				t.Errorf("Expected \"databas\" in 2 documents, got %d", frequencies["databas"])
+			}
+			if _, ok := frequencies["exist"]; ok {
+				t.Error("Terms of the soft 404 should not be indexed")
+			}
+
+			// Workers finish pages in any order, so batches must not change the result
+			if reference == nil {
+				reference = postings
+			} else if !reflect.DeepEqual(postings, reference) {
+				t.Errorf("Postings with batch size %d differ from batch size 1", tt.batchSize)
+			}
+		})
+	}
+}
+
+func TestIndexAllResumesAfterLastPage(t *testing.T) {
+	spiderPath, spiderDB := newSpiderDB(t, corpus[:3])
+	indexPath := filepath.Join(t.TempDir(), "index.db")
+
+	runIndexer(t, spiderPath, indexPath, 2)
+	for _, page := range corpus[3:] {
+		addSpiderPage(t, spiderDB, page)
+	}
+	runIndexer(t, spiderPath, indexPath, 2)
+
+	index := openIndex(t, indexPath)
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"last_indexed_page_id", "7"},
+		{"total_documents", "6"},
+		{"indexing_complete", "true"},
+		{"idf_dirty", "false"},
+	}
+	for _, tt := range tests {
+		var value string
+		if err := index.QueryRow("SELECT value FROM index_metadata WHERE key = ?", tt.key).Scan(&value); err != nil {
+			t.Fatalf("Failed to read %s: %v", tt.key, err)
+		}
+		if value != tt.expected {
+			t.Errorf("Expected %s %s, got %s", tt.key, tt.expected, value)
+		}
+	}
+
+	_, frequencies := indexContents(t, index)
+	if frequencies["databas"] != 2 {
+		t.Errorf("Expected \"databas\" in 2 documents after resuming, got %d", frequencies["databas"])
+	}
+}
+
+func TestIndexAllSyncsChangedAndRemovedPages(t *testing.T) {
+	spiderPath, spiderDB := newSpiderDB(t, corpus)
+	indexPath := filepath.Join(t.TempDir(), "index.db")
+	runIndexer(t, spiderPath, indexPath, 100)
+
+	changes := []string{
+		// Changed: "database" becomes "schema"
+		"UPDATE pages SET content = 'Migrating a schema without downtime.', content_hash = 'hash-v2' WHERE id = 7",
+		// Deleted by the spider
+		"DELETE FROM pages WHERE id = 2",
+		// Flagged as an error page since it was indexed
+		"UPDATE pages SET error_class = 'login_wall' WHERE id = 3",
+	}
+	for _, change := range changes {
+		if _, err := spiderDB.Exec(change); err != nil {
+			t.Fatalf("Failed to change the spider database: %v", err)
+		}
+	}
+	runIndexer(t, spiderPath, indexPath, 100)
+
+	index := openIndex(t, indexPath)
+	_, frequencies := indexContents(t, index)
+
+	tests := []struct {
+		term     string
+		expected int // document frequency, 0 once the term is gone
+	}{
+		{"databas", 2},  // page 1, and page 7's title "Databases"
+		{"schema", 1},   // page 7's new content
+		{"engin", 0},    // only in page 7's old content
+		{"invert", 0},   // only in the deleted page 2
+		{"crawler", 0},  // only in the flagged page 3
+		{"stem", 1},     // page 6, unchanged
+		{"normal", 1},   // page 1, unchanged
+		{"document", 1}, // pages 2 and 4, one of them deleted
+	}
+	for _, tt := range tests {
+		if frequencies[tt.term] != tt.expected {
+			t.Errorf("Expected %q in %d documents, got %d", tt.term, tt.expected, frequencies[tt.term])
+		}
+	}
+
+	var docs, stale int
+	if err := index.QueryRow("SELECT COUNT(*) FROM indexed_pages").Scan(&docs); err != nil {
+		t.Fatalf("Failed to count documents: %v", err)
+	}
+	if docs != 4 {
+		t.Errorf("Expected 4 documents after removing 2, got %d", docs)
+	}
+	err := index.QueryRow("SELECT COUNT(*) FROM postings WHERE doc_id IN (2, 3) OR doc_id NOT IN (SELECT doc_id FROM indexed_pages)").Scan(&stale)
+	if err != nil {
+		t.Fatalf("Failed to count stale postings: %v", err)
+	}
+	if stale != 0 {
+		t.Errorf("Expected the removed pages' postings deleted, got %d", stale)
+	}
+
+	var version string
+	if err := index.QueryRow("SELECT source_version FROM indexed_pages WHERE doc_id = 7").Scan(&version); err != nil {
+		t.Fatalf("Failed to read version: %v", err)
+	}
+	if version != "hash-v2" {
+		t.Errorf("Expected page 7 reindexed at hash-v2, got %q", version)
+	}
+}
@@ -0,0 +1,298 @@
+package storage_test
+
+import (
+	"database/sql"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/deidaraiorek/deisearch/indexer/internal/storage"
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
+)
+
+// newIndex opens an empty index, and a second connection for checking
+// its tables.
+func newIndex(t *testing.T) (*storage.IndexDB, *sql.DB) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "index.db")
+	idb, err := storage.NewIndexDB(path)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	t.Cleanup(func() { idb.Close() })
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to open index: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return idb, db
+}
+
+type document struct {
+	id     int
+	fields textprocessor.DocumentFields
+}
+
+func saveDocuments(t *testing.T, idb *storage.IndexDB, docs ...document) {
+	t.Helper()
+
+	tx, err := idb.BeginTransaction()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmts, err := idb.PrepareStatements(tx)
+	if err != nil {
+		t.Fatalf("Failed to prepare statements: %v", err)
+	}
+	defer stmts.Close()
+
+	processor := textprocessor.NewTextProcessor()
+	for _, doc := range docs {
+		processed := processor.ProcessDocumentWithWeights(doc.fields, 3, 2, 1)
+		if err := idb.SaveDocumentWithStatements(stmts, doc.id, doc.fields.URL, "v1", processed); err != nil {
+			t.Fatalf("Failed to save document %d: %v", doc.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+}
+
+func queryInt(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("Query %q failed: %v", query, err)
+	}
+	return n
+}
+
+func documentFrequency(t *testing.T, db *sql.DB, term string) int {
+	t.Helper()
+
+	var df int
+	err := db.QueryRow("SELECT document_frequency FROM terms WHERE term = ?", term).Scan(&df)
+	if err == sql.ErrNoRows {
+		return -1
+	}
+	if err != nil {
+		t.Fatalf("Failed to read document frequency of %q: %v", term, err)
+	}
+	return df
+}
+
+var documents = []document{
+	{1, textprocessor.DocumentFields{Title: "Database design", Content: "Tables and indexes.", URL: "https://example.com/design"}},
+	{2, textprocessor.DocumentFields{Title: "Database engines", Content: "Storage engines and indexes.", URL: "https://example.com/engines"}},
+	{3, textprocessor.DocumentFields{Title: "Crawling", Content: "Fetching pages.", URL: "https://example.com/crawling"}},
+}
+
+func TestDeleteDocument(t *testing.T) {
+	tests := []struct {
+		name      string
+		docID     int
+		deleted   bool
+		databas   int // document frequency of "databas" after rescoring, -1 once removed
+		design    int
+		remaining int // indexed documents
+	}{
+		{"shared terms keep the other document", 1, true, 1, -1, 2},
+		{"only document with its terms", 3, true, 2, 1, 2},
+		{"not indexed", 42, false, 2, 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idb, db := newIndex(t)
+			saveDocuments(t, idb, documents...)
+			if err := idb.RecalculateScores(storage.DefaultScoring()); err != nil {
+				t.Fatalf("Failed to score: %v", err)
+			}
+
+			deleted, err := idb.DeleteDocument(tt.docID)
+			if err != nil {
+				t.Fatalf("DeleteDocument failed: %v", err)
+			}
+			if deleted != tt.deleted {
+				t.Errorf("Expected deleted %v, got %v", tt.deleted, deleted)
+			}
+
+			if n := queryInt(t, db, "SELECT COUNT(*) FROM postings WHERE doc_id = ?", tt.docID); n != 0 {
+				t.Errorf("Expected postings removed, got %d", n)
+			}
+			if n := queryInt(t, db, "SELECT COUNT(*) FROM doc_stats WHERE doc_id = ?", tt.docID); n != 0 {
+				t.Errorf("Expected doc stats removed, got %d rows", n)
+			}
+
+			dirty, err := idb.IsIDFDirty()
+			if err != nil {
+				t.Fatalf("IsIDFDirty failed: %v", err)
+			}
+			if dirty != tt.deleted {
+				t.Errorf("Expected idf_dirty %v, got %v", tt.deleted, dirty)
+			}
+
+			// Rescoring drops the terms no document has anymore
+			if err := idb.RecalculateScores(storage.DefaultScoring()); err != nil {
+				t.Fatalf("Failed to rescore: %v", err)
+			}
+			if df := documentFrequency(t, db, "databas"); df != tt.databas {
+				t.Errorf("Expected \"databas\" document frequency %d, got %d", tt.databas, df)
+			}
+			if df := documentFrequency(t, db, "design"); df != tt.design {
+				t.Errorf("Expected \"design\" document frequency %d, got %d", tt.design, df)
+			}
+			if n := queryInt(t, db, "SELECT COUNT(*) FROM indexed_pages"); n != tt.remaining {
+				t.Errorf("Expected %d indexed documents, got %d", tt.remaining, n)
+			}
+			if n := queryInt(t, db, "SELECT COUNT(*) FROM postings WHERE term_id NOT IN (SELECT term_id FROM terms)"); n != 0 {
+				t.Errorf("Expected no postings of removed terms, got %d", n)
+			}
+		})
+	}
+}
+
+func TestDeleteAndSaveRestoresFrequencies(t *testing.T) {
+	idb, db := newIndex(t)
+	saveDocuments(t, idb, documents...)
+
+	before := queryInt(t, db, "SELECT SUM(document_frequency) FROM terms")
+	for _, doc := range documents {
+		if _, err := idb.DeleteDocument(doc.id); err != nil {
+			t.Fatalf("Failed to delete document %d: %v", doc.id, err)
+		}
+	}
+	if sum := queryInt(t, db, "SELECT SUM(document_frequency) + SUM(url_only_frequency) FROM terms"); sum != 0 {
+		t.Errorf("Expected every frequency back to 0, got a sum of %d", sum)
+	}
+
+	// Reindexing a changed page deletes and saves it again
+	saveDocuments(t, idb, documents...)
+	if after := queryInt(t, db, "SELECT SUM(document_frequency) FROM terms"); after != before {
+		t.Errorf("Expected document frequencies to sum to %d after saving again, got %d", before, after)
+	}
+}
+
+func termFrequencies(t *testing.T, db *sql.DB, term string) (df, urlOnly int) {
+	t.Helper()
+
+	err := db.QueryRow("SELECT document_frequency, url_only_frequency FROM terms WHERE term = ?", term).Scan(&df, &urlOnly)
+	if err == sql.ErrNoRows {
+		return -1, -1
+	}
+	if err != nil {
+		t.Fatalf("Failed to read frequencies of %q: %v", term, err)
+	}
+	return df, urlOnly
+}
+
+var urlDocument = document{4, textprocessor.DocumentFields{Title: "Tips", Content: "Short notes.", URL: "https://example.com/database-tips"}}
+
+func TestURLOnlyTerms(t *testing.T) {
+	idb, db := newIndex(t)
+	saveDocuments(t, idb, append(documents, urlDocument)...)
+	if err := idb.RecalculateScores(storage.DefaultScoring()); err != nil {
+		t.Fatalf("Failed to score: %v", err)
+	}
+
+	tests := []struct {
+		term    string
+		df      int
+		urlOnly int
+	}{
+		{"databas", 2, 1}, // titles of 1 and 2, URL of 4
+		{"design", 1, 0},  // title and URL of 1
+		{"tip", 1, 0},     // title and URL of 4
+		{"exampl", 0, 4},  // every URL
+	}
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			df, urlOnly := termFrequencies(t, db, tt.term)
+			if df != tt.df || urlOnly != tt.urlOnly {
+				t.Errorf("Expected document frequency %d and URL-only %d, got %d and %d", tt.df, tt.urlOnly, df, urlOnly)
+			}
+		})
+	}
+
+	// URL-only postings are kept for BM25F but score nothing for TF-IDF and BM25
+	var termFreq, urlTF int
+	var tfidf, bm25 float64
+	err := db.QueryRow(`
+		SELECT p.term_frequency, p.url_tf, p.tfidf, p.bm25
+		FROM postings p JOIN terms t ON t.term_id = p.term_id
+		WHERE t.term = 'databas' AND p.doc_id = 4`,
+	).Scan(&termFreq, &urlTF, &tfidf, &bm25)
+	if err != nil {
+		t.Fatalf("Failed to read the URL-only posting: %v", err)
+	}
+	if termFreq != 0 || urlTF != 1 || tfidf != 0 || bm25 != 0 {
+		t.Errorf("Expected an unscored posting with url_tf 1, got term_frequency %d, url_tf %d, tfidf %v, bm25 %v", termFreq, urlTF, tfidf, bm25)
+	}
+
+	// BM25F counts every document with the term, BM25 only those outside the URL
+	var bm25IDF, bm25fIDF float64
+	if err := db.QueryRow("SELECT bm25_idf, bm25f_idf FROM terms WHERE term = 'databas'").Scan(&bm25IDF, &bm25fIDF); err != nil {
+		t.Fatalf("Failed to read IDF: %v", err)
+	}
+	if expected := math.Log(1 + (4-2+0.5)/(2+0.5)); math.Abs(bm25IDF-expected) > 1e-9 {
+		t.Errorf("Expected bm25_idf %v, got %v", expected, bm25IDF)
+	}
+	if expected := math.Log(1 + (4-3+0.5)/(3+0.5)); math.Abs(bm25fIDF-expected) > 1e-9 {
+		t.Errorf("Expected bm25f_idf %v, got %v", expected, bm25fIDF)
+	}
+
+	// Deleting the document takes it out of the URL-only frequency
+	if _, err := idb.DeleteDocument(urlDocument.id); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if df, urlOnly := termFrequencies(t, db, "databas"); df != 2 || urlOnly != 0 {
+		t.Errorf("Expected frequencies 2 and 0 after deleting, got %d and %d", df, urlOnly)
+	}
+}
+
+func TestMigrationMovesURLOnlyFrequencies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	idb, err := storage.NewIndexDB(path)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	saveDocuments(t, idb, append(documents, urlDocument)...)
+	idb.Close()
+
+	// Indexes from before url_only_frequency counted URL-only postings in
+	// document_frequency
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to open index: %v", err)
+	}
+	defer db.Close()
+	for _, statement := range []string{
+		"UPDATE terms SET document_frequency = document_frequency + url_only_frequency",
+		"ALTER TABLE terms DROP COLUMN url_only_frequency",
+		"ALTER TABLE terms DROP COLUMN bm25f_idf",
+		"UPDATE index_metadata SET value = 'false' WHERE key = 'idf_dirty'",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Failed to downgrade the index: %v", err)
+		}
+	}
+
+	idb, err = storage.NewIndexDB(path)
+	if err != nil {
+		t.Fatalf("Failed to migrate index: %v", err)
+	}
+	defer idb.Close()
+
+	if df, urlOnly := termFrequencies(t, db, "databas"); df != 2 || urlOnly != 1 {
+		t.Errorf("Expected frequencies 2 and 1 after migrating, got %d and %d", df, urlOnly)
+	}
+	if dirty, err := idb.IsIDFDirty(); err != nil || !dirty {
+		t.Errorf("Expected the migration to schedule rescoring, got %v (%v)", dirty, err)
+	}
+}
@@ -0,0 +1,169 @@
+package storage_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/deidaraiorek/deisearch/indexer/internal/storage"
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
+)
+
+func TestScoringValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		scoring storage.Scoring
+		valid   bool
+	}{
+		{"defaults", storage.DefaultScoring(), true},
+		{"tfidf", storage.Scoring{Model: storage.ModelTFIDF, K1: 1.2, B: 0.75}, true},
+		{"no length normalization", storage.Scoring{Model: storage.ModelBM25, K1: 2, B: 0}, true},
+		{"unknown model", storage.Scoring{Model: "bm26", K1: 1.2, B: 0.75}, false},
+		{"negative k1", storage.Scoring{Model: storage.ModelBM25, K1: -0.1, B: 0.75}, false},
+		{"b above 1", storage.Scoring{Model: storage.ModelBM25F, K1: 1.2, B: 1.1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.scoring.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestRecalculateScoresBM25(t *testing.T) {
+	docs := []document{
+		{1, textprocessor.DocumentFields{Title: "Rust", Content: "Rust ownership and borrowing in Rust programs."}},
+		{2, textprocessor.DocumentFields{Title: "Languages", Description: "Rust and Go", Content: "Comparing compiled languages for services and tools."}},
+		{3, textprocessor.DocumentFields{Title: "Databases", Content: "Pages and indexes.", URL: "https://example.com/rust"}},
+	}
+
+	tests := []struct {
+		name    string
+		scoring storage.Scoring
+	}{
+		{"defaults", storage.DefaultScoring()},
+		{"no length normalization", storage.Scoring{Model: storage.ModelBM25, K1: 2, B: 0}},
+		{"full length normalization", storage.Scoring{Model: storage.ModelBM25F, K1: 0.9, B: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idb, db := newIndex(t)
+			saveDocuments(t, idb, docs...)
+			if err := idb.RecalculateScores(tt.scoring); err != nil {
+				t.Fatalf("RecalculateScores failed: %v", err)
+			}
+
+			// BM25 counts occurrences and lengths unweighted, over the
+			// title, description and content
+			processor := textprocessor.NewTextProcessor()
+			lengths := make([]float64, len(docs))
+			avgLength := 0.0
+			for i, doc := range docs {
+				l := processor.ProcessDocument(doc.fields).FieldLengths
+				lengths[i] = float64(l[textprocessor.FieldTitle] + l[textprocessor.FieldDescription] + l[textprocessor.FieldContent])
+				avgLength += lengths[i] / float64(len(docs))
+			}
+
+			// "rust" is in the title and content of 1 and the description of
+			// 2; only in the URL of 3
+			idf := math.Log(1 + (3-2+0.5)/(2+0.5))
+			occurrences := []float64{3, 1, 0}
+
+			for i, doc := range docs {
+				f := occurrences[i]
+				expected := idf * f * (tt.scoring.K1 + 1) / (f + tt.scoring.K1*(1-tt.scoring.B+tt.scoring.B*lengths[i]/avgLength))
+
+				var bm25 float64
+				err := db.QueryRow(`
+					SELECT p.bm25 FROM postings p JOIN terms t ON t.term_id = p.term_id
+					WHERE t.term = 'rust' AND p.doc_id = ?`, doc.id,
+				).Scan(&bm25)
+				if err != nil {
+					t.Fatalf("Failed to read BM25 of doc %d: %v", doc.id, err)
+				}
+				if math.Abs(bm25-expected) > 1e-4 {
+					t.Errorf("Expected doc %d to score %v, got %v", doc.id, expected, bm25)
+				}
+			}
+
+			stored, err := idb.GetMetadata("avg_doc_length")
+			if err != nil {
+				t.Fatalf("Failed to read avg_doc_length: %v", err)
+			}
+			avg, err := strconv.ParseFloat(stored, 64)
+			if err != nil || math.Abs(avg-avgLength) > 1e-4 {
+				t.Errorf("Expected avg_doc_length %v, got %s", avgLength, stored)
+			}
+		})
+	}
+}
+
+func TestScoresOutdated(t *testing.T) {
+	scored := storage.DefaultScoring()
+
+	tests := []struct {
+		name     string
+		change   func(t *testing.T, idb *storage.IndexDB)
+		scoring  storage.Scoring
+		outdated bool
+	}{
+		{"same settings", func(t *testing.T, idb *storage.IndexDB) {}, scored, false},
+		{"other k1", func(t *testing.T, idb *storage.IndexDB) {}, storage.Scoring{Model: scored.Model, K1: 2, B: scored.B}, true},
+		{"other default model", func(t *testing.T, idb *storage.IndexDB) {}, storage.Scoring{Model: storage.ModelTFIDF, K1: scored.K1, B: scored.B}, true},
+		{"new document", func(t *testing.T, idb *storage.IndexDB) {
+			saveDocuments(t, idb, document{4, textprocessor.DocumentFields{Title: "Tips", Content: "Short notes."}})
+		}, scored, true},
+		{"scored with weighted counts", func(t *testing.T, idb *storage.IndexDB) {
+			if err := idb.SetMetadata("bm25_counts", "weighted"); err != nil {
+				t.Fatalf("Failed to set metadata: %v", err)
+			}
+		}, scored, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idb, _ := newIndex(t)
+			saveDocuments(t, idb, documents...)
+			if err := idb.RecalculateScores(scored); err != nil {
+				t.Fatalf("RecalculateScores failed: %v", err)
+			}
+
+			tt.change(t, idb)
+			outdated, err := idb.ScoresOutdated(tt.scoring)
+			if err != nil {
+				t.Fatalf("ScoresOutdated failed: %v", err)
+			}
+			if outdated != tt.outdated {
+				t.Errorf("Expected outdated %v, got %v", tt.outdated, outdated)
+			}
+		})
+	}
+}
+
+func TestStoredScoring(t *testing.T) {
+	idb, _ := newIndex(t)
+
+	stored, err := idb.StoredScoring()
+	if err != nil {
+		t.Fatalf("StoredScoring failed: %v", err)
+	}
+	if stored != storage.DefaultScoring() {
+		t.Errorf("Expected the defaults for a new index, got %+v", stored)
+	}
+
+	custom := storage.Scoring{Model: storage.ModelBM25F, K1: 1.6, B: 0.4}
+	saveDocuments(t, idb, documents...)
+	if err := idb.RecalculateScores(custom); err != nil {
+		t.Fatalf("RecalculateScores failed: %v", err)
+	}
+	stored, err = idb.StoredScoring()
+	if err != nil {
+		t.Fatalf("StoredScoring failed: %v", err)
+	}
+	if stored != custom {
+		t.Errorf("Expected %+v, got %+v", custom, stored)
+	}
+}
@@ -0,0 +1,41 @@
+// Package positions encodes the word positions of a term in a document
+// for the postings table: each position as the gap from the previous
+// one, as a uvarint. Gaps are small, so most take a single byte.
+package positions
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrCorrupt = errors.New("corrupt positions")
+
+// Encode packs ascending positions. Returns nil for none.
+func Encode(positions []int) []byte {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	buf := make([]byte, 0, len(positions)+4)
+	previous := 0
+	for _, position := range positions {
+		buf = binary.AppendUvarint(buf, uint64(position-previous))
+		previous = position
+	}
+	return buf
+}
+
+func Decode(data []byte) ([]int, error) {
+	positions := make([]int, 0, len(data))
+	previous := 0
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, ErrCorrupt
+		}
+		previous += int(delta)
+		positions = append(positions, previous)
+		data = data[n:]
+	}
+	return positions, nil
+}
@@ -0,0 +1,72 @@
+package positions_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/deidaraiorek/deisearch/pkg/positions"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions []int
+		size      int // encoded bytes
+	}{
+		{"single position", []int{0}, 1},
+		{"adjacent words", []int{3, 4, 5}, 3},
+		{"repeated position", []int{7, 7}, 2},
+		{"gap over one byte", []int{1, 200}, 3},
+		{"field gap", []int{2, 103, 104}, 3},
+		{"large positions", []int{100000, 100001, 5000000}, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := positions.Encode(tt.positions)
+			if len(data) != tt.size {
+				t.Errorf("Expected %d bytes, got %d", tt.size, len(data))
+			}
+
+			decoded, err := positions.Decode(data)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.positions) {
+				t.Errorf("Expected %v, got %v", tt.positions, decoded)
+			}
+		})
+	}
+}
+
+func TestEncodeNone(t *testing.T) {
+	if data := positions.Encode(nil); data != nil {
+		t.Errorf("Expected nil for no positions, got %v", data)
+	}
+
+	// URL-only postings store NULL
+	decoded, err := positions.Decode(nil)
+	if err != nil || len(decoded) != 0 {
+		t.Errorf("Expected no positions from nil, got %v (%v)", decoded, err)
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated varint", []byte{0x80}},
+		{"truncated after a position", []byte{0x05, 0xff}},
+		{"overflow", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := positions.Decode(tt.data); !errors.Is(err, positions.ErrCorrupt) {
+				t.Errorf("Expected ErrCorrupt, got %v", err)
+			}
+		})
+	}
+}
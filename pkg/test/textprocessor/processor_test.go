@@ -0,0 +1,105 @@
+package textprocessor_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
+)
+
+func TestDocumentPositions(t *testing.T) {
+	processor := textprocessor.NewTextProcessor()
+
+	tests := []struct {
+		name     string
+		doc      textprocessor.DocumentFields
+		weights  [3]int // title, description, content
+		expected map[string][]int
+	}{
+		{
+			name:     "stopwords keep their positions",
+			doc:      textprocessor.DocumentFields{Content: "the state of the art"},
+			weights:  [3]int{3, 2, 1},
+			expected: map[string][]int{"state": {1}, "art": {4}},
+		},
+		{
+			name:    "fields are 100 positions apart",
+			doc:     textprocessor.DocumentFields{Title: "Database design", Description: "Schemas", Content: "Database design rules"},
+			weights: [3]int{3, 2, 1},
+			// title 0-1, description at 1+100, content from 101+100
+			expected: map[string][]int{"databas": {0, 201}, "design": {1, 202}, "schema": {101}, "rule": {203}},
+		},
+		{
+			name:     "empty fields take no room",
+			doc:      textprocessor.DocumentFields{Content: "Database design"},
+			weights:  [3]int{3, 2, 1},
+			expected: map[string][]int{"databas": {0}, "design": {1}},
+		},
+		{
+			name:     "unweighted fields have no positions",
+			doc:      textprocessor.DocumentFields{Title: "Database", Description: "Schemas", Content: "design"},
+			weights:  [3]int{1, 0, 1},
+			expected: map[string][]int{"databas": {0}, "design": {100}},
+		},
+		{
+			name:     "URLs have no positions",
+			doc:      textprocessor.DocumentFields{Content: "design", URL: "https://www.example.com/database"},
+			weights:  [3]int{3, 2, 1},
+			expected: map[string][]int{"design": {0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := processor.ProcessDocumentWithWeights(tt.doc, tt.weights[0], tt.weights[1], tt.weights[2])
+			if !reflect.DeepEqual(doc.Positions, tt.expected) {
+				t.Errorf("Expected positions %v, got %v", tt.expected, doc.Positions)
+			}
+		})
+	}
+}
+
+func TestFieldFrequencies(t *testing.T) {
+	processor := textprocessor.NewTextProcessor()
+	doc := processor.ProcessDocumentWithWeights(textprocessor.DocumentFields{
+		Title:       "Database design",
+		Description: "Designing a database",
+		Content:     "Tables, indexes and database design.",
+		URL:         "https://www.example.com/blog/database-tips",
+	}, 3, 2, 1)
+
+	tests := []struct {
+		term     string
+		fields   textprocessor.FieldCounts // title, description, content, URL
+		weighted int
+	}{
+		{"databas", textprocessor.FieldCounts{1, 1, 1, 1}, 6},
+		{"design", textprocessor.FieldCounts{1, 1, 1, 0}, 6},
+		{"tabl", textprocessor.FieldCounts{0, 0, 1, 0}, 1},
+		{"blog", textprocessor.FieldCounts{0, 0, 0, 1}, 0},
+		{"tip", textprocessor.FieldCounts{0, 0, 0, 1}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			if fields := doc.FieldFrequencies[tt.term]; fields != tt.fields {
+				t.Errorf("Expected field counts %v, got %v", tt.fields, fields)
+			}
+			if weighted := doc.TermFrequencies[tt.term]; weighted != tt.weighted {
+				t.Errorf("Expected weighted frequency %d, got %d", tt.weighted, weighted)
+			}
+		})
+	}
+
+	// "www." and the scheme are dropped from the URL
+	expectedLengths := textprocessor.FieldCounts{2, 2, 4, 5}
+	if doc.FieldLengths != expectedLengths {
+		t.Errorf("Expected field lengths %v, got %v", expectedLengths, doc.FieldLengths)
+	}
+	if doc.TotalTerms != 3*2+2*2+4 {
+		t.Errorf("Expected URL terms left out of the weighted total, got %d", doc.TotalTerms)
+	}
+	if _, ok := doc.Positions["blog"]; ok {
+		t.Error("URL terms should have no positions")
+	}
+}
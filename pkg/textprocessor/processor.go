@@ -18,16 +18,30 @@ func NewTextProcessor() *TextProcessor {
 	}
 }
 
+// Positions of a document's fields are spaced this far apart, so a phrase
+// can't match across the end of the title and the start of the content.
+const fieldPositionGap = 100
+
 func (tp *TextProcessor) Process(text string) []string {
-	tokens := tp.tokenizer.Tokenize(text)
+	tokens := tp.ProcessWithPositions(text)
 
 	stemmed := make([]string, len(tokens))
 	for i, token := range tokens {
-		stemmed[i] = tp.stemmer.Stem(token)
+		stemmed[i] = token.Text
 	}
 	return stemmed
 }
 
+// ProcessWithPositions stems the text's tokens, keeping the word position
+// of each.
+func (tp *TextProcessor) ProcessWithPositions(text string) []tokenizer.Token {
+	tokens := tp.tokenizer.TokenizeWithPositions(text)
+	for i := range tokens {
+		tokens[i].Text = tp.stemmer.Stem(tokens[i].Text)
+	}
+	return tokens
+}
+
 func (tp *TextProcessor) ProcessToFrequency(text string) map[string]int {
 	tokens := tp.Process(text)
 
@@ -43,60 +57,92 @@ type DocumentFields struct {
 	Title       string
 	Description string
 	Content     string
+	URL         string
 }
 
+// Field is a part of a document whose terms are counted separately, so
+// ranking can weight a title match differently from a body match.
+type Field int
+
+const (
+	FieldTitle Field = iota
+	FieldDescription
+	FieldContent
+	FieldURL
+	NumFields
+)
+
+var fieldNames = [NumFields]string{"title", "description", "content", "url"}
+
+func (f Field) String() string {
+	return fieldNames[f]
+}
+
+// FieldCounts holds one count per field, indexed by Field.
+type FieldCounts [NumFields]int
+
 type ProcessedDocument struct {
-	TermFrequencies map[string]int
-	TotalTerms      int
-	UniqueTerms     int
+	TermFrequencies  map[string]int         // weighted sum over title, description and content
+	FieldFrequencies map[string]FieldCounts // unweighted, per field, including the URL
+	FieldLengths     FieldCounts            // terms in each field
+	Positions        map[string][]int       // ascending word positions of each term
+	TotalTerms       int
+	UniqueTerms      int
 }
 
 func (tp *TextProcessor) ProcessDocument(doc DocumentFields) ProcessedDocument {
-	// Use strings.Builder for efficient string concatenation
-	var sb strings.Builder
-	sb.Grow(len(doc.Title) + len(doc.Description) + len(doc.Content) + 2)
-	sb.WriteString(doc.Title)
-	sb.WriteString(" ")
-	sb.WriteString(doc.Description)
-	sb.WriteString(" ")
-	sb.WriteString(doc.Content)
-
-	termFreq := tp.ProcessToFrequency(sb.String())
-	totalTerms := 0
-	for _, freq := range termFreq {
-		totalTerms += freq
-	}
-
-	return ProcessedDocument{
-		TermFrequencies: termFreq,
-		TotalTerms:      totalTerms,
-		UniqueTerms:     len(termFreq),
-	}
+	return tp.ProcessDocumentWithWeights(doc, 1, 1, 1)
 }
 
+// ProcessDocumentWithWeights counts each field's terms times the field's
+// weight, and every field's terms separately. Positions are unweighted:
+// the title comes first, then the description and content, each starting
+// fieldPositionGap past the last word of the previous field. URLs have no
+// positions and don't count toward the weighted frequencies.
 func (tp *TextProcessor) ProcessDocumentWithWeights(doc DocumentFields, titleWeight, descWeight, contentWeight int) ProcessedDocument {
 	termFreq := make(map[string]int)
-
-	if doc.Title != "" && titleWeight > 0 {
-		titleTerms := tp.ProcessToFrequency(doc.Title)
-		for term, freq := range titleTerms {
-			termFreq[term] += freq * titleWeight
+	fieldFreq := make(map[string]FieldCounts)
+	positions := make(map[string][]int)
+	var fieldLengths FieldCounts
+
+	offset := 0
+	for _, field := range []struct {
+		field  Field
+		text   string
+		weight int
+	}{
+		{FieldTitle, doc.Title, titleWeight},
+		{FieldDescription, doc.Description, descWeight},
+		{FieldContent, doc.Content, contentWeight},
+	} {
+		if field.text == "" {
+			continue
 		}
-	}
 
-	if doc.Description != "" && descWeight > 0 {
-		descTerms := tp.ProcessToFrequency(doc.Description)
-		for term, freq := range descTerms {
-			termFreq[term] += freq * descWeight
+		tokens := tp.ProcessWithPositions(field.text)
+		for _, token := range tokens {
+			counts := fieldFreq[token.Text]
+			counts[field.field]++
+			fieldFreq[token.Text] = counts
+
+			if field.weight > 0 {
+				termFreq[token.Text] += field.weight
+				positions[token.Text] = append(positions[token.Text], offset+token.Position)
+			}
+		}
+		fieldLengths[field.field] = len(tokens)
+		if len(tokens) > 0 && field.weight > 0 {
+			offset += tokens[len(tokens)-1].Position + fieldPositionGap
 		}
 	}
 
-	if doc.Content != "" && contentWeight > 0 {
-		contentTerms := tp.ProcessToFrequency(doc.Content)
-		for term, freq := range contentTerms {
-			termFreq[term] += freq * contentWeight
-		}
+	urlTerms := tp.Process(urlText(doc.URL))
+	for _, term := range urlTerms {
+		counts := fieldFreq[term]
+		counts[FieldURL]++
+		fieldFreq[term] = counts
 	}
+	fieldLengths[FieldURL] = len(urlTerms)
 
 	totalTerms := 0
 	for _, freq := range termFreq {
@@ -104,8 +150,20 @@ func (tp *TextProcessor) ProcessDocumentWithWeights(doc DocumentFields, titleWei
 	}
 
 	return ProcessedDocument{
-		TermFrequencies: termFreq,
-		TotalTerms:      totalTerms,
-		UniqueTerms:     len(termFreq),
+		TermFrequencies:  termFreq,
+		FieldFrequencies: fieldFreq,
+		FieldLengths:     fieldLengths,
+		Positions:        positions,
+		TotalTerms:       totalTerms,
+		UniqueTerms:      len(termFreq),
 	}
 }
+
+// urlText drops the parts every URL shares, leaving the host and path
+// words ("https://www.example.com/blog/db-design" -> "example.com/blog/db-design").
+func urlText(rawURL string) string {
+	text := strings.ToLower(rawURL)
+	text = strings.TrimPrefix(text, "https://")
+	text = strings.TrimPrefix(text, "http://")
+	return strings.TrimPrefix(text, "www.")
+}
@@ -20,13 +20,31 @@ func NewTokenizer() *Tokenizer {
 	}
 }
 
+// Token is a word with its position among all words of the text. Dropped
+// stopwords still take up a position, so distances between tokens are the
+// distances in the original text.
+type Token struct {
+	Text     string
+	Position int
+}
+
 func (t *Tokenizer) Tokenize(text string) []string {
+	tokens := t.TokenizeWithPositions(text)
+
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Text
+	}
+	return words
+}
+
+func (t *Tokenizer) TokenizeWithPositions(text string) []Token {
 	normalized := t.normalize(text)
 	words := t.split(normalized)
 
-	tokens := make([]string, 0)
+	tokens := make([]Token, 0)
 
-	for _, word := range words {
+	for position, word := range words {
 		if word == "" {
 			continue
 		}
@@ -43,7 +61,7 @@ func (t *Tokenizer) Tokenize(text string) []string {
 			continue
 		}
 
-		tokens = append(tokens, word)
+		tokens = append(tokens, Token{Text: word, Position: position})
 	}
 	return tokens
 }
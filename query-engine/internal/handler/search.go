@@ -4,9 +4,11 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
@@ -31,8 +33,60 @@ var (
 	cache        = &SearchCache{results: make(map[string][]CachedResult)}
 	indexReader  *storage.IndexReader
 	spiderReader *storage.SpiderReader
+	defaultModel string // "" uses the model the indexer recorded
+	bm25fParams  = storage.DefaultBM25FParams()
+	bm25Params   *storage.BM25Params // nil uses the scores the indexer computed
 )
 
+// SetDefaultModel ranks queries that don't ask for a model with model
+// instead of the one the indexer recorded.
+func SetDefaultModel(model string) error {
+	switch model {
+	case storage.ModelTFIDF, storage.ModelBM25, storage.ModelBM25F:
+	default:
+		return fmt.Errorf("invalid model %q, expected tfidf, bm25 or bm25f", model)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	defaultModel = model
+	return nil
+}
+
+// IndexBM25Params returns the k1 and b the indexer scored BM25 with.
+func IndexBM25Params() (storage.BM25Params, error) {
+	return indexReader.BM25Params()
+}
+
+// SetBM25Params ranks BM25 queries with k1 and b computed at query time
+// instead of the indexer's precomputed scores. Cached results ranked with
+// the old ones are dropped.
+func SetBM25Params(params storage.BM25Params) error {
+	if err := params.Validate(); err != nil {
+		return fmt.Errorf("invalid BM25 parameters: %w", err)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	bm25Params = &params
+	cache.results = make(map[string][]CachedResult)
+	return nil
+}
+
+// SetBM25FParams replaces the field weights and parameters used by BM25F.
+// Cached results ranked with the old ones are dropped.
+func SetBM25FParams(params storage.BM25FParams) error {
+	if err := params.Validate(); err != nil {
+		return fmt.Errorf("invalid BM25F parameters: %w", err)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	bm25fParams = params
+	cache.results = make(map[string][]CachedResult)
+	return nil
+}
+
 func InitReaders(indexDBPath, spiderDBPath, pagerankDBPath string) error {
 	var err error
 	indexReader, err = storage.NewIndexReader(indexDBPath)
@@ -82,13 +136,39 @@ func HandleSearch(w http.ResponseWriter, r *http.Request) {
 		page = parsedPage
 	}
 
+	// Without a model, rank with the configured default or the way the
+	// indexer recorded
+	model := r.URL.Query().Get("model")
+	cache.mu.RLock()
+	fallback := defaultModel
+	cache.mu.RUnlock()
+	switch {
+	case model == "" && fallback != "":
+		model = fallback
+	case model == "":
+		var err error
+		model, err = indexReader.ScoringModel()
+		if err != nil {
+			http.Error(w, "Failed to read index metadata", http.StatusInternalServerError)
+			return
+		}
+	case model == storage.ModelTFIDF, model == storage.ModelBM25, model == storage.ModelBM25F:
+	default:
+		http.Error(w, "Invalid model, expected tfidf, bm25 or bm25f", http.StatusBadRequest)
+		return
+	}
+
 	processor := textprocessor.NewTextProcessor()
 	terms := processor.Process(query)
 
+	// "database design" in quotes only matches the words next to each other
+	phrase := len(query) > 2 && strings.HasPrefix(query, `"`) && strings.HasSuffix(query, `"`) && len(terms) > 1
+
 	if len(terms) == 0 {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"query":   query,
+			"model":   model,
 			"results": []interface{}{},
 			"total":   0,
 			"page":    page,
@@ -99,23 +179,39 @@ func HandleSearch(w http.ResponseWriter, r *http.Request) {
 	limit := 10
 	offset := (page - 1) * limit
 
-	cacheKey := generateCacheKey(terms)
+	cacheKey := model + ":" + generateCacheKey(terms)
+	if phrase {
+		cacheKey = "phrase:" + cacheKey
+	}
 
 	cache.mu.RLock()
 	cachedResults, found := cache.results[cacheKey]
+	fieldParams, params := bm25fParams, bm25Params
 	cache.mu.RUnlock()
 
 	if !found {
-		termIDs, err := indexReader.GetTermIDs(terms)
-		if err != nil {
-			http.Error(w, "Failed to get term IDs", http.StatusInternalServerError)
-			return
-		}
+		ranking := storage.Ranking{Model: model, BM25: params, BM25F: fieldParams}
 
-		searchResults, err := indexReader.SearchDocuments(termIDs)
-		if err != nil {
-			http.Error(w, "Failed to search documents", http.StatusInternalServerError)
-			return
+		var searchResults []storage.SearchResult
+		if phrase {
+			var err error
+			searchResults, err = indexReader.SearchPhrase(processor.ProcessWithPositions(query), ranking)
+			if err != nil {
+				http.Error(w, "Failed to search documents", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			termIDs, err := indexReader.GetTermIDs(terms)
+			if err != nil {
+				http.Error(w, "Failed to get term IDs", http.StatusInternalServerError)
+				return
+			}
+
+			searchResults, err = indexReader.Search(termIDs, ranking)
+			if err != nil {
+				http.Error(w, "Failed to search documents", http.StatusInternalServerError)
+				return
+			}
 		}
 
 		docIDs := make([]int64, len(searchResults))
@@ -132,7 +228,7 @@ func HandleSearch(w http.ResponseWriter, r *http.Request) {
 		cachedResults = make([]CachedResult, 0, len(searchResults))
 		for _, result := range searchResults {
 			if pageInfo, ok := pages[result.DocID]; ok {
-				// Use TF-IDF score directly
+				// Use the model's score directly
 				finalScore := result.Score
 
 				content := pageInfo.Content
@@ -167,6 +263,7 @@ func HandleSearch(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"query":   query,
+			"model":   model,
 			"results": []interface{}{},
 			"total":   total,
 			"page":    page,
@@ -183,6 +280,7 @@ func HandleSearch(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"query":   query,
+		"model":   model,
 		"results": pageResults,
 		"total":   total,
 		"page":    page,
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Ranking models for keyword search
+const (
+	ModelTFIDF = "tfidf"
+	ModelBM25  = "bm25"
+	ModelBM25F = "bm25f"
+)
+
+// ScoringModel returns the default ranking model recorded by the indexer.
+// Indexes built before BM25 existed only have TF-IDF scores.
+func (ir *IndexReader) ScoringModel() (string, error) {
+	var model string
+	err := ir.db.QueryRow("SELECT value FROM index_metadata WHERE key = 'scoring_model'").Scan(&model)
+	if err == sql.ErrNoRows {
+		return ModelTFIDF, nil
+	}
+	return model, err
+}
+
+func (ir *IndexReader) metadataFloat(key string) (float64, error) {
+	var value string
+	if err := ir.db.QueryRow("SELECT value FROM index_metadata WHERE key = ?", key).Scan(&value); err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return f, nil
+}
+
+type BM25Params struct {
+	K1 float64 // how quickly repeated occurrences stop adding to the score
+	B  float64 // how strongly frequencies are normalized by document length, 0 to 1
+}
+
+func (p BM25Params) Validate() error {
+	var errs []error
+	if p.K1 < 0 {
+		errs = append(errs, fmt.Errorf("k1 must not be negative, got %v", p.K1))
+	}
+	if p.B < 0 || p.B > 1 {
+		errs = append(errs, fmt.Errorf("b must be between 0 and 1, got %v", p.B))
+	}
+	return errors.Join(errs...)
+}
+
+// BM25Params returns the k1 and b the indexer precomputed BM25 scores
+// with, or the indexer's defaults for indexes that didn't record them.
+func (ir *IndexReader) BM25Params() (BM25Params, error) {
+	params := BM25Params{K1: 1.2, B: 0.75}
+	for key, dest := range map[string]*float64{"bm25_k1": &params.K1, "bm25_b": &params.B} {
+		value, err := ir.metadataFloat(key)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return params, err
+		}
+		*dest = value
+	}
+	return params, nil
+}
+
+// SearchDocumentsBM25 ranks documents by the sum of the BM25 scores the
+// indexer precomputed for each query term.
+func (ir *IndexReader) SearchDocumentsBM25(termIDs []int64) ([]SearchResult, error) {
+	return ir.searchBM25(termIDs, anyTerm)
+}
+
+func (ir *IndexReader) searchBM25(termIDs []int64, m match) ([]SearchResult, error) {
+	if len(termIDs) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	having, havingArgs := m.having(len(termIDs))
+	query := `
+		SELECT doc_id, SUM(bm25) AS score
+		FROM postings
+		WHERE term_id IN (` + placeholders(len(termIDs)) + `)
+		  AND bm25 > 0
+		GROUP BY doc_id
+		` + having + `
+		ORDER BY score DESC
+		LIMIT ?`
+
+	args := make([]interface{}, len(termIDs))
+	for i, termID := range termIDs {
+		args[i] = termID
+	}
+	args = append(append(args, havingArgs...), m.limit)
+	return ir.searchScores(query, args)
+}
+
+// SearchDocumentsBM25WithParams ranks documents by BM25 computed at query
+// time, to try other k1 and b values without rescoring the index. Like the
+// indexer, it counts occurrences and lengths unweighted, over the title,
+// description and content.
+func (ir *IndexReader) SearchDocumentsBM25WithParams(termIDs []int64, params BM25Params) ([]SearchResult, error) {
+	return ir.searchBM25WithParams(termIDs, params, anyTerm)
+}
+
+func (ir *IndexReader) searchBM25WithParams(termIDs []int64, params BM25Params, m match) ([]SearchResult, error) {
+	if len(termIDs) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	avgDocLength, err := ir.metadataFloat("avg_doc_length")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read average document length: %w", err)
+	}
+
+	having, havingArgs := m.having(len(termIDs))
+	query := `
+		SELECT doc_id,
+			SUM(bm25_idf * tf * (? + 1) / (tf + ? * (1 - ? + ? * length / ?))) AS score
+		FROM (
+			SELECT p.doc_id, t.bm25_idf,
+				p.title_tf + p.description_tf + p.content_tf AS tf,
+				d.title_length + d.description_length + d.content_length AS length
+			FROM postings p
+			JOIN doc_stats d ON d.doc_id = p.doc_id
+			JOIN terms t ON t.term_id = p.term_id
+			WHERE p.term_id IN (` + placeholders(len(termIDs)) + `)
+		)
+		WHERE tf > 0
+		GROUP BY doc_id
+		` + having + `
+		ORDER BY score DESC
+		LIMIT ?`
+
+	args := []interface{}{params.K1, params.K1, params.B, params.B, max(avgDocLength, 1)}
+	for _, termID := range termIDs {
+		args = append(args, termID)
+	}
+	args = append(append(args, havingArgs...), m.limit)
+	return ir.searchScores(query, args)
+}
+
+func (ir *IndexReader) searchScores(query string, args []interface{}) ([]SearchResult, error) {
+	rows, err := ir.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		if err := rows.Scan(&result.DocID, &result.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan result: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
+)
+
+// FieldParams tunes one field for BM25F: how much an occurrence in it is
+// worth, and how strongly its frequencies are normalized by the field's
+// length (0 ignores length, 1 fully normalizes).
+type FieldParams struct {
+	Weight float64
+	B      float64
+}
+
+type BM25FParams struct {
+	K1     float64 // how quickly repeated occurrences stop adding to the score
+	Fields [textprocessor.NumFields]FieldParams
+}
+
+func DefaultBM25FParams() BM25FParams {
+	var params BM25FParams
+	params.K1 = 1.2
+	params.Fields[textprocessor.FieldTitle] = FieldParams{Weight: 3, B: 0.75}
+	params.Fields[textprocessor.FieldDescription] = FieldParams{Weight: 2, B: 0.75}
+	params.Fields[textprocessor.FieldContent] = FieldParams{Weight: 1, B: 0.75}
+	params.Fields[textprocessor.FieldURL] = FieldParams{Weight: 2, B: 0.5}
+	return params
+}
+
+func (p BM25FParams) Validate() error {
+	var errs []error
+	if p.K1 < 0 {
+		errs = append(errs, fmt.Errorf("k1 must not be negative, got %v", p.K1))
+	}
+	weighted := false
+	for field, fp := range p.Fields {
+		name := textprocessor.Field(field).String()
+		if fp.Weight < 0 {
+			errs = append(errs, fmt.Errorf("%s weight must not be negative, got %v", name, fp.Weight))
+		}
+		if fp.B < 0 || fp.B > 1 {
+			errs = append(errs, fmt.Errorf("%s b must be between 0 and 1, got %v", name, fp.B))
+		}
+		weighted = weighted || fp.Weight > 0
+	}
+	if !weighted {
+		errs = append(errs, errors.New("at least one field needs a positive weight"))
+	}
+	return errors.Join(errs...)
+}
+
+func (ir *IndexReader) fieldAverages() ([textprocessor.NumFields]float64, error) {
+	var averages [textprocessor.NumFields]float64
+	for field := range averages {
+		average, err := ir.metadataFloat("avg_" + textprocessor.Field(field).String() + "_length")
+		if err != nil {
+			return averages, err
+		}
+		averages[field] = average
+	}
+	return averages, nil
+}
+
+// SearchDocumentsBM25F ranks documents by BM25F. Each field's frequency
+// is normalized by its length and weighted before saturation:
+//
+//	tf' = sum over fields of weight * tf / (1 - b + b * length / avg_length)
+//	score = sum over terms of bm25f_idf * tf' / (k1 + tf')
+//
+// bm25f_idf counts documents with the term in any field, including the
+// URL. Weights and parameters apply at query time, so they can be tuned
+// without reindexing.
+func (ir *IndexReader) SearchDocumentsBM25F(termIDs []int64, params BM25FParams) ([]SearchResult, error) {
+	return ir.searchBM25F(termIDs, params, anyTerm)
+}
+
+func (ir *IndexReader) searchBM25F(termIDs []int64, params BM25FParams, m match) ([]SearchResult, error) {
+	if len(termIDs) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	averages, err := ir.fieldAverages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read field lengths: %w", err)
+	}
+
+	var terms []string
+	var args []interface{}
+	for field, column := range [textprocessor.NumFields]string{"title", "description", "content", "url"} {
+		p := params.Fields[field]
+		if p.Weight == 0 {
+			continue
+		}
+		// A field empty in every document has no occurrences to normalize
+		average := max(averages[field], 1)
+		terms = append(terms, fmt.Sprintf("? * p.%s_tf / (1 - ? + ? * d.%s_length / ?)", column, column))
+		args = append(args, p.Weight, p.B, p.B, average)
+	}
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	// Documents with the terms only in fields weighted 0 don't match.
+	// URL-only postings count for BM25F, but not toward containing every term
+	where := ""
+	if m.all {
+		where = "AND p.term_frequency > 0"
+	}
+	having, havingArgs := m.having(len(termIDs))
+
+	query := `
+		SELECT doc_id, SUM(bm25f_idf * tf / (? + tf)) AS score
+		FROM (
+			SELECT p.doc_id, t.bm25f_idf, ` + strings.Join(terms, " + ") + ` AS tf
+			FROM postings p
+			JOIN doc_stats d ON d.doc_id = p.doc_id
+			JOIN terms t ON t.term_id = p.term_id
+			WHERE p.term_id IN (` + placeholders(len(termIDs)) + `)
+			` + where + `
+		)
+		WHERE tf > 0
+		GROUP BY doc_id
+		` + having + `
+		ORDER BY score DESC
+		LIMIT ?`
+
+	args = append([]interface{}{params.K1}, args...)
+	for _, termID := range termIDs {
+		args = append(args, termID)
+	}
+	args = append(append(args, havingArgs...), m.limit)
+
+	return ir.searchScores(query, args)
+}
@@ -3,7 +3,11 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/deidaraiorek/deisearch/pkg/positions"
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor/tokenizer"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -71,7 +75,30 @@ type SearchResult struct {
 	Score float64
 }
 
+// match narrows down the documents a search returns: the best limit of
+// them, and with all set, only those containing every term outside their
+// URL.
+type match struct {
+	all   bool
+	limit int
+}
+
+var anyTerm = match{limit: 100}
+
+// having is the clause and arguments that keep the documents a search over
+// termCount terms matches.
+func (m match) having(termCount int) (string, []interface{}) {
+	if !m.all {
+		return "", nil
+	}
+	return "HAVING COUNT(*) = ?", []interface{}{termCount}
+}
+
 func (ir *IndexReader) SearchDocuments(termIDs []int64) ([]SearchResult, error) {
+	return ir.searchTFIDF(termIDs, anyTerm)
+}
+
+func (ir *IndexReader) searchTFIDF(termIDs []int64, m match) ([]SearchResult, error) {
 	if len(termIDs) == 0 {
 		return []SearchResult{}, nil
 	}
@@ -81,10 +108,11 @@ func (ir *IndexReader) SearchDocuments(termIDs []int64) ([]SearchResult, error)
 			SELECT doc_id, tfidf as adjusted_score
 			FROM postings
 			WHERE term_id = ?
+			  AND term_frequency > 0
 			ORDER BY tfidf DESC
-			LIMIT 100`
+			LIMIT ?`
 
-		rows, err := ir.db.Query(query, termIDs[0])
+		rows, err := ir.db.Query(query, termIDs[0], m.limit)
 		if err != nil {
 			return nil, fmt.Errorf("failed to search documents: %w", err)
 		}
@@ -119,10 +147,15 @@ func (ir *IndexReader) SearchDocuments(termIDs []int64) ([]SearchResult, error)
 		args[i+1] = termID
 	}
 
+	having, havingArgs := m.having(len(termIDs))
+	// Postings of terms only in the URL are there for BM25F
 	query += `)
+		  AND term_frequency > 0
 		GROUP BY doc_id
+		` + having + `
 		ORDER BY adjusted_score DESC
-		LIMIT 100`
+		LIMIT ?`
+	args = append(append(args, havingArgs...), m.limit)
 
 	rows, err := ir.db.Query(query, args...)
 	if err != nil {
@@ -141,3 +174,175 @@ func (ir *IndexReader) SearchDocuments(termIDs []int64) ([]SearchResult, error)
 
 	return results, nil
 }
+
+// Ranking is a model and the parameters it ranks with.
+type Ranking struct {
+	Model string
+	BM25  *BM25Params // nil uses the scores the indexer computed
+	BM25F BM25FParams
+}
+
+// Search ranks the documents containing any of the terms.
+func (ir *IndexReader) Search(termIDs []int64, ranking Ranking) ([]SearchResult, error) {
+	return ir.rank(termIDs, ranking, anyTerm)
+}
+
+func (ir *IndexReader) rank(termIDs []int64, ranking Ranking, m match) ([]SearchResult, error) {
+	switch {
+	case ranking.Model == ModelBM25F:
+		return ir.searchBM25F(termIDs, ranking.BM25F, m)
+	case ranking.Model == ModelBM25 && ranking.BM25 != nil:
+		return ir.searchBM25WithParams(termIDs, *ranking.BM25, m)
+	case ranking.Model == ModelBM25:
+		return ir.searchBM25(termIDs, m)
+	default:
+		return ir.searchTFIDF(termIDs, m)
+	}
+}
+
+// Best-scoring documents containing every phrase term that are checked for
+// the phrase itself
+const phraseCandidates = 1000
+
+// SearchPhrase finds documents where the tokens appear at the same
+// distances from each other as in the query. Candidates are the best
+// documents containing every token by the ranking's model, and results
+// keep their scores.
+func (ir *IndexReader) SearchPhrase(tokens []tokenizer.Token, ranking Ranking) ([]SearchResult, error) {
+	if len(tokens) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	termIDs, err := ir.termIDsByTerm(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(termIDs) < uniqueTerms(tokens) {
+		// A term no document contains
+		return []SearchResult{}, nil
+	}
+
+	ids := make([]interface{}, 0, len(termIDs))
+	rankIDs := make([]int64, 0, len(termIDs))
+	for _, termID := range termIDs {
+		ids = append(ids, termID)
+		rankIDs = append(rankIDs, termID)
+	}
+
+	candidates, err := ir.rank(rankIDs, ranking, match{all: true, limit: phraseCandidates})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search phrase candidates: %w", err)
+	}
+	if len(candidates) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	docIDs := make([]interface{}, len(candidates))
+	for i, candidate := range candidates {
+		docIDs[i] = candidate.DocID
+	}
+	docPositions, err := ir.getPositions(ids, docIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SearchResult{}
+	for _, candidate := range candidates {
+		if containsPhrase(tokens, termIDs, docPositions[candidate.DocID]) {
+			results = append(results, candidate)
+			if len(results) == 100 {
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+func (ir *IndexReader) termIDsByTerm(tokens []tokenizer.Token) (map[string]int64, error) {
+	args := make([]interface{}, len(tokens))
+	for i, token := range tokens {
+		args[i] = token.Text
+	}
+
+	rows, err := ir.db.Query("SELECT term, term_id FROM terms WHERE term IN ("+placeholders(len(args))+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query term IDs: %w", err)
+	}
+	defer rows.Close()
+
+	termIDs := make(map[string]int64)
+	for rows.Next() {
+		var term string
+		var termID int64
+		if err := rows.Scan(&term, &termID); err != nil {
+			return nil, fmt.Errorf("failed to scan term ID: %w", err)
+		}
+		termIDs[term] = termID
+	}
+	return termIDs, rows.Err()
+}
+
+// getPositions maps doc_id and term_id to the term's positions in the doc.
+func (ir *IndexReader) getPositions(termIDs, docIDs []interface{}) (map[int64]map[int64][]int, error) {
+	rows, err := ir.db.Query(
+		"SELECT doc_id, term_id, positions FROM postings WHERE term_id IN ("+placeholders(len(termIDs))+") AND doc_id IN ("+placeholders(len(docIDs))+")",
+		append(termIDs, docIDs...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query positions: %w", err)
+	}
+	defer rows.Close()
+
+	docPositions := make(map[int64]map[int64][]int)
+	for rows.Next() {
+		var docID, termID int64
+		var data []byte
+		if err := rows.Scan(&docID, &termID, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan positions: %w", err)
+		}
+		decoded, err := positions.Decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode positions of doc %d: %w", docID, err)
+		}
+		if docPositions[docID] == nil {
+			docPositions[docID] = make(map[int64][]int)
+		}
+		docPositions[docID][termID] = decoded
+	}
+	return docPositions, rows.Err()
+}
+
+// containsPhrase reports whether some occurrence of the first token is
+// followed by every other token at its offset in the query.
+func containsPhrase(tokens []tokenizer.Token, termIDs map[string]int64, termPositions map[int64][]int) bool {
+	first := tokens[0]
+	for _, start := range termPositions[termIDs[first.Text]] {
+		matched := true
+		for _, token := range tokens[1:] {
+			want := start + token.Position - first.Position
+			found := termPositions[termIDs[token.Text]]
+			i := sort.SearchInts(found, want)
+			if i == len(found) || found[i] != want {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueTerms(tokens []tokenizer.Token) int {
+	seen := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		seen[token.Text] = true
+	}
+	return len(seen)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -1,15 +1,34 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
 	"github.com/deidaraiorek/deisearch/query-engine/internal/handler"
+	"github.com/deidaraiorek/deisearch/query-engine/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 func main() {
+	model := flag.String("model", "", "model queries without one rank with: tfidf, bm25 or bm25f (default: the index's scoring_model)")
+	k1 := flag.Float64("k1", 0, "BM25 k1, computed at query time (default: the index's bm25_k1, using its precomputed scores)")
+	b := flag.Float64("b", 0, "BM25 b, 0 to 1, computed at query time (default: the index's bm25_b, using its precomputed scores)")
+	bm25f := bm25fFlags()
+	flag.Parse()
+
+	if *model != "" {
+		if err := handler.SetDefaultModel(*model); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	if err := handler.SetBM25FParams(bm25f()); err != nil {
+		log.Fatalf("%v", err)
+	}
+
 	indexDBPath := "/Users/dangpham/Dev/deisearch/index.db"
 	spiderDBPath := "/Users/dangpham/Dev/deisearch/spider.db"
 	pagerankDBPath := "/Users/dangpham/Dev/deisearch/pagerank.db"
@@ -19,6 +38,34 @@ func main() {
 		log.Fatalf("Failed to initialize readers: %v", err)
 	}
 
+	// BM25 uses the index's precomputed scores unless k1 or b is given;
+	// the one not given stays as the index recorded it
+	var setK1, setB bool
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "k1":
+			setK1 = true
+		case "b":
+			setB = true
+		}
+	})
+	if setK1 || setB {
+		params, err := handler.IndexBM25Params()
+		if err != nil {
+			log.Fatalf("Failed to read BM25 parameters: %v", err)
+		}
+		if setK1 {
+			params.K1 = *k1
+		}
+		if setB {
+			params.B = *b
+		}
+		if err := handler.SetBM25Params(params); err != nil {
+			log.Fatalf("%v", err)
+		}
+		log.Printf("Ranking BM25 at query time with k1=%g, b=%g", params.K1, params.B)
+	}
+
 	if err := handler.InitSemanticSearch(embeddingsDBPath); err != nil {
 		log.Printf("Warning: Semantic search disabled: %v", err)
 	}
@@ -39,6 +86,29 @@ func main() {
 	}
 }
 
+// bm25fFlags registers -bm25f-k1 and a weight and b flag per field
+// (-title-weight, -title-b, ...), defaulting to storage.DefaultBM25FParams.
+// The returned function reads them after flag.Parse.
+func bm25fFlags() func() storage.BM25FParams {
+	defaults := storage.DefaultBM25FParams()
+	k1 := flag.Float64("bm25f-k1", defaults.K1, "BM25F k1, how quickly repeated occurrences stop adding to the score")
+
+	var weights, bs [textprocessor.NumFields]*float64
+	for field, params := range defaults.Fields {
+		name := textprocessor.Field(field).String()
+		weights[field] = flag.Float64(name+"-weight", params.Weight, "BM25F weight of "+name+" matches")
+		bs[field] = flag.Float64(name+"-b", params.B, "BM25F length normalization of the "+name+", 0 to 1")
+	}
+
+	return func() storage.BM25FParams {
+		params := storage.BM25FParams{K1: *k1}
+		for field := range params.Fields {
+			params.Fields[field] = storage.FieldParams{Weight: *weights[field], B: *bs[field]}
+		}
+		return params
+	}
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
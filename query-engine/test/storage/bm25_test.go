@@ -0,0 +1,177 @@
+package storage_test
+
+import (
+	"database/sql"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
+	"github.com/deidaraiorek/deisearch/query-engine/internal/storage"
+)
+
+func TestBM25ParamsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params storage.BM25Params
+		valid  bool
+	}{
+		{"defaults", storage.BM25Params{K1: 1.2, B: 0.75}, true},
+		{"no saturation", storage.BM25Params{K1: 0, B: 0.75}, true},
+		{"full length normalization", storage.BM25Params{K1: 2, B: 1}, true},
+		{"negative k1", storage.BM25Params{K1: -1, B: 0.75}, false},
+		{"negative b", storage.BM25Params{K1: 1.2, B: -0.1}, false},
+		{"b above 1", storage.BM25Params{K1: 1.2, B: 1.5}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.params.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestIndexBM25Params(t *testing.T) {
+	reader := newTestIndex(t, fieldDocs)
+	params, err := reader.BM25Params()
+	if err != nil {
+		t.Fatalf("BM25Params failed: %v", err)
+	}
+	if expected := (storage.BM25Params{K1: testK1, B: testB}); params != expected {
+		t.Errorf("Expected %+v, got %+v", expected, params)
+	}
+
+	// An index from before BM25 has no scoring settings
+	path := filepath.Join(t.TempDir(), "index.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(testSchema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	old, err := storage.NewIndexReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open index: %v", err)
+	}
+	defer old.Close()
+
+	params, err = old.BM25Params()
+	if err != nil {
+		t.Fatalf("BM25Params failed: %v", err)
+	}
+	if expected := (storage.BM25Params{K1: 1.2, B: 0.75}); params != expected {
+		t.Errorf("Expected the indexer's defaults %+v, got %+v", expected, params)
+	}
+	if model, err := old.ScoringModel(); err != nil || model != storage.ModelTFIDF {
+		t.Errorf("Expected %s, got %q (%v)", storage.ModelTFIDF, model, err)
+	}
+}
+
+// expectedBM25 scores docs[doc] for term with BM25 over the unweighted
+// title, description and content counts.
+func expectedBM25(docs []testDoc, doc int, term string, params storage.BM25Params) float64 {
+	processor := textprocessor.NewTextProcessor()
+	lengths := make([]float64, len(docs))
+	frequencies := make([]float64, len(docs))
+	avgLength := 0.0
+	n := 0
+	for i, d := range docs {
+		processed := processor.ProcessDocumentWithWeights(d.fields, 3, 2, 1)
+		l := processed.FieldLengths
+		f := processed.FieldFrequencies[term]
+		lengths[i] = float64(l[textprocessor.FieldTitle] + l[textprocessor.FieldDescription] + l[textprocessor.FieldContent])
+		frequencies[i] = float64(f[textprocessor.FieldTitle] + f[textprocessor.FieldDescription] + f[textprocessor.FieldContent])
+		avgLength += lengths[i] / float64(len(docs))
+		if frequencies[i] > 0 {
+			n++
+		}
+	}
+
+	f := frequencies[doc]
+	idf := math.Log(1 + (float64(len(docs)-n)+0.5)/(float64(n)+0.5))
+	return idf * f * (params.K1 + 1) / (f + params.K1*(1-params.B+params.B*lengths[doc]/avgLength))
+}
+
+func TestSearchDocumentsBM25WithParams(t *testing.T) {
+	reader := newTestIndex(t, fieldDocs)
+	termIDs, err := reader.GetTermIDs([]string{"rust"})
+	if err != nil {
+		t.Fatalf("Failed to get term IDs: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		params   storage.BM25Params
+		expected []int64 // in rank order
+	}{
+		// Doc 1 mentions rust once in a short page, doc 2 twice in a long one;
+		// the URL-only match in doc 3 doesn't rank
+		{"index settings", storage.BM25Params{K1: testK1, B: testB}, []int64{1, 2}},
+		{"no length normalization", storage.BM25Params{K1: testK1, B: 0}, []int64{2, 1}},
+		{"full length normalization", storage.BM25Params{K1: 5, B: 1}, []int64{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := reader.SearchDocumentsBM25WithParams(termIDs, tt.params)
+			if err != nil {
+				t.Fatalf("SearchDocumentsBM25WithParams failed: %v", err)
+			}
+
+			got := docIDs(results)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected docs %v, got %v", tt.expected, got)
+			}
+			for i, result := range results {
+				if result.DocID != tt.expected[i] {
+					t.Errorf("Expected docs %v, got %v", tt.expected, got)
+					break
+				}
+				index := int(result.DocID) - 1
+				if expected := expectedBM25(fieldDocs, index, "rust", tt.params); math.Abs(result.Score-expected) > 1e-4 {
+					t.Errorf("Expected doc %d to score %v, got %v", result.DocID, expected, result.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestPrecomputedBM25MatchesQueryTime(t *testing.T) {
+	reader := newTestIndex(t, phraseDocs)
+	processor := textprocessor.NewTextProcessor()
+
+	for _, query := range []string{"database", "database design", "state art museum"} {
+		t.Run(query, func(t *testing.T) {
+			termIDs, err := reader.GetTermIDs(processor.Process(query))
+			if err != nil {
+				t.Fatalf("Failed to get term IDs: %v", err)
+			}
+
+			precomputed, err := reader.SearchDocumentsBM25(termIDs)
+			if err != nil {
+				t.Fatalf("SearchDocumentsBM25 failed: %v", err)
+			}
+			queryTime, err := reader.SearchDocumentsBM25WithParams(termIDs, storage.BM25Params{K1: testK1, B: testB})
+			if err != nil {
+				t.Fatalf("SearchDocumentsBM25WithParams failed: %v", err)
+			}
+
+			if len(precomputed) == 0 || len(precomputed) != len(queryTime) {
+				t.Fatalf("Expected the same docs, got %v and %v", docIDs(precomputed), docIDs(queryTime))
+			}
+			scores := make(map[int64]float64)
+			for _, result := range queryTime {
+				scores[result.DocID] = result.Score
+			}
+			for _, result := range precomputed {
+				if score, ok := scores[result.DocID]; !ok || math.Abs(result.Score-score) > 1e-4 {
+					t.Errorf("Expected doc %d to score %v at query time, got %v", result.DocID, result.Score, score)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package storage_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
+	"github.com/deidaraiorek/deisearch/query-engine/internal/storage"
+)
+
+func TestBM25FParamsValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(p *storage.BM25FParams)
+		valid bool
+	}{
+		{"defaults", func(p *storage.BM25FParams) {}, true},
+		{"field turned off", func(p *storage.BM25FParams) { p.Fields[textprocessor.FieldURL].Weight = 0 }, true},
+		{"negative k1", func(p *storage.BM25FParams) { p.K1 = -1 }, false},
+		{"negative weight", func(p *storage.BM25FParams) { p.Fields[textprocessor.FieldTitle].Weight = -2 }, false},
+		{"b above 1", func(p *storage.BM25FParams) { p.Fields[textprocessor.FieldContent].B = 1.5 }, false},
+		{"every field off", func(p *storage.BM25FParams) {
+			for field := range p.Fields {
+				p.Fields[field].Weight = 0
+			}
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := storage.DefaultBM25FParams()
+			tt.edit(&params)
+			if err := params.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+var fieldDocs = []testDoc{
+	{1, textprocessor.DocumentFields{Title: "Rust", Content: "A systems language with ownership."}},
+	{2, textprocessor.DocumentFields{Title: "Languages", Content: "Rust, Go and Zig compile to native code. Rust has no garbage collector."}},
+	{3, textprocessor.DocumentFields{Title: "Compilers", Content: "Parsing and code generation.", URL: "https://example.com/rust/compiler"}},
+	{4, textprocessor.DocumentFields{Title: "Databases", Description: "Storage engines", Content: "Pages, indexes and logs."}},
+}
+
+// expectedBM25F scores docs[doc] for term with the BM25F formula.
+func expectedBM25F(docs []testDoc, doc int, term string, params storage.BM25FParams) float64 {
+	processor := textprocessor.NewTextProcessor()
+	processed := make([]textprocessor.ProcessedDocument, len(docs))
+	var averages [textprocessor.NumFields]float64
+	n := 0
+	for i, d := range docs {
+		processed[i] = processor.ProcessDocumentWithWeights(d.fields, 3, 2, 1)
+		for field, length := range processed[i].FieldLengths {
+			averages[field] += float64(length) / float64(len(docs))
+		}
+		if _, ok := processed[i].FieldFrequencies[term]; ok {
+			n++
+		}
+	}
+
+	tf := 0.0
+	for field, p := range params.Fields {
+		length := float64(processed[doc].FieldLengths[field])
+		tf += p.Weight * float64(processed[doc].FieldFrequencies[term][field]) / (1 - p.B + p.B*length/math.Max(averages[field], 1))
+	}
+	idf := math.Log(1 + (float64(len(docs)-n)+0.5)/(float64(n)+0.5))
+	return idf * tf / (params.K1 + tf)
+}
+
+func TestSearchDocumentsBM25F(t *testing.T) {
+	reader := newTestIndex(t, fieldDocs)
+	termIDs, err := reader.GetTermIDs([]string{"rust"})
+	if err != nil {
+		t.Fatalf("Failed to get term IDs: %v", err)
+	}
+
+	titleOnly := storage.DefaultBM25FParams()
+	for field := range titleOnly.Fields {
+		if textprocessor.Field(field) != textprocessor.FieldTitle {
+			titleOnly.Fields[field].Weight = 0
+		}
+	}
+	contentHeavy := storage.DefaultBM25FParams()
+	contentHeavy.Fields[textprocessor.FieldTitle].Weight = 0.5
+	contentHeavy.Fields[textprocessor.FieldContent].Weight = 5
+
+	tests := []struct {
+		name     string
+		params   storage.BM25FParams
+		expected []int64 // in rank order
+	}{
+		// The URL-only match still ranks, below the title and content matches
+		{"defaults", storage.DefaultBM25FParams(), []int64{1, 2, 3}},
+		{"title only", titleOnly, []int64{1}},
+		{"content outweighs the title", contentHeavy, []int64{2, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := reader.SearchDocumentsBM25F(termIDs, tt.params)
+			if err != nil {
+				t.Fatalf("SearchDocumentsBM25F failed: %v", err)
+			}
+
+			got := docIDs(results)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected docs %v, got %v", tt.expected, got)
+			}
+			for i, result := range results {
+				if result.DocID != tt.expected[i] {
+					t.Errorf("Expected docs %v, got %v", tt.expected, got)
+					break
+				}
+				index := int(result.DocID) - 1
+				if expected := expectedBM25F(fieldDocs, index, "rust", tt.params); math.Abs(result.Score-expected) > 1e-4 {
+					t.Errorf("Expected doc %d to score %v, got %v", result.DocID, expected, result.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestURLOnlyMatchesOnlyRankWithBM25F(t *testing.T) {
+	reader := newTestIndex(t, fieldDocs)
+	termIDs, err := reader.GetTermIDs([]string{"compil"})
+	if err != nil {
+		t.Fatalf("Failed to get term IDs: %v", err)
+	}
+
+	// "compil" is in doc 2's content, doc 3's title and URL
+	for _, ranking := range rankings {
+		t.Run(rankingName(ranking), func(t *testing.T) {
+			results, err := reader.Search(termIDs, ranking)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(results) != 2 {
+				t.Errorf("Expected docs 2 and 3, got %v", docIDs(results))
+			}
+		})
+	}
+
+	termIDs, err = reader.GetTermIDs([]string{"exampl"})
+	if err != nil {
+		t.Fatalf("Failed to get term IDs: %v", err)
+	}
+	for _, ranking := range rankings {
+		t.Run(rankingName(ranking)+" URL only", func(t *testing.T) {
+			results, err := reader.Search(termIDs, ranking)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+
+			expected := 0
+			if ranking.Model == storage.ModelBM25F {
+				expected = 1
+			}
+			if len(results) != expected {
+				t.Errorf("Expected %d results for a term only in a URL, got %v", expected, docIDs(results))
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package storage_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/deidaraiorek/deisearch/pkg/positions"
+	"github.com/deidaraiorek/deisearch/pkg/textprocessor"
+	"github.com/deidaraiorek/deisearch/query-engine/internal/storage"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testSchema = `
+CREATE TABLE terms (
+	term_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	term TEXT UNIQUE NOT NULL,
+	document_frequency INTEGER DEFAULT 0,
+	idf REAL DEFAULT 0,
+	bm25_idf REAL DEFAULT 0,
+	url_only_frequency INTEGER DEFAULT 0,
+	bm25f_idf REAL DEFAULT 0
+);
+CREATE TABLE postings (
+	term_id INTEGER NOT NULL,
+	doc_id INTEGER NOT NULL,
+	term_frequency INTEGER NOT NULL,
+	tf REAL DEFAULT 0,
+	tfidf REAL DEFAULT 0,
+	bm25 REAL DEFAULT 0,
+	positions BLOB,
+	title_tf INTEGER DEFAULT 0,
+	description_tf INTEGER DEFAULT 0,
+	content_tf INTEGER DEFAULT 0,
+	url_tf INTEGER DEFAULT 0,
+	PRIMARY KEY (term_id, doc_id)
+);
+CREATE TABLE doc_stats (
+	doc_id INTEGER PRIMARY KEY,
+	doc_length INTEGER NOT NULL,
+	unique_terms INTEGER NOT NULL,
+	title_length INTEGER DEFAULT 0,
+	description_length INTEGER DEFAULT 0,
+	content_length INTEGER DEFAULT 0,
+	url_length INTEGER DEFAULT 0
+);
+CREATE TABLE index_metadata (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);`
+
+// Scores the test index is built with, as the indexer's defaults
+const (
+	testK1 = 1.2
+	testB  = 0.75
+)
+
+type testDoc struct {
+	id     int64
+	fields textprocessor.DocumentFields
+}
+
+// newTestIndex builds an index of docs the way the indexer does, with
+// field weights 3, 2 and 1, and opens it for reading.
+func newTestIndex(t *testing.T, docs []testDoc) *storage.IndexReader {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "index.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(testSchema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	processor := textprocessor.NewTextProcessor()
+	processed := make([]textprocessor.ProcessedDocument, len(docs))
+	termIDs := make(map[string]int64)
+	df := make(map[string]int)
+	urlOnly := make(map[string]int)
+	var totalLength float64
+	var fieldTotals [textprocessor.NumFields]float64
+
+	for i, doc := range docs {
+		processed[i] = processor.ProcessDocumentWithWeights(doc.fields, 3, 2, 1)
+		lengths := processed[i].FieldLengths
+		for term := range processed[i].FieldFrequencies {
+			if _, ok := termIDs[term]; !ok {
+				termIDs[term] = int64(len(termIDs) + 1)
+			}
+			if processed[i].TermFrequencies[term] > 0 {
+				df[term]++
+			} else {
+				urlOnly[term]++
+			}
+		}
+		totalLength += float64(lengths[textprocessor.FieldTitle] + lengths[textprocessor.FieldDescription] + lengths[textprocessor.FieldContent])
+		for field, length := range lengths {
+			fieldTotals[field] += float64(length)
+		}
+	}
+
+	n := float64(len(docs))
+	avgLength := totalLength / n
+	bm25IDF := func(df int) float64 {
+		return math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+	}
+
+	for term, termID := range termIDs {
+		var idf, termBM25IDF float64
+		if df[term] > 0 {
+			idf = math.Log(n / float64(df[term]))
+			termBM25IDF = bm25IDF(df[term])
+		}
+		_, err := db.Exec("INSERT INTO terms VALUES (?, ?, ?, ?, ?, ?, ?)",
+			termID, term, df[term], idf, termBM25IDF, urlOnly[term], bm25IDF(df[term]+urlOnly[term]))
+		if err != nil {
+			t.Fatalf("Failed to insert term %q: %v", term, err)
+		}
+	}
+
+	for i, doc := range docs {
+		p := processed[i]
+		lengths := p.FieldLengths
+		length := float64(lengths[textprocessor.FieldTitle] + lengths[textprocessor.FieldDescription] + lengths[textprocessor.FieldContent])
+		_, err := db.Exec("INSERT INTO doc_stats VALUES (?, ?, ?, ?, ?, ?, ?)", doc.id, p.TotalTerms, p.UniqueTerms,
+			lengths[textprocessor.FieldTitle], lengths[textprocessor.FieldDescription], lengths[textprocessor.FieldContent], lengths[textprocessor.FieldURL])
+		if err != nil {
+			t.Fatalf("Failed to insert doc stats: %v", err)
+		}
+
+		for term, fields := range p.FieldFrequencies {
+			var tf, tfidf, bm25 float64
+			if p.TermFrequencies[term] > 0 {
+				tf = float64(p.TermFrequencies[term]) / float64(p.TotalTerms)
+				tfidf = tf * math.Log(n/float64(df[term]))
+				f := float64(fields[textprocessor.FieldTitle] + fields[textprocessor.FieldDescription] + fields[textprocessor.FieldContent])
+				bm25 = bm25IDF(df[term]) * f * (testK1 + 1) / (f + testK1*(1-testB+testB*length/avgLength))
+			}
+			_, err := db.Exec("INSERT INTO postings VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				termIDs[term], doc.id, p.TermFrequencies[term], tf, tfidf, bm25, positions.Encode(p.Positions[term]),
+				fields[textprocessor.FieldTitle], fields[textprocessor.FieldDescription], fields[textprocessor.FieldContent], fields[textprocessor.FieldURL])
+			if err != nil {
+				t.Fatalf("Failed to insert posting: %v", err)
+			}
+		}
+	}
+
+	metadata := map[string]string{
+		"scoring_model":  storage.ModelBM25,
+		"bm25_k1":        fmt.Sprint(testK1),
+		"bm25_b":         fmt.Sprint(testB),
+		"avg_doc_length": fmt.Sprintf("%f", avgLength),
+	}
+	for field, total := range fieldTotals {
+		metadata["avg_"+textprocessor.Field(field).String()+"_length"] = fmt.Sprintf("%f", total/n)
+	}
+	for key, value := range metadata {
+		if _, err := db.Exec("INSERT INTO index_metadata VALUES (?, ?)", key, value); err != nil {
+			t.Fatalf("Failed to insert %s: %v", key, err)
+		}
+	}
+
+	reader, err := storage.NewIndexReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open index: %v", err)
+	}
+	t.Cleanup(func() { reader.Close() })
+	return reader
+}
+
+func docIDs(results []storage.SearchResult) []int64 {
+	ids := []int64{}
+	for _, result := range results {
+		ids = append(ids, result.DocID)
+	}
+	return ids
+}
+
+var rankings = []storage.Ranking{
+	{Model: storage.ModelTFIDF},
+	{Model: storage.ModelBM25},
+	{Model: storage.ModelBM25, BM25: &storage.BM25Params{K1: 2, B: 0.3}},
+	{Model: storage.ModelBM25F, BM25F: storage.DefaultBM25FParams()},
+}
+
+func rankingName(r storage.Ranking) string {
+	if r.BM25 != nil {
+		return r.Model + " at query time"
+	}
+	return r.Model
+}
+
+var phraseDocs = []testDoc{
+	{1, textprocessor.DocumentFields{Title: "Database design", Content: "We teach database design to beginners."}},
+	{2, textprocessor.DocumentFields{Title: "Tables", Content: "A database needs careful design."}},
+	{3, textprocessor.DocumentFields{Title: "Good database", Content: "Design patterns for services."}},
+	{4, textprocessor.DocumentFields{Title: "Art", Content: "The state of the art in search."}},
+	{5, textprocessor.DocumentFields{Title: "Art", Content: "A state art museum."}},
+	{6, textprocessor.DocumentFields{Title: "Notes", Content: "Design notes on database design and database design reviews."}},
+	{7, textprocessor.DocumentFields{Title: "Schemas", Content: "Schema migrations.", URL: "https://example.com/database-design"}},
+}
+
+func TestSearchPhrase(t *testing.T) {
+	reader := newTestIndex(t, phraseDocs)
+	processor := textprocessor.NewTextProcessor()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []int64
+	}{
+		{"adjacent words", "database design", []int64{1, 6}},
+		{"words apart don't match", "database careful design", []int64{}},
+		{"reversed words don't match", "design database", []int64{}},
+		{"phrase in the content", "careful design", []int64{2}},
+		{"no match across the title and content", "good database design", []int64{}},
+		{"stopwords keep the distance", "state of the art", []int64{4}},
+		{"unknown term", "database architecture", []int64{}},
+		{"single term", "schema", []int64{7}},
+	}
+
+	for _, ranking := range rankings {
+		for _, tt := range tests {
+			t.Run(rankingName(ranking)+"/"+tt.name, func(t *testing.T) {
+				results, err := reader.SearchPhrase(processor.ProcessWithPositions(tt.query), ranking)
+				if err != nil {
+					t.Fatalf("SearchPhrase failed: %v", err)
+				}
+
+				got := docIDs(results)
+				sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+				if !reflect.DeepEqual(got, tt.expected) {
+					t.Errorf("Expected docs %v, got %v", tt.expected, got)
+				}
+			})
+		}
+	}
+}
+
+func TestSearchPhraseRanksWithModel(t *testing.T) {
+	reader := newTestIndex(t, phraseDocs)
+	processor := textprocessor.NewTextProcessor()
+
+	termIDs, err := reader.GetTermIDs(processor.Process("database design"))
+	if err != nil {
+		t.Fatalf("Failed to get term IDs: %v", err)
+	}
+
+	for _, ranking := range rankings {
+		t.Run(rankingName(ranking), func(t *testing.T) {
+			phrase, err := reader.SearchPhrase(processor.ProcessWithPositions("database design"), ranking)
+			if err != nil {
+				t.Fatalf("SearchPhrase failed: %v", err)
+			}
+
+			// The phrase matches keep the order and scores of a search for
+			// the same terms with the same model
+			ranked, err := reader.Search(termIDs, ranking)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			var expected []storage.SearchResult
+			for _, result := range ranked {
+				if result.DocID == 1 || result.DocID == 6 {
+					expected = append(expected, result)
+				}
+			}
+
+			if !reflect.DeepEqual(phrase, expected) {
+				t.Errorf("Expected %v, got %v", expected, phrase)
+			}
+		})
+	}
+}
@@ -18,6 +18,9 @@ type Page struct {
 
 type SpiderDB struct {
 	db *sql.DB
+
+	// Excludes soft 404s, error and login pages the spider flagged
+	pageFilter string
 }
 
 func NewSpiderDB(dbPath string) (*SpiderDB, error) {
@@ -26,7 +29,28 @@ func NewSpiderDB(dbPath string) (*SpiderDB, error) {
 		return nil, fmt.Errorf("failed to open spider database: %w", err)
 	}
 
-	return &SpiderDB{db: db}, nil
+	sdb := &SpiderDB{db: db}
+
+	hasErrorClass, err := sdb.hasColumn("pages", "error_class")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to inspect spider database: %w", err)
+	}
+	// Databases from before error pages were classified have no such column
+	if hasErrorClass {
+		sdb.pageFilter = " AND COALESCE(error_class, '') = ''"
+	}
+
+	return sdb, nil
+}
+
+func (sdb *SpiderDB) hasColumn(table, column string) (bool, error) {
+	var count int
+	err := sdb.db.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?",
+		table, column,
+	).Scan(&count)
+	return count > 0, err
 }
 
 func (sdb *SpiderDB) Close() error {
@@ -48,7 +72,7 @@ func (sdb *SpiderDB) GetPageByID(id int) (*Page, error) {
 
 func (sdb *SpiderDB) GetPagesAfterID(afterID int, limit int) ([]*Page, error) {
 	rows, err := sdb.db.Query(
-		"SELECT id, url, title, description, content, status_code FROM pages WHERE id > ? ORDER BY id LIMIT ?",
+		"SELECT id, url, title, description, content, status_code FROM pages WHERE id > ?"+sdb.pageFilter+" ORDER BY id LIMIT ?",
 		afterID, limit,
 	)
 	if err != nil {
@@ -71,6 +95,6 @@ func (sdb *SpiderDB) GetPagesAfterID(afterID int, limit int) ([]*Page, error) {
 
 func (sdb *SpiderDB) GetTotalPageCount() (int, error) {
 	var count int
-	err := sdb.db.QueryRow("SELECT COUNT(*) FROM pages").Scan(&count)
+	err := sdb.db.QueryRow("SELECT COUNT(*) FROM pages WHERE 1 = 1" + sdb.pageFilter).Scan(&count)
 	return count, err
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/config"
+	"github.com/dangpham/deisearch/spider/internal/events"
+	"github.com/dangpham/deisearch/spider/internal/scheduler"
+	"github.com/dangpham/deisearch/spider/internal/scope"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func runCrawl(args []string) error {
+	fs := flag.NewFlagSet("crawl", flag.ExitOnError)
+	common := addCommonFlags(fs)
+	logPath := fs.String("log", "", "log file, overrides log_path")
+	workers := fs.Int("workers", 0, "concurrent crawlers, overrides workers")
+	rate := fs.Float64("rate", 0, "seconds between requests per host, overrides rate_limit_sec")
+	maxPages := fs.Int("max-pages", 0, "pages to crawl, overrides max_pages")
+	userAgent := fs.String("user-agent", "", "overrides user_agent")
+	statusAddr := fs.String("status-addr", "", "serve /status and /metrics on this address, overrides status_addr")
+	shardID := fs.Int("shard", 0, "this process's shard ID, overrides shard.id")
+	feedInterval := fs.Duration("feed-interval", 0, "feed poll interval, overrides feed_poll_interval")
+	fs.Parse(args)
+
+	// Only flags given on the command line override the config
+	cfg, err := common.load(func(cfg *config.Config) {
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "log":
+				cfg.LogPath = *logPath
+			case "workers":
+				cfg.Workers = *workers
+			case "rate":
+				cfg.RateLimitSec = float32(*rate)
+			case "max-pages":
+				cfg.MaxPages = *maxPages
+			case "user-agent":
+				cfg.UserAgent = *userAgent
+			case "status-addr":
+				cfg.StatusAddr = *statusAddr
+			case "shard":
+				cfg.Shard.ID = *shardID
+			case "feed-interval":
+				cfg.FeedPollInterval = *feedInterval
+			}
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.LogPath != "" {
+		logFile, err := os.OpenFile(cfg.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		defer logFile.Close()
+
+		multiWriter := io.MultiWriter(os.Stdout, logFile)
+		log.SetOutput(multiWriter)
+	}
+
+	rules, err := scope.Compile(cfg.Scope)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Initializing database...")
+	db, err := storage.NewDatabase(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+	db.SetVersionRetention(cfg.Versions)
+	db.SetEventLog(cfg.Events.Enabled())
+
+	if cfg.Events.Enabled() {
+		publisher, err := events.NewPublisher(db, cfg.Events)
+		if err != nil {
+			return fmt.Errorf("failed to set up event sinks: %w", err)
+		}
+		publisher.Start()
+		// Deferred after db.Close, so it runs first and sees every write
+		defer publisher.Close()
+		log.Printf("Publishing page events to %d sinks", len(cfg.Events.Sinks))
+	}
+
+	log.Println("Creating scheduler...")
+	sched := scheduler.New(db, &scheduler.Config{
+		Workers:             cfg.Workers,
+		RateLimitSec:        cfg.RateLimitSec,
+		MaxPages:            cfg.MaxPages,
+		UserAgent:           cfg.UserAgent,
+		BoilerplateMinPages: cfg.BoilerplateMinPages,
+		BoilerplateMinRatio: cfg.BoilerplateMinRatio,
+		MaxPDFBytes:         cfg.MaxPDFBytes,
+		MaxPDFPages:         cfg.MaxPDFPages,
+		FeedPollInterval:    cfg.FeedPollInterval,
+		Scope:               rules,
+		Shard:               &cfg.Shard,
+		Focus:               &cfg.Focus,
+	})
+
+	storedSeeds, err := db.ListSeeds()
+	if err != nil {
+		return fmt.Errorf("failed to load seeds: %w", err)
+	}
+	// Focus seed pages are crawled first so the topic profile fills in early
+	seedURLs := append([]string{}, cfg.Focus.SeedPages...)
+	seedURLs = append(seedURLs, cfg.Seeds...)
+	for _, seed := range storedSeeds {
+		seedURLs = append(seedURLs, seed.URL)
+	}
+
+	log.Printf("Adding %d seed URLs...", len(seedURLs))
+	for _, url := range seedURLs {
+		if err := sched.AddSeed(url); err != nil && !errors.Is(err, scheduler.ErrForeignHost) {
+			log.Printf("Failed to add seed %s: %v", url, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		log.Println("\nShutting down gracefully...")
+		cancel()
+	}()
+
+	// The shard exchange and the status endpoints share one handler
+	addrs := []string{}
+	if cfg.StatusAddr != "" {
+		addrs = append(addrs, cfg.StatusAddr)
+	}
+	if cfg.Shard.Enabled() && cfg.Shard.ListenAddr() != cfg.StatusAddr {
+		addrs = append(addrs, cfg.Shard.ListenAddr())
+	}
+	for _, addr := range addrs {
+		// Listen up front so a taken port fails the crawl instead of
+		// silently losing the links other shards send
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		server := &http.Server{Handler: sched.Handler()}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("🔴 Warning: Server on %s stopped: %v", addr, err)
+			}
+		}()
+		defer server.Close()
+		log.Printf("Listening on %s (/status, /metrics)", addr)
+	}
+
+	log.Println("Starting crawler...")
+	start := time.Now()
+	if err := sched.Start(ctx); err != nil {
+		return fmt.Errorf("scheduler error: %w", err)
+	}
+
+	log.Println("Stripping learned boilerplate from earlier pages...")
+	sched.StripLearnedBoilerplate()
+
+	log.Printf("Crawling completed in %v!", time.Since(start).Round(time.Second))
+	log.Printf("Database saved to: %s", cfg.DBPath)
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/events"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func runEvents(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected list, cursors, rewind or publish")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("events "+action, flag.ExitOnError)
+	common := addCommonFlags(fs)
+	from := fs.Int64("from", 0, "list events after this seq (list)")
+	limit := fs.Int("limit", 1000, "events to list, 0 for all (list)")
+	fs.Parse(args[1:])
+
+	if action == "rewind" && fs.NArg() != 2 {
+		return fmt.Errorf("events rewind needs a sink and a seq")
+	}
+
+	cfg, db, err := common.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "list":
+		return listEvents(db, *from, *limit)
+
+	case "cursors":
+		return listCursors(db, cfg.Events)
+
+	case "rewind":
+		sink := fs.Arg(0)
+		seq, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+		if err != nil || seq < 0 {
+			return fmt.Errorf("%q is not a seq", fs.Arg(1))
+		}
+		if err := db.SetEventCursor(sink, seq); err != nil {
+			return err
+		}
+		fmt.Printf("%s will receive events after %d\n", sink, seq)
+		return nil
+
+	case "publish":
+		if !cfg.Events.Enabled() {
+			return fmt.Errorf("no event sinks are configured")
+		}
+		publisher, err := events.NewPublisher(db, cfg.Events)
+		if err != nil {
+			return err
+		}
+		defer publisher.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		err = publisher.Publish(ctx)
+		for _, s := range publisher.Stats() {
+			fmt.Printf("%s: delivered %d, now at %d\n", s.Sink, s.Delivered, s.Cursor)
+		}
+		return err
+
+	default:
+		return fmt.Errorf("unknown events action %q, expected list, cursors, rewind or publish", action)
+	}
+}
+
+// listEvents prints events as NDJSON, the format the sinks receive.
+func listEvents(db *storage.Database, from int64, limit int) error {
+	encoder := json.NewEncoder(os.Stdout)
+	remaining := limit
+	for {
+		batchSize := 1000
+		if limit > 0 {
+			batchSize = min(batchSize, remaining)
+		}
+
+		batch, err := db.ListPageEvents(from, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, event := range batch {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		from = batch[len(batch)-1].Seq
+		if limit > 0 {
+			remaining -= len(batch)
+			if remaining == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func listCursors(db *storage.Database, config events.Config) error {
+	last, err := db.LastEventSeq()
+	if err != nil {
+		return err
+	}
+	cursors, err := db.ListEventCursors()
+	if err != nil {
+		return err
+	}
+
+	stored := make(map[string]storage.EventCursor)
+	for _, c := range cursors {
+		stored[c.Sink] = c
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SINK\tSEQ\tBEHIND\tUPDATED\n")
+
+	// Configured sinks first, then cursors of sinks no longer configured
+	for _, sink := range config.Sinks {
+		c, ok := stored[sink.ID()]
+		delete(stored, sink.ID())
+		updated := "never"
+		if ok {
+			updated = c.UpdatedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", sink.ID(), c.Seq, last-c.Seq, updated)
+	}
+	for _, sink := range sortedKeys(stored) {
+		c := stored[sink]
+		fmt.Fprintf(w, "%s (not configured)\t%d\t%d\t%s\n", sink, c.Seq, last-c.Seq, c.UpdatedAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/dangpham/deisearch/spider/internal/config"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+// commonFlags are accepted by every command.
+type commonFlags struct {
+	configPath *string
+	dbPath     *string
+}
+
+func addCommonFlags(fs *flag.FlagSet) *commonFlags {
+	return &commonFlags{
+		configPath: fs.String("config", "", "config file (default spider.yaml or $SPIDER_CONFIG)"),
+		dbPath:     fs.String("db", "", "path to spider.db, overrides db_path"),
+	}
+}
+
+// load reads the config file and environment, applies flag overrides and
+// validates the result.
+func (c *commonFlags) load(overrides ...func(*config.Config)) (*config.Config, error) {
+	cfg, err := config.Load(*c.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if *c.dbPath != "" {
+		cfg.DBPath = *c.dbPath
+	}
+	for _, override := range overrides {
+		override(cfg)
+	}
+	cfg.ExpandShard()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+func (c *commonFlags) openDatabase() (*config.Config, *storage.Database, error) {
+	cfg, err := c.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := storage.NewDatabase(cfg.DBPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	db.SetVersionRetention(cfg.Versions)
+	db.SetEventLog(cfg.Events.Enabled())
+	return cfg, db, nil
+}
@@ -0,0 +1,257 @@
+package boilerplate
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Blocks shorter than this are too generic to strip safely
+	minBlockLength = 40
+
+	// Cap on tracked candidate blocks per host so memory stays bounded
+	maxBlocksPerHost = 5000
+)
+
+type Template struct {
+	Host      string
+	Hash      uint64
+	Text      string
+	PageCount int
+	HostPages int
+}
+
+type Detector struct {
+	hosts      map[string]*hostStats
+	minPages   int
+	minRatio   float64
+	flushEvery int
+	mu         sync.Mutex
+}
+
+type hostStats struct {
+	pages        int
+	blocks       map[uint64]*block
+	sinceFlush   int
+	newTemplates bool
+}
+
+type block struct {
+	text      string
+	pageCount int
+}
+
+// NewDetector only strips once a host has minPages observed pages, and
+// treats a block as a template when it appears on minRatio of them.
+func NewDetector(minPages int, minRatio float64) *Detector {
+	if minPages <= 0 {
+		minPages = 10
+	}
+	if minRatio <= 0 {
+		minRatio = 0.3
+	}
+
+	return &Detector{
+		hosts:      make(map[string]*hostStats),
+		minPages:   minPages,
+		minRatio:   minRatio,
+		flushEvery: 50,
+	}
+}
+
+func HashBlock(text string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	return h.Sum64()
+}
+
+// Load seeds the detector with templates persisted by a previous crawl.
+func (d *Detector) Load(templates []Template) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, t := range templates {
+		stats := d.getHost(t.Host)
+		if t.HostPages > stats.pages {
+			stats.pages = t.HostPages
+		}
+		stats.blocks[t.Hash] = &block{text: t.Text, pageCount: t.PageCount}
+	}
+}
+
+// Observe records the blocks of one page and reports whether the host is
+// due to have its templates persisted.
+func (d *Detector) Observe(host string, blocks []string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.getHost(host)
+	stats.pages++
+	stats.sinceFlush++
+	if stats.pages == d.minPages {
+		stats.newTemplates = true
+	}
+
+	seen := make(map[uint64]bool, len(blocks))
+	for _, text := range blocks {
+		text = normalize(text)
+		if len(text) < minBlockLength {
+			continue
+		}
+
+		hash := HashBlock(text)
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		b, exists := stats.blocks[hash]
+		if !exists {
+			if len(stats.blocks) >= maxBlocksPerHost {
+				stats.prune()
+				if len(stats.blocks) >= maxBlocksPerHost {
+					continue
+				}
+			}
+			b = &block{text: text}
+			stats.blocks[hash] = b
+		}
+
+		wasTemplate := d.isTemplate(stats, b)
+		b.pageCount++
+		if !wasTemplate && d.isTemplate(stats, b) {
+			stats.newTemplates = true
+		}
+	}
+
+	return stats.sinceFlush >= d.flushEvery
+}
+
+// Strip removes every learned template block of the host from content.
+func (d *Detector) Strip(host string, content string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats, exists := d.hosts[host]
+	if !exists || stats.pages < d.minPages {
+		return content
+	}
+
+	return RemoveBlocks(content, func(block string) bool {
+		b, exists := stats.blocks[HashBlock(block)]
+		return exists && b.text == block && d.isTemplate(stats, b)
+	})
+}
+
+// RemoveBlocks drops the lines of content that are template blocks. The
+// parser puts every block on a line of its own, so only whole blocks are
+// removed: a paragraph that merely contains a template's text is kept.
+func RemoveBlocks(content string, isTemplate func(block string) bool) string {
+	lines := strings.Split(content, "\n")
+
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !isTemplate(normalize(line)) {
+			kept = append(kept, line)
+		}
+	}
+
+	if len(kept) == len(lines) {
+		return content
+	}
+	return strings.Join(kept, "\n")
+}
+
+// Templates returns the current templates of a host and resets its flush
+// counter.
+func (d *Detector) Templates(host string) []Template {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats, exists := d.hosts[host]
+	if !exists {
+		return nil
+	}
+	stats.sinceFlush = 0
+
+	var templates []Template
+	for hash, b := range stats.blocks {
+		if d.isTemplate(stats, b) {
+			templates = append(templates, Template{
+				Host:      host,
+				Hash:      hash,
+				Text:      b.text,
+				PageCount: b.pageCount,
+				HostPages: stats.pages,
+			})
+		}
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].PageCount > templates[j].PageCount
+	})
+	return templates
+}
+
+// ChangedHosts returns hosts that gained templates since the last call, so
+// previously saved pages of those hosts can be re-stripped.
+func (d *Detector) ChangedHosts() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var hosts []string
+	for host, stats := range d.hosts {
+		if stats.newTemplates {
+			hosts = append(hosts, host)
+			stats.newTemplates = false
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// PendingHosts returns hosts with pages observed since their last flush.
+func (d *Detector) PendingHosts() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var hosts []string
+	for host, stats := range d.hosts {
+		if stats.sinceFlush > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func (d *Detector) getHost(host string) *hostStats {
+	stats, exists := d.hosts[host]
+	if !exists {
+		stats = &hostStats{blocks: make(map[uint64]*block)}
+		d.hosts[host] = stats
+	}
+	return stats
+}
+
+func (d *Detector) isTemplate(stats *hostStats, b *block) bool {
+	if stats.pages < d.minPages || b.pageCount < 2 {
+		return false
+	}
+	return float64(b.pageCount)/float64(stats.pages) >= d.minRatio
+}
+
+// prune drops blocks that were only ever seen once
+func (s *hostStats) prune() {
+	for hash, b := range s.blocks {
+		if b.pageCount <= 1 {
+			delete(s.blocks, hash)
+		}
+	}
+}
+
+func normalize(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/events"
+	"github.com/dangpham/deisearch/spider/internal/focus"
+	"github.com/dangpham/deisearch/spider/internal/scope"
+	"github.com/dangpham/deisearch/spider/internal/shard"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is read when no -config flag or SPIDER_CONFIG is given and
+// the file exists.
+const DefaultPath = "spider.yaml"
+
+type Config struct {
+	DBPath    string `yaml:"db_path"`
+	LogPath   string `yaml:"log_path"`
+	UserAgent string `yaml:"user_agent"`
+
+	// Address for the /status and /metrics endpoints; empty disables them
+	StatusAddr string `yaml:"status_addr"`
+
+	Workers          int           `yaml:"workers"`
+	RateLimitSec     float32       `yaml:"rate_limit_sec"`
+	MaxPages         int           `yaml:"max_pages"`
+	MaxPDFBytes      int64         `yaml:"max_pdf_bytes"`
+	MaxPDFPages      int           `yaml:"max_pdf_pages"`
+	FeedPollInterval time.Duration `yaml:"feed_poll_interval"`
+
+	BoilerplateMinPages int     `yaml:"boilerplate_min_pages"`
+	BoilerplateMinRatio float64 `yaml:"boilerplate_min_ratio"`
+
+	Scope scope.Config `yaml:"scope"`
+	Seeds []string     `yaml:"seeds"`
+
+	// How many earlier versions of re-crawled pages are kept, per host
+	Versions storage.VersionRetention `yaml:"versions"`
+
+	// Splitting the crawl across processes by host; "{shard}" in db_path
+	// and log_path is replaced with the shard ID
+	Shard shard.Config `yaml:"shard"`
+
+	// Where saved page events are published; no sinks disables the event log
+	Events events.Config `yaml:"events"`
+
+	// Topic profile for a focused crawl; no keywords or seed pages crawls
+	// breadth-first
+	Focus focus.Config `yaml:"focus"`
+}
+
+// durationKeys are decoded as time.Duration.
+var durationKeys = map[string]bool{"feed_poll_interval": true, "max_age": true, "retention": true}
+
+// UnmarshalYAML reads bare numbers in duration fields as seconds, so
+// "feed_poll_interval: 0" works; yaml.v3 only parses strings like "30m".
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	type plain Config
+	bareDurationsToSeconds(node)
+	return node.Decode((*plain)(c))
+}
+
+func bareDurationsToSeconds(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if durationKeys[key.Value] && value.Kind == yaml.ScalarNode && (value.Tag == "!!int" || value.Tag == "!!float") {
+			value.Value += "s"
+			value.Tag = "!!str"
+		}
+		bareDurationsToSeconds(value)
+	}
+}
+
+// ShardPlaceholder lets every shard share one config file.
+const ShardPlaceholder = "{shard}"
+
+// ExpandShard replaces ShardPlaceholder in the paths with the shard ID.
+func (c *Config) ExpandShard() {
+	id := strconv.Itoa(c.Shard.ID)
+	c.DBPath = strings.ReplaceAll(c.DBPath, ShardPlaceholder, id)
+	c.LogPath = strings.ReplaceAll(c.LogPath, ShardPlaceholder, id)
+}
+
+func Default() *Config {
+	return &Config{
+		DBPath:       "spider.db",
+		LogPath:      "crawler.log",
+		UserAgent:    "DeiSearchBot/1.0",
+		Workers:      40,
+		RateLimitSec: 0.05,
+		MaxPages:     500000,
+		Versions:     storage.DefaultVersionRetention,
+	}
+}
+
+// Load reads the config file over the defaults, then applies SPIDER_*
+// environment overrides. An empty path falls back to SPIDER_CONFIG, then
+// DefaultPath if it exists.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if path == "" {
+		path = os.Getenv("SPIDER_CONFIG")
+		explicit = path != ""
+	}
+	if path == "" {
+		path = DefaultPath
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// No config file: defaults and overrides only
+	default:
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	var errs []error
+
+	str := func(name string, field *string) {
+		if value, ok := os.LookupEnv(name); ok {
+			*field = value
+		}
+	}
+	integer := func(name string, field *int) {
+		if value, ok := os.LookupEnv(name); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not an integer", name, value))
+				return
+			}
+			*field = n
+		}
+	}
+
+	str("SPIDER_DB_PATH", &c.DBPath)
+	str("SPIDER_LOG_PATH", &c.LogPath)
+	str("SPIDER_USER_AGENT", &c.UserAgent)
+	str("SPIDER_STATUS_ADDR", &c.StatusAddr)
+	integer("SPIDER_WORKERS", &c.Workers)
+	integer("SPIDER_MAX_PAGES", &c.MaxPages)
+	integer("SPIDER_SHARD_ID", &c.Shard.ID)
+	str("SPIDER_SHARD_TOKEN", &c.Shard.Token)
+
+	if value, ok := os.LookupEnv("SPIDER_RATE_LIMIT_SEC"); ok {
+		rate, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("SPIDER_RATE_LIMIT_SEC: %q is not a number", value))
+		} else {
+			c.RateLimitSec = float32(rate)
+		}
+	}
+	if value, ok := os.LookupEnv("SPIDER_FEED_POLL_INTERVAL"); ok {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("SPIDER_FEED_POLL_INTERVAL: %q is not a duration", value))
+		} else {
+			c.FeedPollInterval = interval
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if strings.TrimSpace(c.DBPath) == "" {
+		errs = append(errs, errors.New("db_path must be set"))
+	}
+	if strings.TrimSpace(c.UserAgent) == "" {
+		errs = append(errs, errors.New("user_agent must be set"))
+	}
+	if c.StatusAddr != "" {
+		if _, _, err := net.SplitHostPort(c.StatusAddr); err != nil {
+			errs = append(errs, fmt.Errorf("status_addr %q must be host:port or :port", c.StatusAddr))
+		}
+	}
+	if c.Workers < 1 {
+		errs = append(errs, fmt.Errorf("workers must be at least 1, got %d", c.Workers))
+	}
+	if c.RateLimitSec < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit_sec must not be negative, got %v", c.RateLimitSec))
+	}
+	if c.MaxPages < 0 {
+		errs = append(errs, fmt.Errorf("max_pages must not be negative, got %d", c.MaxPages))
+	}
+	if c.MaxPDFBytes < 0 || c.MaxPDFPages < 0 {
+		errs = append(errs, errors.New("max_pdf_bytes and max_pdf_pages must not be negative"))
+	}
+	if c.FeedPollInterval < 0 {
+		errs = append(errs, fmt.Errorf("feed_poll_interval must not be negative, got %v", c.FeedPollInterval))
+	}
+	if c.BoilerplateMinRatio < 0 || c.BoilerplateMinRatio > 1 {
+		errs = append(errs, fmt.Errorf("boilerplate_min_ratio must be between 0 and 1, got %v", c.BoilerplateMinRatio))
+	}
+	for _, seed := range c.Seeds {
+		if err := ValidateURL(seed); err != nil {
+			errs = append(errs, fmt.Errorf("seed %w", err))
+		}
+	}
+	if _, err := scope.Compile(c.Scope); err != nil {
+		errs = append(errs, fmt.Errorf("scope: %w", err))
+	}
+	if c.Versions.Keep < 0 || c.Versions.MaxAge < 0 {
+		errs = append(errs, errors.New("versions: keep and max_age must not be negative"))
+	}
+	for host, policy := range c.Versions.Hosts {
+		if policy.Keep < 0 || policy.MaxAge < 0 {
+			errs = append(errs, fmt.Errorf("versions.hosts.%s: keep and max_age must not be negative", host))
+		}
+	}
+	if err := c.Shard.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("shard: %w", err))
+	}
+	if err := c.Events.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("events: %w", err))
+	}
+	for _, page := range c.Focus.SeedPages {
+		if err := ValidateURL(page); err != nil {
+			errs = append(errs, fmt.Errorf("focus: seed page %w", err))
+		}
+	}
+	if err := c.Focus.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("focus: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateURL accepts absolute http and https URLs.
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", rawURL)
+	}
+	return nil
+}
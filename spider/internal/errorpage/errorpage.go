@@ -0,0 +1,196 @@
+package errorpage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	ClassSoft404      = "soft_404"
+	ClassNotFound     = "not_found"
+	ClassAccessDenied = "access_denied"
+	ClassLoginWall    = "login_wall"
+	ClassServerError  = "server_error"
+)
+
+const (
+	// Error pages are short; longer pages that mention "404" are usually
+	// articles about errors, not errors themselves
+	maxErrorPageLength = 3000
+
+	// Max differing simhash bits for a page to count as the probe page
+	maxFingerprintDistance = 4
+
+	// Below this many scored words a simhash says little: near-empty pages
+	// all hash to (almost) 0, so they would match any near-empty probe
+	minFingerprintWords = 8
+
+	ProbeMaxAge = 7 * 24 * time.Hour
+)
+
+// Title patterns match a whole title segment, so "Page Not Found | Example"
+// is an error title but "Not Found (album)" and "Error 502 explained" aren't
+var classPatterns = []struct {
+	class   string
+	title   *regexp.Regexp
+	content *regexp.Regexp
+}{
+	{
+		ClassNotFound,
+		regexp.MustCompile(`(?i)^(error )?(404( error)?|(404 )?(page )?not found|page (does not|doesn't|no longer) exists?|(page )?no longer available|page (has been )?removed)[.!]?$`),
+		regexp.MustCompile(`(?i)(\b404\b.{0,20}not found|page (you|you're|you are) (were )?looking for (could not|couldn't|can't|cannot|does not|doesn't|no longer)|page (does not|doesn't) exist|page not found)`),
+	},
+	{
+		ClassAccessDenied,
+		regexp.MustCompile(`(?i)^(error )?(403( error)?|(403 )?(access denied|forbidden|unauthori[sz]ed|not authori[sz]ed|permission denied))[.!]?$`),
+		regexp.MustCompile(`(?i)(access (is )?denied|you (do not|don't) have permission|not authori[sz]ed to (view|access))`),
+	},
+	{
+		ClassLoginWall,
+		regexp.MustCompile(`(?i)^(please )?(log ?in|sign ?in)( required)?[.!]?$`),
+		regexp.MustCompile(`(?i)((log|sign) ?in to (continue|view|see|access|read)|you must be (logged|signed) in|please (log|sign) ?in)`),
+	},
+	{
+		ClassServerError,
+		regexp.MustCompile(`(?i)^(error )?(50[0234]( error)?|(50[0234] )?(internal server error|service (temporarily )?unavailable|bad gateway)|something went wrong)[.!]?$`),
+		regexp.MustCompile(`(?i)(internal server error|service (is )?(temporarily )?unavailable|something went wrong on our end)`),
+	},
+}
+
+// Separators between a title and the site name, e.g. "Not Found | Example"
+var titleSeparator = regexp.MustCompile(`\s+[-|–—]\s+|\s*\|\s*`)
+
+// Classify applies title and content heuristics for error, access-denied
+// and login pages served with a 200 status. Returns "" for normal pages.
+func Classify(title, content string) string {
+	if utf8.RuneCountInString(content) > maxErrorPageLength {
+		return ""
+	}
+
+	for _, p := range classPatterns {
+		if p.content.MatchString(content) || errorTitle(p.title, title) {
+			return p.class
+		}
+	}
+	return ""
+}
+
+// errorTitle reports whether every segment of the title but one (the site
+// name) is an error title, as in "404 - Page Not Found | Example".
+func errorTitle(pattern *regexp.Regexp, title string) bool {
+	segments := titleSeparator.Split(strings.TrimSpace(title), -1)
+	matched := 0
+	for _, segment := range segments {
+		if pattern.MatchString(strings.TrimSpace(segment)) {
+			matched++
+		}
+	}
+	return matched > 0 && matched >= len(segments)-1
+}
+
+// Probe is what a host serves for a path that cannot exist.
+type Probe struct {
+	Host        string
+	StatusCode  int
+	FinalURL    string // where the probe ended up after redirects
+	Title       string
+	Fingerprint uint64
+	Words       int // scored words behind Fingerprint
+	ProbedAt    time.Time
+}
+
+// ServesSoft404 reports whether the host answers missing paths with 200.
+func (p *Probe) ServesSoft404() bool {
+	return p != nil && p.StatusCode == 200
+}
+
+// Matches reports whether a page looks like the host's not-found page.
+// The page the probe redirected to (often the homepage) never matches
+// itself, only other URLs that redirect there. When the probe or the page
+// has too few words for a meaningful fingerprint, the comparison is
+// inconclusive and the page doesn't match.
+func (p *Probe) Matches(pageURL, content string) bool {
+	if !p.ServesSoft404() {
+		return false
+	}
+	if p.FinalURL != "" && pageURL == p.FinalURL {
+		return false
+	}
+	if p.Words < minFingerprintWords {
+		return false
+	}
+	words := scoredWords(content)
+	if len(words) < minFingerprintWords {
+		return false
+	}
+	return Similar(p.Fingerprint, simhash(words))
+}
+
+// ProbePath returns a random path that should not exist on any host.
+func ProbePath() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "/deisearch-probe-" + hex.EncodeToString(b)
+}
+
+// Fingerprint is a 64-bit simhash of the content's words, so near-identical
+// pages hash close together. Words with digits or slashes are skipped: they
+// are usually the requested path a not-found page echoes back.
+func Fingerprint(content string) uint64 {
+	return simhash(scoredWords(content))
+}
+
+// WordCount returns how many words Fingerprint scores in the content.
+func WordCount(content string) int {
+	return len(scoredWords(content))
+}
+
+func scoredWords(content string) []string {
+	var words []string
+	for _, word := range strings.Fields(strings.ToLower(content)) {
+		if strings.ContainsAny(word, "/0123456789") {
+			continue
+		}
+		word = strings.TrimFunc(word, unicode.IsPunct)
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func simhash(words []string) uint64 {
+	var weights [64]int
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for i := 0; i < 64; i++ {
+		if weights[i] > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+	return fingerprint
+}
+
+func Similar(a, b uint64) bool {
+	return bits.OnesCount64(a^b) <= maxFingerprintDistance
+}
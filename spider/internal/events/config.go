@@ -0,0 +1,77 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Sink types
+const (
+	SinkFile    = "file"    // appends NDJSON to a file
+	SinkUnix    = "unix"    // writes NDJSON to a Unix socket a consumer listens on
+	SinkWebhook = "webhook" // POSTs NDJSON batches to a URL
+)
+
+// DefaultRetention is how long delivered events stay replayable.
+const DefaultRetention = 24 * time.Hour
+
+type Config struct {
+	// How long delivered events are kept for replay; 0 uses DefaultRetention
+	Retention time.Duration `yaml:"retention"`
+	Sinks     []SinkConfig  `yaml:"sinks"`
+}
+
+type SinkConfig struct {
+	Name string `yaml:"name"` // cursor key; defaults to type:path or type:url
+	Type string `yaml:"type"`
+	Path string `yaml:"path"` // file and unix sinks
+	URL  string `yaml:"url"`  // webhook sinks
+}
+
+// Enabled reports whether any sink is configured. Without one, the spider
+// doesn't write the event log.
+func (c Config) Enabled() bool {
+	return len(c.Sinks) > 0
+}
+
+func (s SinkConfig) ID() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Type == SinkWebhook:
+		return s.Type + ":" + s.URL
+	default:
+		return s.Type + ":" + s.Path
+	}
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Retention < 0 {
+		errs = append(errs, fmt.Errorf("retention must not be negative, got %v", c.Retention))
+	}
+
+	seen := make(map[string]bool)
+	for i, sink := range c.Sinks {
+		switch sink.Type {
+		case SinkFile, SinkUnix:
+			if sink.Path == "" {
+				errs = append(errs, fmt.Errorf("sinks[%d]: %s sink needs a path", i, sink.Type))
+			}
+		case SinkWebhook:
+			if u, err := url.Parse(sink.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("sinks[%d]: webhook url %q is not an absolute http(s) URL", i, sink.URL))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("sinks[%d]: type must be %s, %s or %s, got %q", i, SinkFile, SinkUnix, SinkWebhook, sink.Type))
+		}
+
+		if seen[sink.ID()] {
+			errs = append(errs, fmt.Errorf("sinks[%d]: duplicate sink %q", i, sink.ID()))
+		}
+		seen[sink.ID()] = true
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,220 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+const (
+	publishBatchSize = 500
+	publishInterval  = 500 * time.Millisecond
+	maxRetryDelay    = time.Minute
+	pruneInterval    = 10 * time.Minute
+)
+
+type SinkStats struct {
+	Sink      string
+	Cursor    int64 // last event delivered
+	Delivered int64 // events delivered by this publisher
+	Failing   bool
+}
+
+type sinkState struct {
+	id        string
+	sink      Sink
+	cursor    int64
+	delivered int64
+	failing   bool // to log once per outage
+	retryAt   time.Time
+	delay     time.Duration
+}
+
+// Publisher tails the page_events outbox and delivers it to every sink,
+// each from its own cursor. A cursor only moves after its sink accepted a
+// batch, so delivery is at least once, across restarts too.
+type Publisher struct {
+	db        *storage.Database
+	retention time.Duration
+	sinks     []*sinkState
+	lastPrune time.Time
+	done      chan struct{}
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+}
+
+func NewPublisher(db *storage.Database, config Config) (*Publisher, error) {
+	if config.Retention == 0 {
+		config.Retention = DefaultRetention
+	}
+
+	p := &Publisher{
+		db:        db,
+		retention: config.Retention,
+		lastPrune: time.Now(),
+		done:      make(chan struct{}),
+	}
+	for _, sc := range config.Sinks {
+		sink, err := NewSink(sc)
+		if err != nil {
+			return nil, err
+		}
+		cursor, err := db.GetEventCursor(sc.ID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cursor of %s: %w", sc.ID(), err)
+		}
+		p.sinks = append(p.sinks, &sinkState{id: sc.ID(), sink: sink, cursor: cursor})
+	}
+	return p, nil
+}
+
+// Start delivers new events in the background until Close.
+func (p *Publisher) Start() {
+	p.wg.Add(1)
+	go p.run()
+}
+
+func (p *Publisher) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(publishInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+			p.publish(ctx, false)
+			cancel()
+
+			if time.Since(p.lastPrune) > pruneInterval {
+				p.prune()
+			}
+		}
+	}
+}
+
+// Publish delivers everything pending to every sink, ignoring retry
+// backoff. Returns the sinks' errors.
+func (p *Publisher) Publish(ctx context.Context) error {
+	return p.publish(ctx, true)
+}
+
+func (p *Publisher) publish(ctx context.Context, force bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	now := time.Now()
+	for _, s := range p.sinks {
+		if !force && now.Before(s.retryAt) {
+			continue
+		}
+		if err := p.drain(ctx, s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// drain delivers batches to one sink until it has caught up or fails.
+func (p *Publisher) drain(ctx context.Context, s *sinkState) error {
+	for {
+		batch, err := p.db.ListPageEvents(s.cursor, publishBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := s.sink.Deliver(ctx, batch); err != nil {
+			s.delay = min(max(2*s.delay, publishInterval), maxRetryDelay)
+			s.retryAt = time.Now().Add(s.delay)
+			if !s.failing {
+				log.Printf("🔴 Warning: Failed to deliver page events to %s, retrying: %v", s.id, err)
+			}
+			s.failing = true
+			return err
+		}
+
+		last := batch[len(batch)-1].Seq
+		if err := p.db.SetEventCursor(s.id, last); err != nil {
+			// The batch will be delivered again, which consumers tolerate
+			return fmt.Errorf("failed to save cursor: %w", err)
+		}
+		if s.failing {
+			log.Printf("Delivering page events to %s again", s.id)
+		}
+		s.cursor = last
+		s.delivered += int64(len(batch))
+		s.failing = false
+		s.delay = 0
+	}
+}
+
+// prune drops events every sink has received once they are older than
+// the retention.
+func (p *Publisher) prune() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastPrune = time.Now()
+
+	if len(p.sinks) == 0 {
+		return
+	}
+	delivered := p.sinks[0].cursor
+	for _, s := range p.sinks[1:] {
+		delivered = min(delivered, s.cursor)
+	}
+
+	pruned, err := p.db.PruneEvents(delivered, time.Now().Add(-p.retention))
+	if err != nil {
+		log.Printf("🔴 Warning: %v", err)
+		return
+	}
+	if pruned > 0 {
+		log.Printf("Pruned %d delivered page events", pruned)
+	}
+}
+
+func (p *Publisher) Stats() []SinkStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]SinkStats, len(p.sinks))
+	for i, s := range p.sinks {
+		stats[i] = SinkStats{Sink: s.id, Cursor: s.cursor, Delivered: s.delivered, Failing: s.failing}
+	}
+	return stats
+}
+
+// Close makes a last delivery attempt, so events of the final write batch
+// go out, then closes the sinks. Whatever is still undelivered is sent on
+// the next run.
+func (p *Publisher) Close() {
+	select {
+	case <-p.done:
+		return
+	default:
+		close(p.done)
+	}
+	p.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+	p.publish(ctx, true)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.sinks {
+		s.sink.Close()
+	}
+}
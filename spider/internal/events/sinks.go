@@ -0,0 +1,188 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+const deliveryTimeout = 30 * time.Second
+
+// Sink delivers batches of events. A batch counts as delivered only when
+// Deliver returns nil; otherwise the whole batch is sent again later, so
+// consumers should skip seqs they have already handled.
+type Sink interface {
+	Deliver(ctx context.Context, batch []storage.PageEvent) error
+	Close() error
+}
+
+func NewSink(config SinkConfig) (Sink, error) {
+	switch config.Type {
+	case SinkFile:
+		return &fileSink{path: config.Path}, nil
+	case SinkUnix:
+		return &unixSink{path: config.Path}, nil
+	case SinkWebhook:
+		return &webhookSink{url: config.URL, client: &http.Client{Timeout: deliveryTimeout}}, nil
+	}
+	return nil, fmt.Errorf("unknown sink type %q", config.Type)
+}
+
+func encodeBatch(batch []storage.PageEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range batch {
+		if err := encoder.Encode(event); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// fileSink appends one JSON line per event and syncs before reporting the
+// batch delivered. Consumers tail the file.
+type fileSink struct {
+	path string
+	file *os.File
+}
+
+func (s *fileSink) Deliver(ctx context.Context, batch []storage.PageEvent) error {
+	data, err := encodeBatch(batch)
+	if err != nil {
+		return err
+	}
+
+	if s.file == nil {
+		file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", s.path, err)
+		}
+		s.file = file
+	}
+
+	if _, err := s.file.Write(data); err != nil {
+		s.Close()
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	return s.file.Sync()
+}
+
+func (s *fileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// unixSink writes JSON lines to a socket the consumer listens on. The
+// consumer answers each event it handled with a line holding its seq; a
+// batch is delivered once its last seq is acknowledged.
+type unixSink struct {
+	path   string
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (s *unixSink) Deliver(ctx context.Context, batch []storage.PageEvent) error {
+	data, err := encodeBatch(batch)
+	if err != nil {
+		return err
+	}
+
+	if s.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "unix", s.path)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", s.path, err)
+		}
+		s.conn = conn
+		s.reader = bufio.NewReader(conn)
+	}
+
+	// A failed exchange leaves the stream in an unknown state; reconnect
+	err = s.exchange(data, batch[len(batch)-1].Seq)
+	if err != nil {
+		s.Close()
+	}
+	return err
+}
+
+func (s *unixSink) exchange(data []byte, lastSeq int64) error {
+	s.conn.SetDeadline(time.Now().Add(deliveryTimeout))
+	if _, err := s.conn.Write(data); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", s.path, err)
+	}
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("no acknowledgement from %s: %w", s.path, err)
+		}
+		acked, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s sent %q instead of a seq", s.path, strings.TrimSpace(line))
+		}
+		if acked >= lastSeq {
+			return nil
+		}
+	}
+}
+
+func (s *unixSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	s.reader = nil
+	return err
+}
+
+// webhookSink POSTs each batch as application/x-ndjson. Any 2xx response
+// acknowledges it.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Deliver(ctx context.Context, batch []storage.PageEvent) error {
+	data, err := encodeBatch(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	return nil
+}
@@ -0,0 +1,135 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type Item struct {
+	URL         string
+	Title       string
+	PublishedAt time.Time
+}
+
+// Covers RSS 2.0 (<rss><channel><item>), RSS 1.0 (<rdf:RDF><item>) and
+// Atom (<feed><entry>) by decoding into one loose structure.
+type document struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Link    string `xml:"link"`
+	GUID    string `xml:"guid"`
+	Title   string `xml:"title"`
+	PubDate string `xml:"pubDate"`
+	Date    string `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+type atomEntry struct {
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Title     string `xml:"title"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+func Parse(r io.Reader) ([]Item, error) {
+	decoder := xml.NewDecoder(r)
+	// Feeds in the wild declare all sorts of charsets; the ASCII-compatible
+	// subset is all we need for links and dates
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	decoder.Strict = false
+
+	var doc document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	var items []Item
+
+	rssItems := append(doc.Channel.Items, doc.Items...)
+	for _, it := range rssItems {
+		link := strings.TrimSpace(it.Link)
+		if link == "" && strings.HasPrefix(strings.TrimSpace(it.GUID), "http") {
+			link = strings.TrimSpace(it.GUID)
+		}
+		if link == "" {
+			continue
+		}
+
+		published := parseDate(it.PubDate)
+		if published.IsZero() {
+			published = parseDate(it.Date)
+		}
+
+		items = append(items, Item{
+			URL:         link,
+			Title:       strings.TrimSpace(it.Title),
+			PublishedAt: published,
+		})
+	}
+
+	for _, entry := range doc.Entries {
+		link := ""
+		for _, l := range entry.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = strings.TrimSpace(l.Href)
+				break
+			}
+		}
+		if link == "" {
+			continue
+		}
+
+		published := parseDate(entry.Published)
+		if published.IsZero() {
+			published = parseDate(entry.Updated)
+		}
+
+		items = append(items, Item{
+			URL:         link,
+			Title:       strings.TrimSpace(entry.Title),
+			PublishedAt: published,
+		})
+	}
+
+	return items, nil
+}
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -2,6 +2,7 @@ package fetcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,6 +12,8 @@ import (
 	"github.com/temoto/robotstxt"
 )
 
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
 type Fetcher struct {
 	client      *http.Client
 	robotsCache map[string]*robotstxt.RobotsData
@@ -34,8 +37,30 @@ func New(userAgent string) *Fetcher {
 }
 
 func (f *Fetcher) Fetch(ctx context.Context, urlStr string) (*http.Response, error) {
+	return f.do(ctx, urlStr, map[string]string{
+		"Accept": "text/html,application/xhtml+xml,application/xml;q=0.9,application/pdf;q=0.8,*/*;q=0.7",
+	})
+}
+
+// FetchFeed fetches an RSS/Atom feed conditionally: when etag or
+// lastModified from a previous poll are given, an unchanged feed comes
+// back as 304 Not Modified.
+func (f *Fetcher) FetchFeed(ctx context.Context, urlStr, etag, lastModified string) (*http.Response, error) {
+	headers := map[string]string{
+		"Accept": "application/rss+xml,application/atom+xml,application/xml;q=0.9,text/xml;q=0.8,*/*;q=0.5",
+	}
+	if etag != "" {
+		headers["If-None-Match"] = etag
+	}
+	if lastModified != "" {
+		headers["If-Modified-Since"] = lastModified
+	}
+	return f.do(ctx, urlStr, headers)
+}
+
+func (f *Fetcher) do(ctx context.Context, urlStr string, headers map[string]string) (*http.Response, error) {
 	if !f.IsAllowed(urlStr) {
-		return nil, fmt.Errorf("disallowed by robots.txt")
+		return nil, ErrDisallowed
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
@@ -44,7 +69,9 @@ func (f *Fetcher) Fetch(ctx context.Context, urlStr string) (*http.Response, err
 	}
 
 	req.Header.Set("User-Agent", f.userAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
 	resp, err := f.client.Do(req)
 	if err != nil {
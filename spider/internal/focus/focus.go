@@ -0,0 +1,234 @@
+package focus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+// Config turns on focused crawling. The topic is described by keywords,
+// the text of seed pages, or both.
+type Config struct {
+	Keywords  []string `yaml:"keywords"`
+	SeedPages []string `yaml:"seed_pages"` // pages whose text defines the topic
+
+	// Links of pages scoring below this aren't followed; 0 follows all
+	MinRelevance float64 `yaml:"min_relevance"`
+}
+
+func (c Config) Enabled() bool {
+	return len(c.Keywords) > 0 || len(c.SeedPages) > 0
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.MinRelevance < 0 || c.MinRelevance > 1 {
+		errs = append(errs, fmt.Errorf("min_relevance must be between 0 and 1, got %v", c.MinRelevance))
+	}
+	for _, keyword := range c.Keywords {
+		if len(tokenize(keyword)) == 0 {
+			errs = append(errs, fmt.Errorf("keyword %q has no indexable words", keyword))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// How a link's priority is made up, from the page it was found on, its
+// anchor text and title, and the words in its URL
+const (
+	parentWeight = 0.5
+	anchorWeight = 0.3
+	urlWeight    = 0.2
+)
+
+// Page titles say more about the topic than any sentence of the body
+const titleBoost = 3
+
+// Classifier scores text against a topic profile: a weighted term vector
+// where keywords have the full weight of 1 and terms of seed pages are
+// weighted by how often they occur.
+type Classifier struct {
+	keywords map[string]bool
+	seedTF   map[string]float64
+	profile  map[string]float64
+	norm     float64
+	seeds    map[string]bool
+	added    map[string]bool // seed pages already in the profile
+	mu       sync.RWMutex
+}
+
+func NewClassifier(config Config) *Classifier {
+	c := &Classifier{
+		keywords: make(map[string]bool),
+		seedTF:   make(map[string]float64),
+		seeds:    make(map[string]bool),
+		added:    make(map[string]bool),
+	}
+	for _, keyword := range config.Keywords {
+		for _, term := range tokenize(keyword) {
+			c.keywords[term] = true
+		}
+	}
+	for _, url := range config.SeedPages {
+		c.seeds[parser.NormalizeURLString(url)] = true
+	}
+	c.rebuild()
+	return c
+}
+
+// IsSeedPage reports whether a page's text belongs in the profile. The URL
+// must be normalized.
+func (c *Classifier) IsSeedPage(url string) bool {
+	return c.seeds[url]
+}
+
+// AddSeedPage adds a seed page's text to the profile, once per page.
+func (c *Classifier) AddSeedPage(url, title, content string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.added[url] {
+		return
+	}
+	c.added[url] = true
+	for term, count := range termCounts(title, content) {
+		c.seedTF[term] += count
+	}
+	c.rebuild()
+}
+
+// rebuild recomputes the profile. Seed terms are scaled so the most
+// frequent one weighs as much as a keyword. Callers hold the lock.
+func (c *Classifier) rebuild() {
+	profile := make(map[string]float64, len(c.seedTF)+len(c.keywords))
+
+	maxWeight := 0.0
+	for _, count := range c.seedTF {
+		maxWeight = math.Max(maxWeight, math.Log1p(count))
+	}
+	for term, count := range c.seedTF {
+		profile[term] = math.Log1p(count) / maxWeight
+	}
+	for term := range c.keywords {
+		profile[term] = 1
+	}
+
+	norm := 0.0
+	for _, weight := range profile {
+		norm += weight * weight
+	}
+	c.profile = profile
+	c.norm = math.Sqrt(norm)
+}
+
+// PageRelevance is the cosine similarity between a page and the profile,
+// from 0 (nothing in common) to 1.
+func (c *Classifier) PageRelevance(title, content string) float64 {
+	counts := termCounts(title, content)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.norm == 0 || len(counts) == 0 {
+		return 0
+	}
+
+	dot, norm := 0.0, 0.0
+	for term, count := range counts {
+		weight := math.Log1p(count)
+		norm += weight * weight
+		dot += weight * c.profile[term]
+	}
+	return dot / (math.Sqrt(norm) * c.norm)
+}
+
+// TextRelevance is the average profile weight of the words of a short
+// text such as anchor text, from 0 to 1. Cosine similarity would punish
+// short texts for all the profile terms they can't contain.
+func (c *Classifier) TextRelevance(text string) float64 {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sum := 0.0
+	for _, term := range terms {
+		sum += c.profile[term]
+	}
+	return sum / float64(len(terms))
+}
+
+// LinkPriority is the frontier priority of a link, from 0 to 1. Links
+// inherit most of it from the relevance of the page they were found on.
+func (c *Classifier) LinkPriority(parentRelevance float64, anchorText, title, url string) float64 {
+	anchor := c.TextRelevance(anchorText + " " + title)
+	return parentWeight*parentRelevance + anchorWeight*anchor + urlWeight*c.TextRelevance(urlWords(url))
+}
+
+func termCounts(title, content string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, term := range tokenize(title) {
+		counts[term] += titleBoost
+	}
+	for _, term := range tokenize(content) {
+		counts[term]++
+	}
+	return counts
+}
+
+// urlWords keeps the path of a URL, whose segments and slugs often name
+// the topic ("/blog/postgres-index-internals").
+func urlWords(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	}
+	if i := strings.Index(url, "/"); i >= 0 {
+		return url[i:]
+	}
+	return ""
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "can": true, "for": true, "from": true, "has": true, "have": true, "how": true,
+	"if": true, "in": true, "into": true, "is": true, "it": true, "its": true, "no": true, "not": true,
+	"of": true, "on": true, "or": true, "our": true, "so": true, "that": true, "the": true,
+	"their": true, "then": true, "there": true, "these": true, "this": true, "to": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "which": true, "who": true, "why": true,
+	"will": true, "with": true, "you": true, "your": true, "www": true, "com": true, "html": true,
+	"htm": true, "php": true,
+}
+
+// tokenize lowercases text, splits it into words and folds simple plurals,
+// so "Databases" matches the keyword "database".
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := words[:0]
+	for _, word := range words {
+		if len(word) < 2 || stopwords[word] {
+			continue
+		}
+		terms = append(terms, singular(word))
+	}
+	return terms
+}
+
+func singular(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return word[:len(word)-1]
+	}
+	return word
+}
@@ -2,123 +2,287 @@ package frontier
 
 import (
 	"container/heap"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/seen"
 )
 
+// FreshPriority puts URLs ahead of everything discovered by link extraction.
+// Used for new feed items.
+const FreshPriority = 100.0
+
 type URLItem struct {
-	URL         string
-	AvailableAt time.Time
-	index       int
+	URL      string
+	Priority float64
+	seq      uint64
+	index    int
 }
 
-type PriorityQueue []*URLItem
+// urlQueue orders one host's URLs by priority, then insertion order.
+type urlQueue []*URLItem
 
-func (pq PriorityQueue) Len() int { return len(pq) }
+func (q urlQueue) Len() int { return len(q) }
 
-func (pq PriorityQueue) Less(i, j int) bool {
-	return pq[i].AvailableAt.Before(pq[j].AvailableAt)
+func (q urlQueue) Less(i, j int) bool {
+	if q[i].Priority != q[j].Priority {
+		return q[i].Priority > q[j].Priority
+	}
+	return q[i].seq < q[j].seq
 }
 
-func (pq PriorityQueue) Swap(i, j int) {
-	pq[i], pq[j] = pq[j], pq[i]
-	pq[i].index = i
-	pq[j].index = j
+func (q urlQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
 }
 
-func (pq *PriorityQueue) Push(x interface{}) {
-	n := len(*pq)
+func (q *urlQueue) Push(x interface{}) {
 	item := x.(*URLItem)
-	item.index = n
-	*pq = append(*pq, item)
+	item.index = len(*q)
+	*q = append(*q, item)
 }
 
-func (pq *PriorityQueue) Pop() interface{} {
-	old := *pq
+func (q *urlQueue) Pop() interface{} {
+	old := *q
 	n := len(old)
 	item := old[n-1]
 	old[n-1] = nil
 	item.index = -1
-	*pq = old[0 : n-1]
+	*q = old[0 : n-1]
 	return item
 }
 
+type hostQueue struct {
+	host        string
+	urls        urlQueue
+	nextAllowed time.Time
+	index       int
+	inReady     bool
+}
+
+// waitingHosts orders hosts by when politeness next allows a fetch.
+type waitingHosts []*hostQueue
+
+func (h waitingHosts) Len() int { return len(h) }
+
+func (h waitingHosts) Less(i, j int) bool {
+	return h[i].nextAllowed.Before(h[j].nextAllowed)
+}
+
+func (h waitingHosts) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waitingHosts) Push(x interface{}) {
+	hq := x.(*hostQueue)
+	hq.index = len(*h)
+	*h = append(*h, hq)
+}
+
+func (h *waitingHosts) Pop() interface{} {
+	old := *h
+	n := len(old)
+	hq := old[n-1]
+	old[n-1] = nil
+	hq.index = -1
+	*h = old[0 : n-1]
+	return hq
+}
+
+// readyHosts orders hosts that may be fetched now by their best URL.
+type readyHosts []*hostQueue
+
+func (h readyHosts) Len() int { return len(h) }
+
+func (h readyHosts) Less(i, j int) bool {
+	pi, pj := h[i].urls[0].Priority, h[j].urls[0].Priority
+	if pi != pj {
+		return pi > pj
+	}
+	return h[i].nextAllowed.Before(h[j].nextAllowed)
+}
+
+func (h readyHosts) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *readyHosts) Push(x interface{}) {
+	hq := x.(*hostQueue)
+	hq.index = len(*h)
+	*h = append(*h, hq)
+}
+
+func (h *readyHosts) Pop() interface{} {
+	old := *h
+	n := len(old)
+	hq := old[n-1]
+	old[n-1] = nil
+	hq.index = -1
+	*h = old[0 : n-1]
+	return hq
+}
+
+// Frontier keeps one queue per host. A host waits in `waiting` until its
+// rate limit allows another fetch, then moves to `ready`, where hosts
+// compete on the priority of their best URL.
 type Frontier struct {
-	queue         *PriorityQueue
-	seen          map[string]bool
-	lastCrawlTime map[string]time.Time
-	mu            sync.Mutex
-	rateLimit     time.Duration
+	hosts     map[string]*hostQueue
+	waiting   *waitingHosts
+	ready     *readyHosts
+	seen      seen.Set
+	size      int
+	seq       uint64
+	mu        sync.Mutex
+	rateLimit time.Duration
 }
 
+// New keeps the seen set in memory, seeded with already crawled URLs.
 func New(crawledURLs []string, rateLimitSeconds float32) *Frontier {
-	pq := make(PriorityQueue, 0)
-	heap.Init(&pq)
-
-	seen := make(map[string]bool)
-	for _, url := range crawledURLs {
-		normalizedURL := parser.NormalizeURLString(url)
-		seen[normalizedURL] = true
+	normalized := make([]string, len(crawledURLs))
+	for i, url := range crawledURLs {
+		normalized[i] = parser.NormalizeURLString(url)
 	}
+	return NewWithSeenSet(seen.NewMemorySet(normalized), rateLimitSeconds)
+}
 
+func NewWithSeenSet(seenSet seen.Set, rateLimitSeconds float32) *Frontier {
 	return &Frontier{
-		queue:         &pq,
-		seen:          seen,
-		lastCrawlTime: make(map[string]time.Time),
-		rateLimit:     time.Duration(rateLimitSeconds * float32(time.Second)),
+		hosts:     make(map[string]*hostQueue),
+		waiting:   &waitingHosts{},
+		ready:     &readyHosts{},
+		seen:      seenSet,
+		rateLimit: time.Duration(rateLimitSeconds * float32(time.Second)),
 	}
 }
 
 func (f *Frontier) AddURL(url string) {
+	normalizedURL := parser.NormalizeURLString(url)
+	if !f.seen.Add(normalizedURL) {
+		return
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	f.push(normalizedURL, 0)
+}
 
-	normalizedURL := parser.NormalizeURLString(url)
-
-	if f.seen[normalizedURL] {
+func (f *Frontier) AddURLs(links []parser.Link) {
+	// The seen set may hit disk, so check it before taking the queue lock
+	var fresh []string
+	for _, link := range links {
+		if f.seen.Add(link.URL) {
+			fresh = append(fresh, link.URL)
+		}
+	}
+	if len(fresh) == 0 {
 		return
 	}
 
-	f.seen[normalizedURL] = true
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	item := &URLItem{
-		URL:         normalizedURL,
-		AvailableAt: time.Now(),
+	for _, url := range fresh {
+		f.push(url, 0)
 	}
-	heap.Push(f.queue, item)
 }
 
-func (f *Frontier) AddURLs(links []parser.Link) {
+// ScoredURL is a discovered link with a priority below FreshPriority,
+// such as its topic relevance in a focused crawl.
+type ScoredURL struct {
+	URL      string
+	Priority float64
+}
+
+// AddScoredURLs queues links so that higher priorities are fetched first,
+// within each host and across hosts that are ready.
+func (f *Frontier) AddScoredURLs(items []ScoredURL) {
+	var fresh []ScoredURL
+	for _, item := range items {
+		if f.seen.Add(item.URL) {
+			fresh = append(fresh, item)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	now := time.Now()
+	for _, item := range fresh {
+		f.push(item.URL, min(item.Priority, FreshPriority-1))
+	}
+}
 
-	for _, link := range links {
-		if f.seen[link.URL] {
-			continue
+type FreshURL struct {
+	URL         string
+	PublishedAt time.Time
+}
+
+// AddFreshURLs queues newly published URLs ahead of regular links, newest
+// first. Per-host rate limits still apply. Returns how many were new.
+func (f *Frontier) AddFreshURLs(items []FreshURL) int {
+	var fresh []FreshURL
+	for _, item := range items {
+		normalizedURL := parser.NormalizeURLString(item.URL)
+		if f.seen.Add(normalizedURL) {
+			fresh = append(fresh, FreshURL{URL: normalizedURL, PublishedAt: item.PublishedAt})
 		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-		f.seen[link.URL] = true
+	now := time.Now()
+	for _, item := range fresh {
+		f.push(item.URL, FreshPriority+recencyBoost(item.PublishedAt, now))
+	}
+	return len(fresh)
+}
 
-		domain := parser.ExtractDomain(link.URL)
+// recencyBoost is in [0, 1): 1 for items published just now, decaying with age.
+func recencyBoost(publishedAt, now time.Time) float64 {
+	if publishedAt.IsZero() {
+		return 0
+	}
+	ageHours := now.Sub(publishedAt).Hours()
+	if ageHours < 0 {
+		ageHours = 0
+	}
+	return 1 / (1 + ageHours/24)
+}
 
-		availableAt := now
-		if lastScheduled, exists := f.lastCrawlTime[domain]; exists {
-			if lastScheduled.After(now) {
-				availableAt = lastScheduled
-			}
-		}
+func (f *Frontier) push(url string, priority float64) {
+	domain := parser.ExtractDomain(url)
 
-		f.lastCrawlTime[domain] = availableAt.Add(f.rateLimit)
+	hq, exists := f.hosts[domain]
+	if !exists {
+		hq = &hostQueue{host: domain, index: -1}
+		f.hosts[domain] = hq
+	}
 
-		item := &URLItem{
-			URL:         link.URL,
-			AvailableAt: availableAt,
-		}
-		heap.Push(f.queue, item)
+	f.seq++
+	heap.Push(&hq.urls, &URLItem{
+		URL:      url,
+		Priority: priority,
+		seq:      f.seq,
+	})
+	f.size++
+
+	switch {
+	case hq.index < 0:
+		// Host had nothing queued; it rejoins once its rate limit allows
+		hq.inReady = false
+		heap.Push(f.waiting, hq)
+	case hq.inReady:
+		heap.Fix(f.ready, hq.index)
 	}
 }
 
@@ -126,38 +290,99 @@ func (f *Frontier) GetNext() (string, time.Duration) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	if f.queue.Len() == 0 {
-		return "", 0
-	}
+	now := time.Now()
 
-	item := (*f.queue)[0]
+	for f.waiting.Len() > 0 && !(*f.waiting)[0].nextAllowed.After(now) {
+		hq := heap.Pop(f.waiting).(*hostQueue)
+		hq.inReady = true
+		heap.Push(f.ready, hq)
+	}
 
-	now := time.Now()
-	if item.AvailableAt.After(now) {
-		return "", item.AvailableAt.Sub(now)
+	if f.ready.Len() == 0 {
+		if f.waiting.Len() == 0 {
+			return "", 0
+		}
+		return "", (*f.waiting)[0].nextAllowed.Sub(now)
 	}
 
-	item = heap.Pop(f.queue).(*URLItem)
+	hq := heap.Pop(f.ready).(*hostQueue)
+	hq.inReady = false
+
+	item := heap.Pop(&hq.urls).(*URLItem)
+	f.size--
+
+	hq.nextAllowed = now.Add(f.rateLimit)
+	if hq.urls.Len() > 0 {
+		heap.Push(f.waiting, hq)
+	}
 
-	domain := parser.ExtractDomain(item.URL)
-	f.lastCrawlTime[domain] = now
 	return item.URL, 0
 }
 
+// RemoveHost drops every queued URL of a host. Returns how many were dropped.
+func (f *Frontier) RemoveHost(host string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	hq, exists := f.hosts[host]
+	if !exists {
+		return 0
+	}
+
+	if hq.index >= 0 {
+		if hq.inReady {
+			heap.Remove(f.ready, hq.index)
+		} else {
+			heap.Remove(f.waiting, hq.index)
+		}
+	}
+	delete(f.hosts, host)
+
+	removed := hq.urls.Len()
+	f.size -= removed
+	return removed
+}
+
 func (f *Frontier) Size() int {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	return f.queue.Len()
+	return f.size
 }
 
 func (f *Frontier) IsEmpty() bool {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	return f.queue.Len() == 0
+	return f.size == 0
 }
 
 func (f *Frontier) HasSeen(url string) bool {
+	return f.seen.Has(url)
+}
+
+type HostSize struct {
+	Host string `json:"host"`
+	URLs int    `json:"urls"`
+}
+
+// LargestHosts returns the n hosts with the most queued URLs, largest first.
+func (f *Frontier) LargestHosts(n int) []HostSize {
 	f.mu.Lock()
-	defer f.mu.Unlock()
-	return f.seen[url]
+	sizes := make([]HostSize, 0, len(f.hosts))
+	for host, hq := range f.hosts {
+		if hq.urls.Len() > 0 {
+			sizes = append(sizes, HostSize{Host: host, URLs: hq.urls.Len()})
+		}
+	}
+	f.mu.Unlock()
+
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].URLs != sizes[j].URLs {
+			return sizes[i].URLs > sizes[j].URLs
+		}
+		return sizes[i].Host < sizes[j].Host
+	})
+	if len(sizes) > n {
+		sizes = sizes[:n]
+	}
+	return sizes
 }
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+const meterWindow = 60 // seconds
+
+// Meter measures an event rate over the last minute, in per-second buckets.
+type Meter struct {
+	started time.Time
+	counts  [meterWindow]int64
+	seconds [meterWindow]int64 // unix second each bucket was last reset for
+	mu      sync.Mutex
+}
+
+func NewMeter() *Meter {
+	return &Meter{started: time.Now()}
+}
+
+func (m *Meter) Mark(n int64) {
+	now := time.Now().Unix()
+	i := now % meterWindow
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seconds[i] != now {
+		m.seconds[i] = now
+		m.counts[i] = 0
+	}
+	m.counts[i] += n
+}
+
+// Rate returns events per second over the last minute, or since the meter
+// was created when that is more recent.
+func (m *Meter) Rate() float64 {
+	now := time.Now()
+	window := now.Sub(m.started).Seconds()
+	if window > meterWindow {
+		window = meterWindow
+	}
+	if window < 1 {
+		window = 1
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total int64
+	for i := range m.counts {
+		if now.Unix()-m.seconds[i] < meterWindow {
+			total += m.counts[i]
+		}
+	}
+	return float64(total) / window
+}
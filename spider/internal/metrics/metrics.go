@@ -0,0 +1,317 @@
+// Package metrics keeps crawl counters and writes them in the Prometheus
+// text exposition format, without pulling in the client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultLatencyBuckets are upper bounds in seconds for fetch latencies.
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics in registration order.
+type Registry struct {
+	collectors []collector
+	mu         sync.Mutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{desc: desc{name, help, "counter"}}
+	r.register(c)
+	return c
+}
+
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, "counter"}, label: label, counters: make(map[string]*Counter)}
+	r.register(c)
+	return c
+}
+
+// NewCounterFunc exposes a counter kept elsewhere.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{name, help, "counter"}, fn: fn})
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{name, help, "gauge"}, fn: fn})
+}
+
+// NewGaugeVecFunc exposes one gauge per label value returned by fn.
+func (r *Registry) NewGaugeVecFunc(name, help, label string, fn func() map[string]float64) {
+	r.register(&funcVecMetric{desc: desc{name, help, "gauge"}, label: label, fn: fn})
+}
+
+func (r *Registry) NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{desc: desc{name, help, "histogram"}, label: label, buckets: buckets, histograms: make(map[string]*Histogram)}
+	r.register(h)
+	return h
+}
+
+// WriteTo writes every metric in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: w}
+	bw := bufio.NewWriter(counter)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return counter.n, err
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+type desc struct {
+	name string
+	help string
+	kind string
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.kind)
+}
+
+type Counter struct {
+	desc
+	value atomic.Int64
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) Add(n int64) {
+	c.value.Add(n)
+}
+
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	fmt.Fprintf(w, "%s %d\n", c.name, c.Value())
+}
+
+type CounterVec struct {
+	desc
+	label    string
+	counters map[string]*Counter
+	mu       sync.Mutex
+}
+
+func (c *CounterVec) With(value string) *Counter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counter, exists := c.counters[value]
+	if !exists {
+		counter = &Counter{}
+		c.counters[value] = counter
+	}
+	return counter
+}
+
+// Values returns the current count per label value.
+func (c *CounterVec) Values() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make(map[string]int64, len(c.counters))
+	for value, counter := range c.counters {
+		values[value] = counter.Value()
+	}
+	return values
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	values := c.Values()
+	for _, value := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s} %d\n", c.name, labelPair(c.label, value), values[value])
+	}
+}
+
+type funcMetric struct {
+	desc
+	fn func() float64
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	m.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", m.name, formatFloat(m.fn()))
+}
+
+type funcVecMetric struct {
+	desc
+	label string
+	fn    func() map[string]float64
+}
+
+func (m *funcVecMetric) write(w *bufio.Writer) {
+	m.writeHeader(w)
+	values := m.fn()
+	for _, value := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s} %s\n", m.name, labelPair(m.label, value), formatFloat(values[value]))
+	}
+}
+
+type Histogram struct {
+	buckets []float64
+	counts  []uint64 // per bucket, not cumulative; the last one is +Inf
+	count   uint64
+	sum     float64
+	mu      sync.Mutex
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.count++
+	h.sum += v
+}
+
+// Snapshot returns the number of observations and their sum.
+func (h *Histogram) Snapshot() (count uint64, sum float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count, h.sum
+}
+
+func (h *Histogram) write(w *bufio.Writer, name, labels string) {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	prefix := ""
+	if labels != "" {
+		prefix = labels + ","
+	}
+
+	var cumulative uint64
+	for i, bound := range h.buckets {
+		cumulative += counts[i]
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", name, prefix, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, count)
+
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, count)
+}
+
+type HistogramVec struct {
+	desc
+	label      string
+	buckets    []float64
+	histograms map[string]*Histogram
+	mu         sync.Mutex
+}
+
+func (h *HistogramVec) With(value string) *Histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	histogram, exists := h.histograms[value]
+	if !exists {
+		histogram = newHistogram(h.buckets)
+		h.histograms[value] = histogram
+	}
+	return histogram
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+
+	h.mu.Lock()
+	histograms := make(map[string]*Histogram, len(h.histograms))
+	for value, histogram := range h.histograms {
+		histograms[value] = histogram
+	}
+	h.mu.Unlock()
+
+	for _, value := range sortedKeys(histograms) {
+		histograms[value].write(w, h.name, labelPair(h.label, value))
+	}
+}
+
+func labelPair(label, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return fmt.Sprintf("%s=\"%s\"", label, value)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
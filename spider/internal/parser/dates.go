@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Where a page date was found
+const (
+	DateSourceJSONLD       = "json_ld"
+	DateSourceMeta         = "meta"
+	DateSourceMicrodata    = "microdata"
+	DateSourcePDF          = "pdf"
+	DateSourceTimeElement  = "time_element"
+	DateSourceURL          = "url"
+	DateSourceLastModified = "last_modified"
+)
+
+// How much each source is trusted. Explicit structured data wins over
+// markup conventions, and the Last-Modified header is often just the time
+// the response was generated.
+const (
+	confidenceJSONLD      = 0.9
+	confidenceArticleMeta = 0.85
+	confidenceMicrodata   = 0.8
+	confidencePDF         = 0.7
+	confidenceMeta        = 0.7
+	confidenceURLDay      = 0.6
+	confidenceTimeElement = 0.5
+	confidenceURLMonth    = 0.4
+	confidenceHeader      = 0.3
+)
+
+// PageDate is a publication or modification date with the source it came
+// from. The zero value means no date was found.
+type PageDate struct {
+	Time       time.Time
+	Source     string
+	Confidence float64 // 0 to 1
+}
+
+func (d PageDate) IsZero() bool {
+	return d.Time.IsZero()
+}
+
+// consider keeps the candidate if it is plausible and more trusted than
+// the current date.
+func (d *PageDate) consider(value time.Time, source string, confidence float64) {
+	if value.IsZero() || confidence <= d.Confidence || !plausibleDate(value) {
+		return
+	}
+	*d = PageDate{Time: value.UTC(), Source: source, Confidence: confidence}
+}
+
+// The web is younger than 1991; anything later than tomorrow is a typo or
+// an event date
+func plausibleDate(t time.Time) bool {
+	return t.Year() >= 1991 && !t.After(time.Now().Add(24*time.Hour))
+}
+
+var publishedMetaNames = []string{
+	"date", "pubdate", "publishdate", "publish-date", "publish_date", "dc.date",
+	"dc.date.issued", "dcterms.created", "dcterms.issued", "citation_publication_date",
+	"parsely-pub-date", "sailthru.date",
+}
+
+var modifiedMetaNames = []string{
+	"last-modified", "lastmod", "dc.date.modified", "dcterms.modified",
+}
+
+var urlDatePattern = regexp.MustCompile(`/((?:19|20)\d{2})[/-](0[1-9]|1[0-2])(?:[/-](0[1-9]|[12]\d|3[01]))?(?:[/-]|$)`)
+
+// extractDates collects publication and modification dates from the
+// page's structured data, meta tags, <time> elements and URL.
+func extractDates(doc *goquery.Document, meta *Metadata, pageURL string) (published, modified PageDate) {
+	for _, raw := range meta.JSONLD {
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			continue
+		}
+		for _, obj := range flattenJSONLD(value) {
+			if !hasPrimaryType(jsonLDTypes(obj)) {
+				continue
+			}
+			published.consider(parseDate(jsonLDString(obj["datePublished"])), DateSourceJSONLD, confidenceJSONLD)
+			modified.consider(parseDate(jsonLDString(obj["dateModified"])), DateSourceJSONLD, confidenceJSONLD)
+		}
+	}
+
+	og := meta.OpenGraph
+	published.consider(parseDate(firstListValue(og["article:published_time"])), DateSourceMeta, confidenceArticleMeta)
+	modified.consider(parseDate(firstListValue(og["article:modified_time"])), DateSourceMeta, confidenceArticleMeta)
+	modified.consider(parseDate(firstListValue(og["og:updated_time"])), DateSourceMeta, confidenceArticleMeta)
+
+	names := metaNameValues(doc)
+	for _, name := range publishedMetaNames {
+		published.consider(parseDate(names[name]), DateSourceMeta, confidenceMeta)
+	}
+	for _, name := range modifiedMetaNames {
+		modified.consider(parseDate(names[name]), DateSourceMeta, confidenceMeta)
+	}
+
+	for _, item := range meta.Microdata {
+		if !primaryTypes[item.Type] {
+			continue
+		}
+		if values := item.Properties["datePublished"]; len(values) > 0 {
+			published.consider(parseDate(values[0]), DateSourceMicrodata, confidenceMicrodata)
+		}
+		if values := item.Properties["dateModified"]; len(values) > 0 {
+			modified.consider(parseDate(values[0]), DateSourceMicrodata, confidenceMicrodata)
+		}
+	}
+
+	published.consider(timeElementDate(doc), DateSourceTimeElement, confidenceTimeElement)
+
+	if date, confidence := urlDate(pageURL); confidence > 0 {
+		published.consider(date, DateSourceURL, confidence)
+	}
+
+	return published, modified
+}
+
+// AddHeaderDates uses the Last-Modified header as a low-confidence
+// modification date.
+func (p *Page) AddHeaderDates(header http.Header) {
+	if value := header.Get("Last-Modified"); value != "" {
+		if t, err := http.ParseTime(value); err == nil {
+			p.Modified.consider(t, DateSourceLastModified, confidenceHeader)
+		}
+	}
+}
+
+func hasPrimaryType(types []string) bool {
+	for _, t := range types {
+		if primaryTypes[t] {
+			return true
+		}
+	}
+	return false
+}
+
+func metaNameValues(doc *goquery.Document) map[string]string {
+	values := make(map[string]string)
+	doc.Find("meta[name][content], meta[http-equiv][content]").Each(func(i int, s *goquery.Selection) {
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr("name", s.AttrOr("http-equiv", ""))))
+		if _, exists := values[name]; !exists {
+			values[name] = strings.TrimSpace(s.AttrOr("content", ""))
+		}
+	})
+	return values
+}
+
+// timeElementDate reads the first <time datetime> of the article, or of
+// the page when it has no article. Elements marked pubdate come first.
+func timeElementDate(doc *goquery.Document) time.Time {
+	scope := doc.Find("article, main, [role='main']").First()
+	if scope.Length() == 0 {
+		scope = doc.Find("body")
+	}
+
+	for _, selector := range []string{"time[pubdate][datetime]", "time[datetime]"} {
+		var found time.Time
+		scope.Find(selector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+			found = parseDate(s.AttrOr("datetime", ""))
+			return found.IsZero()
+		})
+		if !found.IsZero() {
+			return found
+		}
+	}
+	return time.Time{}
+}
+
+// urlDate finds /2024/05/17/ or /2024-05/ style dates in a URL path. A
+// month without a day dates the page to the first of the month, with less
+// confidence.
+func urlDate(pageURL string) (time.Time, float64) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return time.Time{}, 0
+	}
+
+	match := urlDatePattern.FindStringSubmatch(u.Path)
+	if match == nil {
+		return time.Time{}, 0
+	}
+
+	year, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	if match[3] == "" {
+		return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), confidenceURLMonth
+	}
+
+	day, _ := strconv.Atoi(match[3])
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		// 2023/02/30 rolled over into March
+		return time.Time{}, 0
+	}
+	return date, confidenceURLDay
+}
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"20060102",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// parseDate reads the date formats found in markup. Dates without a zone
+// are taken as UTC. Returns the zero time when nothing matches.
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
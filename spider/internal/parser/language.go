@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Alternate is a language variant declared with
+// <link rel="alternate" hreflang="..." href="...">.
+type Alternate struct {
+	Lang string // lowercased hreflang, e.g. "en", "en-gb" or "x-default"
+	URL  string
+}
+
+// IsEnglish reports whether the page is English or declares no language.
+func (p *Page) IsEnglish() bool {
+	return p.Language == "" || p.Language == "en"
+}
+
+// EnglishAlternate returns the URL of the page's English variant, or ""
+// when it declares none. Plain "en" wins over regional variants.
+func (p *Page) EnglishAlternate() string {
+	// Variants selected by query string normalize to the page itself
+	self := NormalizeURLString(p.URL)
+
+	best, bestRank := "", 0
+	for _, alt := range p.Alternates {
+		if alt.URL == self {
+			continue
+		}
+
+		rank := 0
+		switch {
+		case alt.Lang == "en":
+			rank = 3
+		case alt.Lang == "en-us" || alt.Lang == "en-gb":
+			rank = 2
+		case strings.HasPrefix(alt.Lang, "en-"):
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = alt.URL, rank
+		}
+	}
+	return best
+}
+
+// detectLanguage returns the primary language subtag from the
+// Content-Language header or <html lang>, preferring whichever is not
+// English; "" when neither is set.
+func detectLanguage(resp *http.Response, doc *goquery.Document) string {
+	var header string
+	if resp != nil {
+		header = primarySubtag(strings.Split(resp.Header.Get("Content-Language"), ",")[0])
+	}
+	htmlLang := primarySubtag(doc.Find("html").AttrOr("lang", ""))
+
+	switch {
+	case header != "" && header != "en":
+		return header
+	case htmlLang != "" && htmlLang != "en":
+		return htmlLang
+	case header != "":
+		return header
+	}
+	return htmlLang
+}
+
+func primarySubtag(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	return strings.Split(strings.Split(lang, "-")[0], "_")[0]
+}
+
+func (p *Parser) extractAlternates(doc *goquery.Document, baseURL string) []Alternate {
+	var alternates []Alternate
+	seen := make(map[string]bool)
+
+	doc.Find("link[rel][hreflang][href]").Each(func(i int, s *goquery.Selection) {
+		isAlternate := false
+		for _, token := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			if token == "alternate" {
+				isAlternate = true
+			}
+		}
+		lang := strings.ToLower(strings.TrimSpace(s.AttrOr("hreflang", "")))
+		if !isAlternate || lang == "" {
+			return
+		}
+
+		altURL := ResolveLink(baseURL, s.AttrOr("href", ""))
+		if altURL == "" || seen[lang+" "+altURL] {
+			return
+		}
+		seen[lang+" "+altURL] = true
+		alternates = append(alternates, Alternate{Lang: strings.ReplaceAll(lang, "_", "-"), URL: altURL})
+	})
+
+	return alternates
+}
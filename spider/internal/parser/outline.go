@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Section is one heading of the page with the text that follows it up to
+// the next heading. ID is the anchor usable as a URL fragment, if any.
+type Section struct {
+	Level   int    `json:"level"`
+	Text    string `json:"text"`
+	ID      string `json:"id,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+const maxSectionContent = 5000
+
+func (p *Parser) extractOutline(contentDoc *goquery.Selection) []Section {
+	var sections []Section
+	var current *Section
+	var buf strings.Builder
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Content = truncateText(strings.Join(strings.Fields(buf.String()), " "), maxSectionContent)
+		sections = append(sections, *current)
+		buf.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if level := headingLevel(n.Data); level > 0 {
+				s := goquery.NewDocumentFromNode(n).Selection
+				text := strings.Join(strings.Fields(s.Text()), " ")
+				if text != "" {
+					flush()
+					current = &Section{
+						Level: level,
+						Text:  text,
+						ID:    headingID(s),
+					}
+				}
+				return
+			}
+		}
+
+		if n.Type == html.TextNode && current != nil {
+			buf.WriteString(n.Data)
+			buf.WriteString(" ")
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+
+	for _, node := range contentDoc.Find("body").Nodes {
+		walk(node)
+	}
+	flush()
+
+	return sections
+}
+
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+// headingID finds the fragment that links to a heading: its own id, a
+// nested anchor, or the id of a wrapping section element.
+func headingID(s *goquery.Selection) string {
+	if id := strings.TrimSpace(s.AttrOr("id", "")); id != "" {
+		return id
+	}
+	if anchor := s.Find("[id], a[name]").First(); anchor.Length() > 0 {
+		if id := strings.TrimSpace(anchor.AttrOr("id", "")); id != "" {
+			return id
+		}
+		return strings.TrimSpace(anchor.AttrOr("name", ""))
+	}
+	if parent := s.Parent(); parent.Is("section[id]") && parent.Children().First().IsSelection(s) {
+		return strings.TrimSpace(parent.AttrOr("id", ""))
+	}
+	return ""
+}
+
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	// Avoid cutting a multi-byte rune in half
+	for limit > 0 && !isRuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
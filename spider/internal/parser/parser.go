@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
 )
 
 type Page struct {
@@ -13,13 +14,29 @@ type Page struct {
 	Title       string
 	Description string
 	Content     string
+	Blocks      []string // block-level text used for boilerplate detection
+	Outline     []Section
+	Feeds       []string // RSS/Atom feeds advertised via <link rel="alternate">
+	Language    string   // primary language subtag, "" when undeclared
+	Alternates  []Alternate
+	Published   PageDate
+	Modified    PageDate
+	Metadata    *Metadata
+	ContentType string
 	StatusCode  int
 }
 
 type Link struct {
-	URL string
+	URL        string
+	AnchorText string
+	Title      string
+	Rel        []string
+	Region     string // nav, header, content, sidebar or footer
+	Internal   bool
 }
 
+const maxContentLength = 1000000
+
 type Parser struct{}
 
 func New() *Parser {
@@ -34,25 +51,23 @@ func (p *Parser) Parse(resp *http.Response, baseURL string) (*Page, []Link, erro
 		return nil, nil, err
 	}
 
-	if !isEnglish(resp, doc) {
-		return nil, nil, nil
-	}
-
-	title := strings.TrimSpace(doc.Find("title").First().Text())
-	description := p.extractDescription(doc)
-
-	links := p.extractLinks(doc, baseURL)
-
-	content := p.extractContent(doc)
-
-	page := &Page{
-		URL:         baseURL,
-		Title:       title,
-		Description: description,
-		Content:     content,
-		StatusCode:  resp.StatusCode,
+	// Non-English pages are returned without content or links, but with
+	// their language variants so an English one can be crawled instead
+	if language := detectLanguage(resp, doc); language != "" && language != "en" {
+		page := &Page{
+			URL:         baseURL,
+			Title:       strings.TrimSpace(doc.Find("title").First().Text()),
+			Language:    language,
+			Alternates:  p.extractAlternates(doc, baseURL),
+			ContentType: ContentTypeHTML,
+			StatusCode:  resp.StatusCode,
+		}
+		return page, nil, nil
 	}
 
+	page, links := p.parseDocument(doc, baseURL, resp.StatusCode)
+	page.Language = detectLanguage(resp, doc)
+	page.AddHeaderDates(resp.Header)
 	return page, links, nil
 }
 
@@ -62,22 +77,43 @@ func (p *Parser) ParseHTML(htmlContent string, baseURL string) (*Page, []Link, e
 		return nil, nil, err
 	}
 
+	page, links := p.parseDocument(doc, baseURL, 200)
+	page.Language = detectLanguage(nil, doc)
+	return page, links, nil
+}
+
+func (p *Parser) parseDocument(doc *goquery.Document, baseURL string, statusCode int) (*Page, []Link) {
 	title := strings.TrimSpace(doc.Find("title").First().Text())
 	description := p.extractDescription(doc)
+	metadata := p.extractMetadata(doc)
 
 	links := p.extractLinks(doc, baseURL)
+	feeds := p.extractFeeds(doc, baseURL)
+	alternates := p.extractAlternates(doc, baseURL)
 
-	content := p.extractContent(doc)
+	contentDoc := cleanDocument(doc)
+	content := p.extractContent(contentDoc)
+	blocks := p.extractBlocks(contentDoc)
+	outline := p.extractOutline(contentDoc)
+	published, modified := extractDates(doc, metadata, baseURL)
 
 	page := &Page{
 		URL:         baseURL,
 		Title:       title,
 		Description: description,
 		Content:     content,
-		StatusCode:  200,
+		Blocks:      blocks,
+		Outline:     outline,
+		Feeds:       feeds,
+		Alternates:  alternates,
+		Published:   published,
+		Modified:    modified,
+		Metadata:    metadata,
+		ContentType: ContentTypeHTML,
+		StatusCode:  statusCode,
 	}
 
-	return page, links, nil
+	return page, links
 }
 
 func (p *Parser) extractDescription(doc *goquery.Document) string {
@@ -107,6 +143,8 @@ func (p *Page) HasSufficientContent() bool {
 func (p *Parser) extractLinks(doc *goquery.Document, baseURL string) []Link {
 	var links []Link
 
+	baseHost := ExtractDomain(baseURL)
+
 	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
 		href, exists := s.Attr("href")
 		if !exists {
@@ -118,32 +156,137 @@ func (p *Parser) extractLinks(doc *goquery.Document, baseURL string) []Link {
 			return
 		}
 
+		anchorText := strings.Join(strings.Fields(s.Text()), " ")
+		if anchorText == "" {
+			// Image links carry their anchor text in alt
+			anchorText = strings.TrimSpace(s.Find("img[alt]").First().AttrOr("alt", ""))
+		}
+
 		links = append(links, Link{
-			URL: absoluteURL,
+			URL:        absoluteURL,
+			AnchorText: anchorText,
+			Title:      strings.TrimSpace(s.AttrOr("title", "")),
+			Rel:        strings.Fields(strings.ToLower(s.AttrOr("rel", ""))),
+			Region:     linkRegion(s),
+			Internal:   sameSite(baseHost, ExtractDomain(absoluteURL)),
 		})
 	})
 
 	return links
 }
 
-func (p *Parser) extractContent(doc *goquery.Document) string {
+var feedTypes = map[string]bool{
+	"application/rss+xml":  true,
+	"application/atom+xml": true,
+	"application/rdf+xml":  true,
+}
+
+func (p *Parser) extractFeeds(doc *goquery.Document, baseURL string) []string {
+	var feeds []string
+	seen := make(map[string]bool)
+
+	doc.Find("link[rel][href][type]").Each(func(i int, s *goquery.Selection) {
+		rel := strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+		isAlternate := false
+		for _, token := range rel {
+			if token == "alternate" {
+				isAlternate = true
+			}
+		}
+
+		feedType := strings.ToLower(strings.TrimSpace(s.AttrOr("type", "")))
+		if !isAlternate || !feedTypes[feedType] {
+			return
+		}
+
+		// Feed URLs keep their query string; many are served as ?feed=rss2
+		feedURL := resolveFeedURL(baseURL, s.AttrOr("href", ""))
+		if feedURL != "" && !seen[feedURL] {
+			seen[feedURL] = true
+			feeds = append(feeds, feedURL)
+		}
+	})
+
+	return feeds
+}
+
+func resolveFeedURL(base, href string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	relURL, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+
+	feedURL := baseURL.ResolveReference(relURL)
+	feedURL.Fragment = ""
+	if feedURL.Scheme != "http" && feedURL.Scheme != "https" {
+		return ""
+	}
+	return feedURL.String()
+}
+
+// ResolveLink resolves href against base and returns the normalized URL,
+// or "" if it is not something the crawler should fetch.
+func ResolveLink(base, href string) string {
+	absoluteURL := resolveURL(base, strings.TrimSpace(href))
+	if absoluteURL == "" || !isValidURL(absoluteURL) {
+		return ""
+	}
+	return absoluteURL
+}
+
+func linkRegion(s *goquery.Selection) string {
+	if s.Closest("nav, [role='navigation']").Length() > 0 {
+		return "nav"
+	}
+	if s.Closest("footer, [role='contentinfo']").Length() > 0 {
+		return "footer"
+	}
+
+	// <header> and <aside> inside an article belong to the article itself
+	if s.Closest("article, main, [role='main']").Length() > 0 {
+		return "content"
+	}
+	if s.Closest("header, [role='banner']").Length() > 0 {
+		return "header"
+	}
+	if s.Closest("aside, [role='complementary']").Length() > 0 {
+		return "sidebar"
+	}
+	return "content"
+}
+
+func sameSite(hostA, hostB string) bool {
+	hostA = strings.TrimPrefix(strings.ToLower(hostA), "www.")
+	hostB = strings.TrimPrefix(strings.ToLower(hostB), "www.")
+	return hostA != "" && hostA == hostB
+}
+
+func cleanDocument(doc *goquery.Document) *goquery.Selection {
 	contentDoc := doc.Clone()
 
 	// Remove non-content elements
 	contentDoc.Find("script, style, nav, header, footer, aside, iframe, noscript, form, button").Remove()
 
+	return contentDoc
+}
+
+func (p *Parser) extractContent(contentDoc *goquery.Selection) string {
 	// Strategy 1: Try semantic HTML5 elements first (article, main)
 	var content string
 
 	// Try <article> tag
 	if article := contentDoc.Find("article").First(); article.Length() > 0 {
-		content = article.Text()
+		content = blockText(article)
 	}
 
 	// Try <main> tag if article didn't work well
 	if len(strings.TrimSpace(content)) < 100 {
 		if main := contentDoc.Find("main").First(); main.Length() > 0 {
-			content = main.Text()
+			content = blockText(main)
 		}
 	}
 
@@ -158,7 +301,7 @@ func (p *Parser) extractContent(doc *goquery.Document) string {
 
 		for _, selector := range contentSelectors {
 			if elem := contentDoc.Find(selector).First(); elem.Length() > 0 {
-				text := elem.Text()
+				text := blockText(elem)
 				if len(strings.TrimSpace(text)) > len(strings.TrimSpace(content)) {
 					content = text
 				}
@@ -176,27 +319,97 @@ func (p *Parser) extractContent(doc *goquery.Document) string {
 			}
 		})
 		if len(paragraphs) > 0 {
-			content = strings.Join(paragraphs, " ")
+			content = strings.Join(paragraphs, "\n")
 		}
 	}
 
 	// Strategy 4: Fall back to body if nothing else worked
 	if len(strings.TrimSpace(content)) < 100 {
-		content = contentDoc.Find("body").Text()
+		content = blockText(contentDoc.Find("body"))
 	}
 
-	// Clean up whitespace
-	content = strings.TrimSpace(content)
-	content = strings.Join(strings.Fields(content), " ")
+	// Clean up whitespace, keeping one block per line
+	content = normalizeLines(content)
 
 	// Limit size
-	if len(content) > 1000000 {
-		content = content[:1000000]
-	}
+	content = truncateText(content, maxContentLength)
 
 	return content
 }
 
+const blockSelector = "p, li, h1, h2, h3, h4, h5, h6, blockquote, pre, td, th, dd, dt, figcaption, address, div"
+
+// blockTags start a new line of content. Each leaf block ends up on a line
+// of its own, with the same text extractBlocks reports for it, so template
+// blocks can be removed as whole lines.
+var blockTags = map[string]bool{
+	"p": true, "li": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "td": true, "th": true, "dd": true, "dt": true,
+	"figcaption": true, "address": true, "div": true, "article": true, "section": true,
+	"main": true, "ul": true, "ol": true, "dl": true, "table": true, "tr": true, "figure": true,
+}
+
+var lineBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+// blockText is the selection's text with a line break around every block
+// element.
+func blockText(s *goquery.Selection) string {
+	var buf strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(lineBreaks.Replace(n.Data))
+			return
+		}
+		block := n.Type == html.ElementNode && blockTags[n.Data]
+		if block {
+			buf.WriteByte('\n')
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block {
+			buf.WriteByte('\n')
+		}
+	}
+
+	for _, node := range s.Nodes {
+		walk(node)
+	}
+	return buf.String()
+}
+
+// normalizeLines collapses whitespace within each line and drops empty lines.
+func normalizeLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// extractBlocks returns the text of leaf block elements. Repeated blocks
+// across pages of one host are what the boilerplate detector learns from.
+func (p *Parser) extractBlocks(contentDoc *goquery.Selection) []string {
+	var blocks []string
+
+	contentDoc.Find("body").Find(blockSelector).Each(func(i int, s *goquery.Selection) {
+		if s.Find(blockSelector).Length() > 0 {
+			return
+		}
+
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if text != "" {
+			blocks = append(blocks, text)
+		}
+	})
+
+	return blocks
+}
+
 func resolveURL(base, href string) string {
 	baseURL, err := url.Parse(base)
 	if err != nil {
@@ -247,7 +460,7 @@ func isValidURL(urlStr string) bool {
 
 	path := strings.ToLower(u.Path)
 	skipExtensions := []string{
-		".pdf", ".jpg", ".jpeg", ".png", ".gif", ".svg",
+		".jpg", ".jpeg", ".png", ".gif", ".svg",
 		".css", ".js", ".zip", ".tar", ".gz",
 		".exe", ".dmg", ".iso",
 		".mp4", ".avi", ".mov",
@@ -269,23 +482,3 @@ func ExtractDomain(urlStr string) string {
 	}
 	return u.Host
 }
-
-func isEnglish(resp *http.Response, doc *goquery.Document) bool {
-	contentLang := resp.Header.Get("Content-Language")
-	if contentLang != "" {
-		lang := strings.ToLower(strings.Split(contentLang, ",")[0])
-		lang = strings.TrimSpace(strings.Split(lang, "-")[0])
-		if lang != "en" {
-			return false
-		}
-	}
-
-	htmlLang, exists := doc.Find("html").Attr("lang")
-	if exists {
-		lang := strings.ToLower(strings.Split(htmlLang, "-")[0])
-		if lang != "" && lang != "en" {
-			return false
-		}
-	}
-	return true
-}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	ContentTypeHTML = "text/html"
+	ContentTypePDF  = "application/pdf"
+)
+
+// ParsePDF extracts text and document info from a PDF. Only the first
+// maxPages pages are read; the rest of a long document is dropped.
+func (p *Parser) ParsePDF(data []byte, baseURL string, maxPages int) (page *Page, err error) {
+	// The PDF reader panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			page = nil
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	numPages := reader.NumPage()
+	if maxPages > 0 && numPages > maxPages {
+		numPages = maxPages
+	}
+
+	var sb strings.Builder
+	for i := 1; i <= numPages; i++ {
+		pdfPage := reader.Page(i)
+		if pdfPage.V.IsNull() {
+			continue
+		}
+
+		text, err := pdfPage.GetPlainText(nil)
+		if err != nil {
+			continue
+		}
+		sb.WriteString(text)
+		sb.WriteString(" ")
+
+		if sb.Len() > maxContentLength {
+			break
+		}
+	}
+
+	content := strings.Join(strings.Fields(sb.String()), " ")
+	content = truncateText(content, maxContentLength)
+
+	info := reader.Trailer().Key("Info")
+
+	title := strings.TrimSpace(info.Key("Title").Text())
+	if title == "" {
+		title = pdfFallbackTitle(content, baseURL)
+	}
+
+	metadata := &Metadata{
+		Type:          "PDF",
+		Author:        strings.TrimSpace(info.Key("Author").Text()),
+		PublishedTime: parsePDFDate(info.Key("CreationDate").Text()),
+		ModifiedTime:  parsePDFDate(info.Key("ModDate").Text()),
+	}
+
+	page = &Page{
+		URL:         baseURL,
+		Title:       title,
+		Description: strings.TrimSpace(info.Key("Subject").Text()),
+		Content:     content,
+		ContentType: ContentTypePDF,
+		Metadata:    metadata,
+		StatusCode:  200,
+	}
+	page.Published.consider(parseDate(metadata.PublishedTime), DateSourcePDF, confidencePDF)
+	page.Modified.consider(parseDate(metadata.ModifiedTime), DateSourcePDF, confidencePDF)
+	if date, confidence := urlDate(baseURL); confidence > 0 {
+		page.Published.consider(date, DateSourceURL, confidence)
+	}
+	return page, nil
+}
+
+// pdfFallbackTitle uses the start of the text, or the file name, when the
+// document info has no title.
+func pdfFallbackTitle(content, baseURL string) string {
+	if content != "" {
+		words := strings.Fields(content)
+		if len(words) > 12 {
+			words = words[:12]
+		}
+		return strings.Join(words, " ")
+	}
+	return path.Base(baseURL)
+}
+
+// parsePDFDate converts the PDF date format (D:YYYYMMDDHHmmSSOHH'mm') to RFC 3339.
+func parsePDFDate(value string) string {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	value = strings.ReplaceAll(value, "'", "")
+	if len(value) < 4 {
+		return ""
+	}
+
+	layouts := []string{
+		"20060102150405-0700",
+		"20060102150405Z0700",
+		"20060102150405Z",
+		"20060102150405",
+		"200601021504",
+		"20060102",
+		"200601",
+		"2006",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return ""
+}
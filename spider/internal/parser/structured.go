@@ -0,0 +1,428 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type Metadata struct {
+	Type          string
+	Author        string
+	PublishedTime string
+	ModifiedTime  string
+	Image         string
+	SiteName      string
+	Breadcrumb    []string
+
+	OpenGraph map[string]string // og:*, article:* and twitter:* meta tags
+	JSONLD    []json.RawMessage
+	Microdata []MicrodataItem // schema.org microdata and RDFa items
+}
+
+type MicrodataItem struct {
+	Source     string // "microdata" or "rdfa"
+	Type       string
+	Properties map[string][]string
+}
+
+func (m *Metadata) IsEmpty() bool {
+	return m.Type == "" && m.Author == "" && m.PublishedTime == "" && m.ModifiedTime == "" &&
+		m.Image == "" && m.SiteName == "" && len(m.Breadcrumb) == 0 &&
+		len(m.OpenGraph) == 0 && len(m.JSONLD) == 0 && len(m.Microdata) == 0
+}
+
+// Schema.org types we treat as describing the page itself
+var primaryTypes = map[string]bool{
+	"Article": true, "NewsArticle": true, "BlogPosting": true, "TechArticle": true,
+	"ScholarlyArticle": true, "Report": true, "WebPage": true, "AboutPage": true,
+	"FAQPage": true, "QAPage": true, "Product": true, "Recipe": true, "Event": true,
+	"Book": true, "Course": true, "VideoObject": true, "SoftwareApplication": true,
+	"SoftwareSourceCode": true, "Dataset": true, "HowTo": true, "Review": true,
+}
+
+func (p *Parser) extractMetadata(doc *goquery.Document) *Metadata {
+	meta := &Metadata{
+		OpenGraph: extractOpenGraph(doc),
+	}
+
+	var objects []map[string]interface{}
+	doc.Find("script[type='application/ld+json']").Each(func(i int, s *goquery.Selection) {
+		raw := strings.TrimSpace(s.Text())
+		if raw == "" {
+			return
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return
+		}
+
+		compact, err := json.Marshal(value)
+		if err != nil {
+			return
+		}
+		meta.JSONLD = append(meta.JSONLD, compact)
+		objects = append(objects, flattenJSONLD(value)...)
+	})
+
+	meta.Microdata = append(extractMicrodata(doc), extractRDFa(doc)...)
+
+	// JSON-LD is the most deliberate source, then OpenGraph, then microdata
+	applyJSONLD(meta, objects)
+	applyOpenGraph(meta)
+	applyMicrodata(meta)
+
+	if meta.Author == "" {
+		if author, exists := doc.Find("meta[name='author']").Attr("content"); exists {
+			meta.Author = strings.TrimSpace(author)
+		}
+	}
+
+	return meta
+}
+
+func extractOpenGraph(doc *goquery.Document) map[string]string {
+	tags := make(map[string]string)
+
+	doc.Find("meta[property], meta[name]").Each(func(i int, s *goquery.Selection) {
+		key, exists := s.Attr("property")
+		if !exists {
+			key, _ = s.Attr("name")
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if !strings.HasPrefix(key, "og:") && !strings.HasPrefix(key, "article:") && !strings.HasPrefix(key, "twitter:") {
+			return
+		}
+
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		if content == "" {
+			return
+		}
+
+		// Repeated tags (article:tag, og:image) are joined rather than dropped
+		if existing, ok := tags[key]; ok {
+			if existing != content {
+				tags[key] = existing + ", " + content
+			}
+			return
+		}
+		tags[key] = content
+	})
+
+	return tags
+}
+
+func flattenJSONLD(value interface{}) []map[string]interface{} {
+	var objects []map[string]interface{}
+
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			objects = append(objects, flattenJSONLD(item)...)
+		}
+	case map[string]interface{}:
+		objects = append(objects, v)
+		if graph, ok := v["@graph"]; ok {
+			objects = append(objects, flattenJSONLD(graph)...)
+		}
+	}
+
+	return objects
+}
+
+func applyJSONLD(meta *Metadata, objects []map[string]interface{}) {
+	for _, obj := range objects {
+		types := jsonLDTypes(obj)
+
+		for _, t := range types {
+			if t == "BreadcrumbList" && len(meta.Breadcrumb) == 0 {
+				meta.Breadcrumb = breadcrumbFromJSONLD(obj)
+			}
+			if t == "WebSite" && meta.SiteName == "" {
+				meta.SiteName = jsonLDString(obj["name"])
+			}
+		}
+
+		primary := ""
+		for _, t := range types {
+			if primaryTypes[t] {
+				primary = t
+				break
+			}
+		}
+		if primary == "" {
+			continue
+		}
+
+		// A specific type like Article beats a generic WebPage
+		if meta.Type == "" || (meta.Type == "WebPage" && primary != "WebPage") {
+			meta.Type = primary
+		}
+		if meta.Author == "" {
+			meta.Author = jsonLDName(obj["author"])
+		}
+		if meta.PublishedTime == "" {
+			meta.PublishedTime = jsonLDString(obj["datePublished"])
+		}
+		if meta.ModifiedTime == "" {
+			meta.ModifiedTime = jsonLDString(obj["dateModified"])
+		}
+		if meta.Image == "" {
+			meta.Image = jsonLDURL(obj["image"])
+		}
+		if meta.SiteName == "" {
+			meta.SiteName = jsonLDName(obj["publisher"])
+		}
+	}
+}
+
+func applyOpenGraph(meta *Metadata) {
+	og := meta.OpenGraph
+
+	fill := func(field *string, keys ...string) {
+		if *field != "" {
+			return
+		}
+		for _, key := range keys {
+			if value := og[key]; value != "" {
+				*field = firstListValue(value)
+				return
+			}
+		}
+	}
+
+	fill(&meta.Type, "og:type")
+	fill(&meta.Author, "article:author", "twitter:creator")
+	fill(&meta.PublishedTime, "article:published_time")
+	fill(&meta.ModifiedTime, "article:modified_time", "og:updated_time")
+	fill(&meta.Image, "og:image", "og:image:url", "twitter:image", "twitter:image:src")
+	fill(&meta.SiteName, "og:site_name", "twitter:site")
+}
+
+func applyMicrodata(meta *Metadata) {
+	for _, item := range meta.Microdata {
+		prop := func(names ...string) string {
+			for _, name := range names {
+				if values := item.Properties[name]; len(values) > 0 {
+					return values[0]
+				}
+			}
+			return ""
+		}
+
+		if item.Type == "BreadcrumbList" && len(meta.Breadcrumb) == 0 {
+			meta.Breadcrumb = item.Properties["itemListElement"]
+			continue
+		}
+		if !primaryTypes[item.Type] {
+			continue
+		}
+
+		if meta.Type == "" {
+			meta.Type = item.Type
+		}
+		if meta.Author == "" {
+			meta.Author = prop("author")
+		}
+		if meta.PublishedTime == "" {
+			meta.PublishedTime = prop("datePublished")
+		}
+		if meta.ModifiedTime == "" {
+			meta.ModifiedTime = prop("dateModified")
+		}
+		if meta.Image == "" {
+			meta.Image = prop("image")
+		}
+	}
+}
+
+func extractMicrodata(doc *goquery.Document) []MicrodataItem {
+	var items []MicrodataItem
+
+	doc.Find("[itemscope][itemtype]").Each(func(i int, scope *goquery.Selection) {
+		item := MicrodataItem{
+			Source:     "microdata",
+			Type:       schemaTypeName(scope.AttrOr("itemtype", "")),
+			Properties: make(map[string][]string),
+		}
+
+		scope.Find("[itemprop]").Each(func(j int, s *goquery.Selection) {
+			// Properties of nested items belong to those items
+			if owner := s.Parent().Closest("[itemscope]"); owner.Length() > 0 && !owner.IsSelection(scope) {
+				return
+			}
+
+			value := itemValue(s)
+			if value == "" {
+				return
+			}
+			for _, name := range strings.Fields(s.AttrOr("itemprop", "")) {
+				item.Properties[name] = append(item.Properties[name], value)
+			}
+		})
+
+		if len(item.Properties) > 0 {
+			items = append(items, item)
+		}
+	})
+
+	return items
+}
+
+func extractRDFa(doc *goquery.Document) []MicrodataItem {
+	var items []MicrodataItem
+
+	doc.Find("[typeof]").Each(func(i int, scope *goquery.Selection) {
+		item := MicrodataItem{
+			Source:     "rdfa",
+			Type:       schemaTypeName(scope.AttrOr("typeof", "")),
+			Properties: make(map[string][]string),
+		}
+
+		scope.Find("[property]").Each(func(j int, s *goquery.Selection) {
+			if owner := s.Parent().Closest("[typeof]"); owner.Length() > 0 && !owner.IsSelection(scope) {
+				return
+			}
+
+			value := itemValue(s)
+			if value == "" {
+				return
+			}
+			for _, name := range strings.Fields(s.AttrOr("property", "")) {
+				name = schemaTypeName(name)
+				item.Properties[name] = append(item.Properties[name], value)
+			}
+		})
+
+		if len(item.Properties) > 0 {
+			items = append(items, item)
+		}
+	})
+
+	return items
+}
+
+func itemValue(s *goquery.Selection) string {
+	if s.Is("[itemscope]") || s.Is("[typeof]") {
+		// Nested item: its name is the most useful flat value
+		if name := s.Find("[itemprop='name'], [property='name']").First(); name.Length() > 0 {
+			return strings.TrimSpace(name.Text())
+		}
+		return ""
+	}
+
+	for _, attr := range []string{"content", "datetime", "href", "src"} {
+		if value, exists := s.Attr(attr); exists {
+			return strings.TrimSpace(value)
+		}
+	}
+	return strings.Join(strings.Fields(s.Text()), " ")
+}
+
+// schemaTypeName turns "https://schema.org/Article" or "schema:Article" into "Article"
+func schemaTypeName(value string) string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	value = fields[0]
+	if i := strings.LastIndexAny(value, "/:#"); i >= 0 {
+		value = value[i+1:]
+	}
+	return value
+}
+
+func jsonLDTypes(obj map[string]interface{}) []string {
+	switch t := obj["@type"].(type) {
+	case string:
+		return []string{schemaTypeName(t)}
+	case []interface{}:
+		var types []string
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, schemaTypeName(s))
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func jsonLDString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		if len(v) > 0 {
+			return jsonLDString(v[0])
+		}
+	}
+	return ""
+}
+
+func jsonLDName(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		return jsonLDString(v["name"])
+	case []interface{}:
+		var names []string
+		for _, item := range v {
+			if name := jsonLDName(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+func jsonLDURL(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		if url := jsonLDString(v["url"]); url != "" {
+			return url
+		}
+		return jsonLDString(v["contentUrl"])
+	case []interface{}:
+		if len(v) > 0 {
+			return jsonLDURL(v[0])
+		}
+	}
+	return ""
+}
+
+func breadcrumbFromJSONLD(obj map[string]interface{}) []string {
+	elements, ok := obj["itemListElement"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var crumbs []string
+	for _, element := range elements {
+		item, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name := jsonLDString(item["name"])
+		if name == "" {
+			name = jsonLDName(item["item"])
+		}
+		if name != "" {
+			crumbs = append(crumbs, name)
+		}
+	}
+	return crumbs
+}
+
+func firstListValue(value string) string {
+	return strings.TrimSpace(strings.Split(value, ", ")[0])
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/fetcher"
+	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+// Outcome classes recorded in crawl_attempts
+const (
+	OutcomeCrawled             = "crawled"
+	OutcomeErrorPage           = "error_page"
+	OutcomeRobotsDisallowed    = "robots_disallowed"
+	OutcomeFetchError          = "fetch_error"
+	OutcomeHTTPError           = "http_error"
+	OutcomeWrongContentType    = "wrong_content_type"
+	OutcomeOversized           = "oversized"
+	OutcomeNonEnglish          = "non_english"
+	OutcomeParseError          = "parse_error"
+	OutcomeBrowserError        = "browser_error"
+	OutcomeInsufficientContent = "insufficient_content"
+	OutcomeSaveError           = "save_error"
+	OutcomeQuarantined         = "quarantined"
+	OutcomeRejected            = "rejected"
+)
+
+const (
+	FetchModeHTTP    = "http"
+	FetchModeBrowser = "browser"
+)
+
+type crawlAttempt struct {
+	url        string
+	startedAt  time.Time
+	outcome    string
+	statusCode int
+	err        string
+	fetchMode  string
+	body       *countingReader
+	bytes      int64
+}
+
+func newAttempt(url string) *crawlAttempt {
+	return &crawlAttempt{
+		url:       url,
+		startedAt: time.Now(),
+		fetchMode: FetchModeHTTP,
+	}
+}
+
+func (a *crawlAttempt) fail(outcome string, err error) {
+	a.outcome = outcome
+	if err != nil {
+		a.err = err.Error()
+	}
+}
+
+// latency runs until the response body was fully read, or until now when
+// it never was (e.g. the page was skipped after the headers).
+func (a *crawlAttempt) latency() time.Duration {
+	if a.body != nil && !a.body.finishedAt.IsZero() {
+		return a.body.finishedAt.Sub(a.startedAt)
+	}
+	return time.Since(a.startedAt)
+}
+
+func (s *Scheduler) recordAttempt(a *crawlAttempt) {
+	if a.outcome == "" {
+		a.outcome = OutcomeCrawled
+	}
+	bytes := a.bytes
+	if a.body != nil {
+		bytes += a.body.n
+	}
+
+	err := s.writer.Write(&storage.WriteRequest{URL: a.url, Attempt: &storage.CrawlAttempt{
+		URL:         a.url,
+		Host:        parser.ExtractDomain(a.url),
+		AttemptedAt: a.startedAt,
+		Outcome:     a.outcome,
+		StatusCode:  a.statusCode,
+		Error:       a.err,
+		LatencyMs:   a.latency().Milliseconds(),
+		Bytes:       bytes,
+		FetchMode:   a.fetchMode,
+	}})
+	s.metrics.recordAttempt(a, bytes)
+	if err != nil {
+		log.Printf("🔴 Warning: Failed to record crawl attempt for %s: %v", a.url, err)
+	}
+}
+
+func fetchErrorOutcome(err error) string {
+	if errors.Is(err, fetcher.ErrDisallowed) {
+		return OutcomeRobotsDisallowed
+	}
+	return OutcomeFetchError
+}
+
+// countingReader tracks how much of a response body was read and when it
+// was exhausted.
+type countingReader struct {
+	io.ReadCloser
+	n          int64
+	finishedAt time.Time
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	if err == io.EOF && r.finishedAt.IsZero() {
+		r.finishedAt = time.Now()
+	}
+	return n, err
+}
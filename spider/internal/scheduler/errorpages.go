@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/errorpage"
+	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+const maxProbeBytes = 2 * 1024 * 1024
+
+type hostProbe struct {
+	once  sync.Once
+	probe *errorpage.Probe
+}
+
+// classifyErrorPage returns the error class of a page served with 200, or
+// "" for a normal page. Title/content heuristics run first; otherwise the
+// page is compared against what its host serves for a nonexistent path.
+func (s *Scheduler) classifyErrorPage(ctx context.Context, page *parser.Page) string {
+	if class := errorpage.Classify(page.Title, page.Content); class != "" {
+		return class
+	}
+
+	probe := s.probeHost(ctx, page.URL)
+	if probe.Matches(parser.NormalizeURLString(page.URL), page.Content) {
+		return errorpage.ClassSoft404
+	}
+	return ""
+}
+
+// probeHost fetches a random nonexistent path once per host and caches
+// the result for ProbeMaxAge.
+func (s *Scheduler) probeHost(ctx context.Context, pageURL string) *errorpage.Probe {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	s.probesMu.Lock()
+	entry, exists := s.probes[u.Host]
+	if !exists {
+		entry = &hostProbe{}
+		s.probes[u.Host] = entry
+	}
+	s.probesMu.Unlock()
+
+	entry.once.Do(func() {
+		entry.probe = s.fetchProbe(ctx, u.Scheme+"://"+u.Host)
+		if entry.probe == nil {
+			return
+		}
+
+		if err := s.writer.Write(&storage.WriteRequest{URL: u.Host, Probe: toDBProbe(entry.probe)}); err != nil {
+			log.Printf("🔴 Warning: Failed to save probe for %s: %v", u.Host, err)
+		}
+		if entry.probe.ServesSoft404() {
+			log.Printf("🔍 %s serves soft 404s (probe title %q)", u.Host, entry.probe.Title)
+		}
+	})
+	return entry.probe
+}
+
+// fetchProbe returns nil when the probe could not be fetched (robots.txt,
+// network errors), so the host is only judged by the heuristics.
+func (s *Scheduler) fetchProbe(ctx context.Context, origin string) *errorpage.Probe {
+	probeURL := origin + errorpage.ProbePath()
+
+	resp, err := s.fetcher.Fetch(ctx, probeURL)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	probe := &errorpage.Probe{
+		Host:       parser.ExtractDomain(origin),
+		StatusCode: resp.StatusCode,
+		ProbedAt:   time.Now(),
+	}
+	if resp.StatusCode != 200 || !isHTMLContentType(resp.Header.Get("Content-Type")) {
+		return probe
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBytes))
+	if err != nil {
+		return nil
+	}
+
+	page, _, err := s.parser.ParseHTML(string(body), probeURL)
+	if err != nil {
+		return nil
+	}
+
+	if finalURL := resp.Request.URL.String(); finalURL != probeURL {
+		probe.FinalURL = parser.NormalizeURLString(finalURL)
+	}
+	probe.Title = page.Title
+	probe.Fingerprint = errorpage.Fingerprint(page.Content)
+	probe.Words = errorpage.WordCount(page.Content)
+	return probe
+}
+
+func (s *Scheduler) loadHostProbes() {
+	probes, err := s.db.LoadHostProbes(time.Now().Add(-errorpage.ProbeMaxAge))
+	if err != nil {
+		log.Printf("Warning: Failed to load host probes: %v", err)
+		return
+	}
+
+	for _, p := range probes {
+		entry := &hostProbe{probe: &errorpage.Probe{
+			Host:        p.Host,
+			StatusCode:  p.StatusCode,
+			FinalURL:    p.FinalURL,
+			Title:       p.Title,
+			Fingerprint: p.Fingerprint,
+			Words:       p.Words,
+			ProbedAt:    p.ProbedAt,
+		}}
+		entry.once.Do(func() {})
+		s.probes[p.Host] = entry
+	}
+}
+
+func toDBProbe(p *errorpage.Probe) *storage.HostProbe {
+	return &storage.HostProbe{
+		Host:        p.Host,
+		StatusCode:  p.StatusCode,
+		FinalURL:    p.FinalURL,
+		Title:       p.Title,
+		Fingerprint: p.Fingerprint,
+		Words:       p.Words,
+		ProbedAt:    p.ProbedAt,
+	}
+}
@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/feed"
+	"github.com/dangpham/deisearch/spider/internal/frontier"
+	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/shard"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+const (
+	feedsPerPoll    = 100
+	maxFeedBytes    = 5 * 1024 * 1024
+	maxFeedBackoffs = 6 // errors double the poll interval up to 2^6 times
+)
+
+// pollFeeds periodically fetches known feeds and queues their new items
+// at the front of the frontier. Runs until ctx is cancelled or done closes.
+func (s *Scheduler) pollFeeds(ctx context.Context, done <-chan struct{}) {
+	tick := s.config.FeedPollInterval
+	if tick > time.Minute {
+		tick = time.Minute
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		s.pollDueFeeds(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) pollDueFeeds(ctx context.Context) {
+	now := time.Now()
+	feeds, err := s.db.GetFeedsDue(now.Add(-s.config.FeedPollInterval), feedsPerPoll)
+	if err != nil {
+		log.Printf("🔴 Warning: Failed to load due feeds: %v", err)
+		return
+	}
+
+	for i := range feeds {
+		if ctx.Err() != nil {
+			return
+		}
+
+		f := &feeds[i]
+		if !f.LastPolledAt.IsZero() && now.Before(f.LastPolledAt.Add(feedBackoff(s.config.FeedPollInterval, f.ConsecutiveErrors))) {
+			continue
+		}
+
+		added, err := s.pollFeed(ctx, f)
+		f.LastPolledAt = time.Now()
+		if err != nil {
+			f.ConsecutiveErrors++
+			log.Printf("⚠️  Feed poll failed for %s: %v", f.URL, err)
+		} else {
+			f.ConsecutiveErrors = 0
+			if added > 0 {
+				log.Printf("📰 Feed %s: queued %d new items", f.URL, added)
+			}
+		}
+
+		if err := s.db.UpdateFeedPoll(f); err != nil {
+			log.Printf("🔴 Warning: Failed to update feed %s: %v", f.URL, err)
+		}
+	}
+}
+
+func (s *Scheduler) pollFeed(ctx context.Context, f *storage.Feed) (int, error) {
+	resp, err := s.fetcher.FetchFeed(ctx, f.URL, f.ETag, f.LastModified)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 304 {
+		return 0, nil
+	}
+	if resp.StatusCode != 200 {
+		return 0, fmt.Errorf("non-200 status: %d", resp.StatusCode)
+	}
+
+	items, err := feed.Parse(io.LimitReader(resp.Body, maxFeedBytes))
+	if err != nil {
+		return 0, err
+	}
+
+	f.ETag = resp.Header.Get("ETag")
+	f.LastModified = resp.Header.Get("Last-Modified")
+
+	fresh := make([]frontier.FreshURL, 0, len(items))
+	for _, item := range items {
+		itemURL := parser.ResolveLink(f.URL, item.URL)
+		if itemURL == "" || !s.config.Scope.Allows(itemURL) {
+			continue
+		}
+
+		if item.PublishedAt.After(f.LastItemAt) {
+			f.LastItemAt = item.PublishedAt
+		}
+
+		if host := parser.ExtractDomain(itemURL); !s.config.Shard.Owns(host) {
+			s.forwarder.Forward(host, shard.Link{URL: itemURL, FoundOn: f.URL, PublishedAt: item.PublishedAt, Fresh: true})
+			continue
+		}
+		fresh = append(fresh, frontier.FreshURL{
+			URL:         itemURL,
+			PublishedAt: item.PublishedAt,
+		})
+	}
+
+	return s.frontier.AddFreshURLs(fresh), nil
+}
+
+func feedBackoff(interval time.Duration, errors int) time.Duration {
+	if errors > maxFeedBackoffs {
+		errors = maxFeedBackoffs
+	}
+	return interval * time.Duration(1<<errors)
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/dangpham/deisearch/spider/internal/focus"
+	"github.com/dangpham/deisearch/spider/internal/frontier"
+	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+// Annotation under which a focused crawl stores each page's relevance
+const (
+	focusProcessor    = "focus"
+	focusRelevanceKey = "relevance"
+)
+
+// newClassifier builds the topic profile, including the text of seed pages
+// crawled by earlier runs. Seed pages crawled later are added as they come.
+func newClassifier(db *storage.Database, config focus.Config) *focus.Classifier {
+	classifier := focus.NewClassifier(config)
+
+	loaded := 0
+	for _, url := range config.SeedPages {
+		page, err := db.GetPage(parser.NormalizeURLString(url))
+		if err != nil {
+			log.Printf("Warning: Failed to load focus seed page %s: %v", url, err)
+			continue
+		}
+		if page != nil && page.ErrorClass == "" {
+			classifier.AddSeedPage(page.URL, page.Title, page.Content)
+			loaded++
+		}
+	}
+
+	log.Printf("🎯 Focused crawl: %d keywords, %d of %d seed pages already crawled", len(config.Keywords), loaded, len(config.SeedPages))
+	return classifier
+}
+
+// scorePage rates a page against the topic. Returns -1 when the crawl
+// isn't focused.
+func (s *Scheduler) scorePage(normalizedURL string, page *parser.Page) float64 {
+	if s.focus == nil {
+		return -1
+	}
+
+	if s.focus.IsSeedPage(normalizedURL) {
+		s.focus.AddSeedPage(normalizedURL, page.Title, page.Content)
+	}
+	relevance := s.focus.PageRelevance(page.Title+" "+page.Description, page.Content)
+	s.metrics.relevance.With(page.ContentType).Observe(relevance)
+	return relevance
+}
+
+// follows reports whether a page is relevant enough for its links to be
+// crawled. Seed pages always are.
+func (s *Scheduler) follows(normalizedURL string, relevance float64) bool {
+	return s.focus == nil || relevance >= s.config.Focus.MinRelevance || s.focus.IsSeedPage(normalizedURL)
+}
+
+// linkPriority is 0 unless the crawl is focused.
+func (s *Scheduler) linkPriority(relevance float64, link parser.Link) float64 {
+	if s.focus == nil {
+		return 0
+	}
+	return s.focus.LinkPriority(relevance, link.AnchorText, link.Title, link.URL)
+}
+
+func (s *Scheduler) queueLinks(relevance float64, links []parser.Link) {
+	if s.focus == nil {
+		s.frontier.AddURLs(links)
+		return
+	}
+
+	scored := make([]frontier.ScoredURL, len(links))
+	for i, link := range links {
+		scored[i] = frontier.ScoredURL{URL: link.URL, Priority: s.linkPriority(relevance, link)}
+	}
+	s.frontier.AddScoredURLs(scored)
+}
+
+func relevanceAnnotation(relevance float64) storage.PageAnnotation {
+	return storage.PageAnnotation{
+		Processor: focusProcessor,
+		Key:       focusRelevanceKey,
+		Value:     strconv.FormatFloat(relevance, 'f', 4, 64),
+	}
+}
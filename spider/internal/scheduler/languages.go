@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"log"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+// skipNonEnglish records a non-English page's language variants and queues
+// its English alternate, if it declares one, in place of the page.
+func (s *Scheduler) skipNonEnglish(page *parser.Page) {
+	url := parser.NormalizeURLString(page.URL)
+	req := &storage.WriteRequest{URL: url, Variants: languageVariants(page)}
+
+	var links []parser.Link
+	english := page.EnglishAlternate()
+	if english != "" {
+		// The page's text can't be scored, so only the URL ranks the alternate
+		links = s.forwardForeign(url, 0, s.inScope([]parser.Link{{URL: english, Rel: []string{"alternate"}}}))
+		links, req.Quarantined = s.filterTraps(url, links)
+	}
+
+	if len(req.Variants) > 0 || len(req.Quarantined) > 0 {
+		if err := s.writer.Write(req); err != nil {
+			log.Printf("🔴 Warning: Failed to save language variants of %s: %v", url, err)
+		}
+	}
+
+	if english == "" {
+		log.Printf("Skipping non-English (%s) page: %s", page.Language, url)
+		return
+	}
+	s.queueLinks(0, links)
+	log.Printf("🌐 Skipping %s page %s, English alternate: %s", page.Language, url, english)
+}
+
+// languageVariants lists a page and its hreflang alternates as one
+// cluster, one entry per URL. Returns nil for pages without alternates.
+func languageVariants(page *parser.Page) []storage.LanguageVariant {
+	if len(page.Alternates) == 0 {
+		return nil
+	}
+
+	// The page's own hreflang entry is more specific than its detected
+	// language ("en-gb" rather than "en")
+	self := parser.NormalizeURLString(page.URL)
+	langs := map[string]string{self: ""}
+	order := []string{self}
+
+	for _, alt := range page.Alternates {
+		current, exists := langs[alt.URL]
+		if !exists {
+			order = append(order, alt.URL)
+		}
+		// x-default only names a URL no language claimed
+		if current == "" || (current == "x-default" && alt.Lang != "x-default") {
+			langs[alt.URL] = alt.Lang
+		}
+	}
+	if langs[self] == "" {
+		langs[self] = page.Language
+	}
+
+	variants := make([]storage.LanguageVariant, 0, len(order))
+	for _, url := range order {
+		lang := langs[url]
+		if lang == "" {
+			lang = "und"
+		}
+		variants = append(variants, storage.LanguageVariant{URL: url, Lang: lang})
+	}
+	return variants
+}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/metrics"
+	"github.com/dangpham/deisearch/spider/internal/shard"
+)
+
+// Hosts listed in the per-host queue metric and the status page; the
+// frontier can hold hundreds of thousands of hosts.
+const statusTopHosts = 25
+
+// Processors run in-process on parsed pages, so they are timed at a much
+// finer scale than fetches.
+var processorBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+var relevanceBuckets = []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.8, 1}
+
+type crawlMetrics struct {
+	registry      *metrics.Registry
+	startedAt     time.Time
+	pages         *metrics.Counter
+	pageRate      *metrics.Meter
+	outcomes      *metrics.CounterVec
+	fetches       *metrics.CounterVec
+	bytes         *metrics.Counter
+	latency       *metrics.HistogramVec
+	received      *metrics.Counter
+	activeWorkers atomic.Int64
+
+	processorTime       *metrics.HistogramVec
+	processorRejections *metrics.CounterVec
+	processorErrors     *metrics.CounterVec
+
+	relevance *metrics.HistogramVec
+}
+
+func newCrawlMetrics(s *Scheduler) *crawlMetrics {
+	r := metrics.NewRegistry()
+	m := &crawlMetrics{
+		registry:  r,
+		startedAt: time.Now(),
+		pageRate:  metrics.NewMeter(),
+		pages:     r.NewCounter("spider_pages_crawled_total", "Pages saved that count toward the page limit."),
+		outcomes:  r.NewCounterVec("spider_crawl_attempts_total", "URLs taken from the frontier, by outcome.", "outcome"),
+		fetches:   r.NewCounterVec("spider_fetches_total", "Pages fetched, by the mode that produced the final result.", "mode"),
+		bytes:     r.NewCounter("spider_downloaded_bytes_total", "Response bytes read."),
+		latency:   r.NewHistogramVec("spider_fetch_latency_seconds", "Time from request until the body was read.", "mode", metrics.DefaultLatencyBuckets),
+		received:  r.NewCounter("spider_shard_received_links_total", "Links forwarded to this shard by other shards."),
+
+		processorTime:       r.NewHistogramVec("spider_processor_duration_seconds", "Time page processors spent per page.", "processor", processorBuckets),
+		processorRejections: r.NewCounterVec("spider_processor_rejections_total", "Pages rejected by a page processor.", "processor"),
+		processorErrors:     r.NewCounterVec("spider_processor_errors_total", "Page processor failures.", "processor"),
+
+		relevance: r.NewHistogramVec("spider_page_relevance", "Topic relevance of saved pages in a focused crawl.", "content_type", relevanceBuckets),
+	}
+
+	r.NewGaugeFunc("spider_pages_per_second", "Pages saved per second over the last minute.", m.pageRate.Rate)
+	r.NewGaugeFunc("spider_browser_fallback_ratio", "Share of fetched pages that needed the browser.", m.browserFallbackRatio)
+	r.NewGaugeFunc("spider_active_workers", "Workers currently crawling a URL.", func() float64 {
+		return float64(m.activeWorkers.Load())
+	})
+	r.NewGaugeFunc("spider_workers", "Configured workers.", func() float64 {
+		return float64(s.config.Workers)
+	})
+	r.NewGaugeFunc("spider_frontier_urls", "URLs queued in the frontier.", func() float64 {
+		return float64(s.frontier.Size())
+	})
+	r.NewGaugeVecFunc("spider_frontier_host_urls", "URLs queued for the hosts with the largest queues.", "host", func() map[string]float64 {
+		sizes := make(map[string]float64)
+		for _, h := range s.frontier.LargestHosts(statusTopHosts) {
+			sizes[h.Host] = float64(h.URLs)
+		}
+		return sizes
+	})
+	r.NewGaugeFunc("spider_write_queue", "Requests waiting for the database writer.", func() float64 {
+		return float64(s.writer.Stats().Queued)
+	})
+	r.NewCounterFunc("spider_write_stalls_total", "Times a worker blocked on a full write queue.", func() float64 {
+		return float64(s.writer.Stats().Stalls)
+	})
+	r.NewCounterFunc("spider_write_failures_total", "Write requests the database writer failed to store.", func() float64 {
+		return float64(s.writer.Stats().Failed)
+	})
+	r.NewGaugeFunc("spider_uptime_seconds", "Seconds since the scheduler was created.", func() float64 {
+		return time.Since(m.startedAt).Seconds()
+	})
+
+	if s.forwarder != nil {
+		r.NewCounterFunc("spider_shard_forwarded_links_total", "Links delivered to the shards owning their hosts.", func() float64 {
+			return float64(s.forwarder.Stats().Forwarded)
+		})
+		r.NewCounterFunc("spider_shard_dropped_links_total", "Links dropped because their shard stayed unreachable.", func() float64 {
+			return float64(s.forwarder.Stats().Dropped)
+		})
+		r.NewGaugeFunc("spider_shard_pending_links", "Links waiting to be forwarded.", func() float64 {
+			return float64(s.forwarder.Stats().Pending)
+		})
+	}
+
+	return m
+}
+
+func (m *crawlMetrics) recordPage() {
+	m.pages.Inc()
+	m.pageRate.Mark(1)
+}
+
+func (m *crawlMetrics) recordAttempt(a *crawlAttempt, bytes int64) {
+	m.outcomes.With(a.outcome).Inc()
+	m.bytes.Add(bytes)
+
+	// Attempts that never reached the network have no fetch mode
+	if a.fetchMode != "" {
+		m.fetches.With(a.fetchMode).Inc()
+		m.latency.With(a.fetchMode).Observe(a.latency().Seconds())
+	}
+}
+
+func (m *crawlMetrics) browserFallbackRatio() float64 {
+	fetches := m.fetches.Values()
+	total := fetches[FetchModeHTTP] + fetches[FetchModeBrowser]
+	if total == 0 {
+		return 0
+	}
+	return float64(fetches[FetchModeBrowser]) / float64(total)
+}
+
+// Handler serves GET /status (GetStats as JSON) and GET /metrics
+// (Prometheus text format) while the crawl runs, and accepts links from
+// other shards when sharded.
+func (s *Scheduler) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", s.metrics.registry.Handler())
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(s.GetStats())
+	})
+	if s.forwarder != nil {
+		mux.Handle("POST "+shard.LinksPath, shard.Handler(s.config.Shard.Token, s.receiveLinks))
+	}
+	return mux
+}
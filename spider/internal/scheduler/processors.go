@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+// PageProcessor is an extraction step run on every crawled page after
+// parsing and before it is saved. Processors run in the order they were
+// added; error pages skip them.
+type PageProcessor interface {
+	// Name identifies the processor in page_annotations and crawl stats
+	Name() string
+	// Process may modify the page and its links, set fields or reject the
+	// page. An error is logged and the page is saved anyway.
+	Process(page *ProcessedPage) error
+}
+
+// ProcessedPage is a parsed page on its way to being saved.
+type ProcessedPage struct {
+	*parser.Page
+	Links []parser.Link
+
+	processor   string
+	annotations []storage.PageAnnotation
+	rejected    string
+}
+
+// Set stores a key/value field for the page, under the current
+// processor's name.
+func (p *ProcessedPage) Set(key, value string) {
+	p.annotations = append(p.annotations, storage.PageAnnotation{
+		Processor: p.processor,
+		Key:       key,
+		Value:     value,
+	})
+}
+
+// Reject drops the page: it isn't saved and later processors don't run.
+func (p *ProcessedPage) Reject(reason string) {
+	if reason == "" {
+		reason = "rejected"
+	}
+	p.rejected = reason
+}
+
+// AddProcessor registers a processor. Call it before Start.
+func (s *Scheduler) AddProcessor(processor PageProcessor) {
+	s.processors = append(s.processors, processor)
+}
+
+// processPage runs the processors over a page. Returns an error naming
+// the processor if one rejected it.
+func (s *Scheduler) processPage(page *parser.Page, links []parser.Link) (*ProcessedPage, error) {
+	processed := &ProcessedPage{Page: page, Links: links}
+
+	for _, processor := range s.processors {
+		name := processor.Name()
+		processed.processor = name
+
+		start := time.Now()
+		err := processor.Process(processed)
+		s.metrics.processorTime.With(name).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			s.metrics.processorErrors.With(name).Inc()
+			log.Printf("🔴 Warning: Processor %s failed on %s: %v", name, page.URL, err)
+		}
+		if processed.rejected != "" {
+			s.metrics.processorRejections.With(name).Inc()
+			log.Printf("🚫 %s rejected %s: %s", name, page.URL, processed.rejected)
+			return processed, fmt.Errorf("%s: %s", name, processed.rejected)
+		}
+	}
+	return processed, nil
+}
+
+// processorStats reports each processor's page count, average time,
+// rejections and errors, in registration order.
+func (s *Scheduler) processorStats() []map[string]interface{} {
+	stats := make([]map[string]interface{}, 0, len(s.processors))
+	rejections := s.metrics.processorRejections.Values()
+	errors := s.metrics.processorErrors.Values()
+
+	for _, processor := range s.processors {
+		name := processor.Name()
+		count, sum := s.metrics.processorTime.With(name).Snapshot()
+		avgMs := 0.0
+		if count > 0 {
+			avgMs = sum / float64(count) * 1000
+		}
+		stats = append(stats, map[string]interface{}{
+			"name":     name,
+			"pages":    count,
+			"avg_ms":   avgMs,
+			"total_ms": sum * 1000,
+			"rejected": rejections[name],
+			"errors":   errors[name],
+		})
+	}
+	return stats
+}
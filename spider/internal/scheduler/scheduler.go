@@ -2,16 +2,28 @@ package scheduler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/dangpham/deisearch/spider/internal/boilerplate"
 	"github.com/dangpham/deisearch/spider/internal/fetcher"
+	"github.com/dangpham/deisearch/spider/internal/focus"
 	"github.com/dangpham/deisearch/spider/internal/frontier"
 	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/scope"
+	"github.com/dangpham/deisearch/spider/internal/seen"
+	"github.com/dangpham/deisearch/spider/internal/shard"
 	"github.com/dangpham/deisearch/spider/internal/storage"
+	"github.com/dangpham/deisearch/spider/internal/traps"
 )
 
 type Config struct {
@@ -20,15 +32,62 @@ type Config struct {
 	MaxPages     int
 	MaxDepth     int
 	UserAgent    string
+
+	// Boilerplate detection: a block is a site template once it appears on
+	// at least BoilerplateMinRatio of a host's pages (after MinPages pages)
+	BoilerplateMinPages int
+	BoilerplateMinRatio float64
+
+	MaxPDFBytes int64
+	MaxPDFPages int
+
+	// How often discovered RSS/Atom feeds are re-polled; 0 disables polling.
+	// With polling on, workers keep waiting for feed items when the
+	// frontier runs dry instead of exiting.
+	FeedPollInterval time.Duration
+
+	// Crawler-trap heuristics; zero values use the detector defaults
+	Traps traps.Config
+
+	// Sizing of the seen-URL Bloom filter; zero values use the defaults
+	SeenSet seen.Config
+
+	// Batching of the single database writer; zero values use the defaults
+	Writer storage.WriterConfig
+
+	// Which URLs may be crawled; nil allows everything
+	Scope *scope.Rules
+
+	// Which hosts this process crawls when the crawl is split across
+	// processes; nil crawls every host
+	Shard *shard.Config
+
+	// Topic profile for a focused crawl, which follows the links of
+	// relevant pages first; nil or an empty profile crawls breadth-first
+	Focus *focus.Config
 }
 
+// ErrForeignHost is returned for seeds whose host another shard crawls.
+var ErrForeignHost = errors.New("host belongs to another shard")
+
 type Scheduler struct {
 	config         *Config
 	frontier       *frontier.Frontier
 	fetcher        *fetcher.Fetcher
 	browserFetcher *fetcher.BrowserFetcher
 	parser         *parser.Parser
+	boilerplate    *boilerplate.Detector
+	traps          *traps.Detector
 	db             *storage.Database
+	writer         *storage.Writer
+	seenStore      *storage.SeenStore
+	forwarder      *shard.Forwarder  // nil unless sharded
+	focus          *focus.Classifier // nil unless focused
+	processors     []PageProcessor
+	metrics        *crawlMetrics
+
+	probes   map[string]*hostProbe
+	probesMu sync.Mutex
 
 	pageCount           int
 	browserFetchedCount int
@@ -45,30 +104,114 @@ func New(db *storage.Database, config *Config) *Scheduler {
 	if config.Workers == 0 {
 		config.Workers = 20
 	}
+	if config.MaxPDFBytes == 0 {
+		config.MaxPDFBytes = 20 * 1024 * 1024
+	}
+	if config.MaxPDFPages == 0 {
+		config.MaxPDFPages = 200
+	}
 
-	crawledURLs, err := db.LoadAllCrawledURLs()
+	writer := storage.NewWriter(db, config.Writer)
+	seenStore, err := db.NewSeenStore(writer)
+	if err != nil {
+		log.Printf("Warning: Failed to open seen-URL store: %v", err)
+	}
+	seenSet, err := newSeenSet(db, seenStore, config.SeenSet)
 	if err != nil {
 		log.Printf("Warning: Failed to load crawled URLs: %v", err)
-		crawledURLs = []string{}
 	}
 
-	return &Scheduler{
+	detector := boilerplate.NewDetector(config.BoilerplateMinPages, config.BoilerplateMinRatio)
+	templates, err := db.LoadSiteTemplates()
+	if err != nil {
+		log.Printf("Warning: Failed to load site templates: %v", err)
+	}
+	detector.Load(toDetectorTemplates(templates))
+
+	trapDetector := traps.NewDetector(config.Traps)
+	quarantined, err := db.LoadQuarantinedHosts()
+	if err != nil {
+		log.Printf("Warning: Failed to load quarantined hosts: %v", err)
+	}
+	for _, h := range quarantined {
+		trapDetector.QuarantineHost(h.Host, h.Reason)
+	}
+
+	s := &Scheduler{
 		config:         config,
-		frontier:       frontier.New(crawledURLs, config.RateLimitSec),
+		frontier:       frontier.NewWithSeenSet(seenSet, config.RateLimitSec),
 		fetcher:        fetcher.New(config.UserAgent),
 		browserFetcher: fetcher.NewBrowserFetcher(config.UserAgent),
 		parser:         parser.New(),
+		boilerplate:    detector,
+		traps:          trapDetector,
 		db:             db,
+		writer:         writer,
+		seenStore:      seenStore,
+		probes:         make(map[string]*hostProbe),
+	}
+	if config.Shard.Enabled() {
+		s.forwarder = shard.NewForwarder(config.Shard)
 	}
+	if config.Focus != nil && config.Focus.Enabled() {
+		s.focus = newClassifier(db, *config.Focus)
+	}
+	s.metrics = newCrawlMetrics(s)
+	s.loadHostProbes()
+	return s
+}
+
+// newSeenSet clears URLs queued by the previous run and streams crawled
+// URLs into the Bloom filter; the pages table answers for them exactly.
+// Without a seen store, lookups and writes go to the database directly.
+func newSeenSet(db *storage.Database, store *storage.SeenStore, config seen.Config) (*seen.DiskSet, error) {
+	var set *seen.DiskSet
+	if store != nil {
+		set = seen.NewDiskSet(store, config)
+	} else {
+		set = seen.NewDiskSet(db, config)
+	}
+
+	if err := db.ResetSeenURLs(); err != nil {
+		return set, fmt.Errorf("failed to reset seen URLs: %w", err)
+	}
+
+	start := time.Now()
+	count := 0
+	err := db.ForEachCrawledURL(func(url string) {
+		set.Preload(url)
+		count++
+	})
+	if err != nil {
+		return set, err
+	}
+
+	log.Printf("Loaded %d crawled URLs into a %dMB seen filter in %v", count, set.SizeBytes()/(1024*1024), time.Since(start).Round(time.Millisecond))
+	return set, nil
 }
 
 func (s *Scheduler) AddSeed(url string) error {
+	if !s.config.Scope.Allows(url) {
+		return fmt.Errorf("seed is out of scope")
+	}
+	if !s.config.Shard.Owns(parser.ExtractDomain(url)) {
+		return ErrForeignHost
+	}
 	s.frontier.AddURL(url)
 	return nil
 }
 
 func (s *Scheduler) Start(ctx context.Context) error {
 	log.Printf("Starting crawler with %d workers", s.config.Workers)
+	if s.config.Shard.Enabled() {
+		log.Printf("Crawling as shard %d of %d", s.config.Shard.ID, s.config.Shard.Count())
+	}
+
+	done := make(chan struct{})
+	if s.config.FeedPollInterval > 0 {
+		log.Printf("Polling feeds every %v", s.config.FeedPollInterval)
+		go s.pollFeeds(ctx, done)
+	}
 
 	var wg sync.WaitGroup
 	for i := 0; i < s.config.Workers; i++ {
@@ -80,6 +223,20 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	}
 
 	wg.Wait()
+	close(done)
+
+	if s.forwarder != nil {
+		s.forwarder.Close()
+	}
+
+	s.flushAllSiteTemplates()
+
+	log.Printf("Flushing %d queued writes...", s.writer.Stats().Queued)
+	s.writer.Close()
+	if s.seenStore != nil {
+		s.seenStore.Close()
+	}
+	s.logTrapReport()
 
 	s.mu.Lock()
 	browserCount := s.browserFetchedCount
@@ -111,7 +268,7 @@ func (s *Scheduler) worker(ctx context.Context, workerID int) {
 				continue
 			}
 
-			if s.frontier.IsEmpty() {
+			if s.frontier.IsEmpty() && !s.waitsForURLs() {
 				log.Printf("Worker %d: Frontier empty, exiting", workerID)
 				return
 			}
@@ -120,9 +277,20 @@ func (s *Scheduler) worker(ctx context.Context, workerID int) {
 			continue
 		}
 
+		// Hosts quarantined after this URL was queued (e.g. by a feed) are skipped
+		if s.traps.IsHostQuarantined(parser.ExtractDomain(url)) {
+			attempt := newAttempt(url)
+			attempt.fetchMode = ""
+			attempt.fail(OutcomeQuarantined, nil)
+			s.recordAttempt(attempt)
+			continue
+		}
+
 		log.Printf("Worker %d: Crawling %s", workerID, url)
 
+		s.metrics.activeWorkers.Add(1)
 		crawled, err := s.crawlURL(ctx, url)
+		s.metrics.activeWorkers.Add(-1)
 		if err != nil {
 			log.Printf("Worker %d: Error crawling %s: %v", workerID, url, err)
 		}
@@ -134,70 +302,179 @@ func (s *Scheduler) worker(ctx context.Context, workerID int) {
 }
 
 func (s *Scheduler) crawlURL(ctx context.Context, url string) (bool, error) {
+	attempt := newAttempt(url)
+	defer s.recordAttempt(attempt)
+
 	// Phase 1: Try with fast HTTP fetcher
 	resp, err := s.fetcher.Fetch(ctx, url)
 	if err != nil {
+		attempt.fail(fetchErrorOutcome(err), err)
 		return false, fmt.Errorf("fetch failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	attempt.statusCode = resp.StatusCode
+	attempt.body = &countingReader{ReadCloser: resp.Body}
+	resp.Body = attempt.body
+
 	if resp.StatusCode != 200 {
-		return false, fmt.Errorf("non-200 status: %d", resp.StatusCode)
+		err := fmt.Errorf("non-200 status: %d", resp.StatusCode)
+		attempt.fail(OutcomeHTTPError, err)
+		return false, err
 	}
 
-	// Security: Validate Content-Type to prevent processing non-HTML files
 	contentType := resp.Header.Get("Content-Type")
+	if isPDFContentType(contentType) {
+		return s.crawlPDF(resp, url, attempt)
+	}
+
+	// Security: Validate Content-Type to prevent processing non-HTML files
 	if contentType != "" && !isHTMLContentType(contentType) {
 		log.Printf("🔒 Skipping non-HTML content type: %s for %s", contentType, url)
+		attempt.fail(OutcomeWrongContentType, fmt.Errorf("content type %s", contentType))
 		return false, nil
 	}
 
 	// Security: Check content length to prevent huge downloads
 	if resp.ContentLength > 10*1024*1024 { // 10MB limit
 		log.Printf("🔒 Skipping oversized content (%d bytes) for %s", resp.ContentLength, url)
+		attempt.fail(OutcomeOversized, fmt.Errorf("content length %d", resp.ContentLength))
 		return false, nil
 	}
 
 	page, links, err := s.parser.Parse(resp, url)
 	if err != nil {
+		attempt.fail(OutcomeParseError, err)
 		return false, fmt.Errorf("🔴 parse failed: %w", err)
 	}
 
-	if page == nil {
-		log.Printf("Skipping non-English page: %s", url)
+	if !page.IsEnglish() {
+		s.skipNonEnglish(page)
+		attempt.fail(OutcomeNonEnglish, nil)
 		return false, nil
 	}
 
 	// Phase 2: If content is insufficient, retry with browser
 	if !page.HasSufficientContent() {
 		log.Printf("⚠️  Insufficient content from HTTP fetch, retrying with browser: %s", url)
+		attempt.fetchMode = FetchModeBrowser
 
 		htmlContent, err := s.browserFetcher.FetchHTML(ctx, url)
 		if err != nil {
 			log.Printf("⚠️  Browser fetch failed, skipping page: %v", err)
+			attempt.fail(OutcomeBrowserError, err)
 			return false, nil
 		}
+		attempt.bytes += int64(len(htmlContent))
 
 		// Parse the browser-fetched HTML
 		browserPage, browserLinks, err := s.parser.ParseHTML(htmlContent, url)
 		if err != nil {
 			log.Printf("⚠️  Browser parse failed, skipping page: %v", err)
+			attempt.fail(OutcomeParseError, err)
 			return false, nil
 		}
 
 		if browserPage.HasSufficientContent() {
 			log.Printf("✅ Browser fetch successful for: %s", url)
+			// The browser gives no response headers to date the page by
+			if browserPage.Modified.IsZero() {
+				browserPage.Modified = page.Modified
+			}
 			page = browserPage
 			links = browserLinks
 			s.incrementBrowserFetchedCount()
 		} else {
 			// Both HTTP and browser fetch failed to get sufficient content
 			log.Printf("❌ Skipping page with insufficient content (even after browser fetch): %s", url)
+			attempt.fail(OutcomeInsufficientContent, nil)
 			return false, nil
 		}
 	}
 
+	return s.finishAttempt(attempt, page, links, s.classifyErrorPage(ctx, page))
+}
+
+func (s *Scheduler) crawlPDF(resp *http.Response, url string, attempt *crawlAttempt) (bool, error) {
+	if resp.ContentLength > s.config.MaxPDFBytes {
+		log.Printf("🔒 Skipping oversized PDF (%d bytes) for %s", resp.ContentLength, url)
+		attempt.fail(OutcomeOversized, fmt.Errorf("content length %d", resp.ContentLength))
+		return false, nil
+	}
+
+	// Content-Length can be missing or wrong, so enforce the limit while reading
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.config.MaxPDFBytes+1))
+	if err != nil {
+		attempt.fail(OutcomeFetchError, err)
+		return false, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if int64(len(data)) > s.config.MaxPDFBytes {
+		log.Printf("🔒 Skipping oversized PDF (over %d bytes) for %s", s.config.MaxPDFBytes, url)
+		attempt.fail(OutcomeOversized, fmt.Errorf("over %d bytes", s.config.MaxPDFBytes))
+		return false, nil
+	}
+
+	page, err := s.parser.ParsePDF(data, url, s.config.MaxPDFPages)
+	if err != nil {
+		log.Printf("⚠️  PDF parse failed, skipping: %s: %v", url, err)
+		attempt.fail(OutcomeParseError, err)
+		return false, nil
+	}
+	page.AddHeaderDates(resp.Header)
+
+	if !page.HasSufficientContent() {
+		// Usually a scanned document without a text layer
+		log.Printf("❌ Skipping PDF with insufficient text: %s", url)
+		attempt.fail(OutcomeInsufficientContent, nil)
+		return false, nil
+	}
+
+	log.Printf("📄 Extracted PDF (%d chars): %s", len(page.Content), url)
+	return s.finishAttempt(attempt, page, nil, "")
+}
+
+func (s *Scheduler) finishAttempt(attempt *crawlAttempt, page *parser.Page, links []parser.Link, errorClass string) (bool, error) {
+	var annotations []storage.PageAnnotation
+	if errorClass == "" && len(s.processors) > 0 {
+		processed, rejection := s.processPage(page, links)
+		if rejection != nil {
+			attempt.fail(OutcomeRejected, rejection)
+			return false, nil
+		}
+		links = processed.Links
+		annotations = processed.annotations
+		if annotations == nil {
+			// Still replaces fields stored by an earlier crawl
+			annotations = []storage.PageAnnotation{}
+		}
+	}
+
+	crawled, err := s.savePage(page, links, errorClass, annotations)
+	switch {
+	case err != nil:
+		attempt.fail(OutcomeSaveError, err)
+	case errorClass != "":
+		attempt.fail(OutcomeErrorPage, errors.New(errorClass))
+	}
+	return crawled, err
+}
+
+// savePage stores a parsed page. Pages with an error class are stored
+// without their links, feeds and structured data.
+func (s *Scheduler) savePage(page *parser.Page, links []parser.Link, errorClass string, annotations []storage.PageAnnotation) (bool, error) {
 	normalizedURL := parser.NormalizeURLString(page.URL)
+	host := parser.ExtractDomain(normalizedURL)
+
+	// PDFs have no site chrome and would only dilute the per-host block counts
+	if page.ContentType == parser.ContentTypeHTML && errorClass == "" {
+		if s.boilerplate.Observe(host, page.Blocks) {
+			s.flushSiteTemplates(host)
+		}
+		page.Content = s.boilerplate.Strip(host, page.Content)
+	}
+
+	contentHash := hashContent(page.Content)
+
 	dbPage := &storage.Page{
 		URL:         normalizedURL,
 		Title:       page.Title,
@@ -205,29 +482,253 @@ func (s *Scheduler) crawlURL(ctx context.Context, url string) (bool, error) {
 		Content:     page.Content,
 		StatusCode:  page.StatusCode,
 		CrawledAt:   time.Now(),
+		ContentType: page.ContentType,
+		ContentHash: contentHash,
+		ErrorClass:  errorClass,
+	}
+	if len(page.Outline) > 0 {
+		dbPage.Outline = marshalJSON(page.Outline)
+	}
+
+	req := &storage.WriteRequest{URL: normalizedURL, Page: dbPage}
+
+	relevance := -1.0
+	if errorClass == "" {
+		relevance = s.scorePage(normalizedURL, page)
+		req.Feeds = page.Feeds
+		req.Variants = languageVariants(page)
+		if page.Metadata != nil && !page.Metadata.IsEmpty() {
+			req.Metadata = toDBMetadata(normalizedURL, page.Metadata)
+		}
+		req.Dates = toDBDates(normalizedURL, page)
+		req.Annotations = annotations
+		if relevance >= 0 {
+			req.Annotations = append(req.Annotations, relevanceAnnotation(relevance))
+		}
+		if len(links) > 0 {
+			// The link graph keeps every outlink; only in-scope ones are crawled,
+			// and links to other shards' hosts are checked by their owner
+			req.Outlinks = toDBLinks(links)
+		}
+		if !s.follows(normalizedURL, relevance) {
+			links = nil
+		}
+		if len(links) > 0 {
+			links = s.forwardForeign(normalizedURL, relevance, s.inScope(links))
+			links, req.Quarantined = s.filterTraps(normalizedURL, links)
+		}
 	}
 
-	if err := s.db.SavePage(dbPage); err != nil {
+	if err := s.writer.Write(req); err != nil {
 		return false, fmt.Errorf("🔴 save page failed: %w", err)
 	}
 
-	linkURLs := make([]string, len(links))
-	for i, link := range links {
-		linkURLs[i] = link.URL
+	if s.traps.RecordContent(host, contentHash) {
+		s.quarantineHost(host)
 	}
 
-	if len(linkURLs) > 0 {
-		if err := s.db.SaveLinks(normalizedURL, linkURLs); err != nil {
-			log.Printf("🔴 Warning: Failed to save links: %v", err)
-		}
+	if errorClass != "" {
+		// Saved so it isn't re-crawled, but it doesn't count toward MaxPages
+		log.Printf("🚫 Marked %s as %s", normalizedURL, errorClass)
+		return false, nil
+	}
 
-		s.frontier.AddURLs(links)
+	if len(links) > 0 {
+		s.queueLinks(relevance, links)
 		log.Printf("Worker: Added %d new links to frontier", len(links))
 	}
 
 	return true, nil
 }
 
+// waitsForURLs reports whether workers should wait on an empty frontier
+// instead of exiting: feed items or links from other shards may still come.
+func (s *Scheduler) waitsForURLs() bool {
+	return s.config.FeedPollInterval > 0 || s.config.Shard.Enabled()
+}
+
+func (s *Scheduler) inScope(links []parser.Link) []parser.Link {
+	if s.config.Scope == nil {
+		return links
+	}
+
+	allowed := make([]parser.Link, 0, len(links))
+	for _, link := range links {
+		if s.config.Scope.Allows(link.URL) {
+			allowed = append(allowed, link)
+		}
+	}
+	return allowed
+}
+
+// filterTraps splits links into those safe to crawl and those that look
+// like crawler traps, which are quarantined instead.
+func (s *Scheduler) filterTraps(fromURL string, links []parser.Link) ([]parser.Link, []storage.QuarantinedURL) {
+	crawlable := make([]parser.Link, 0, len(links))
+	var quarantined []storage.QuarantinedURL
+
+	for _, link := range links {
+		if reason := s.traps.CheckURL(link.URL); reason != "" {
+			quarantined = append(quarantined, storage.QuarantinedURL{
+				URL:     link.URL,
+				Host:    parser.ExtractDomain(link.URL),
+				Reason:  reason,
+				FoundOn: fromURL,
+			})
+			continue
+		}
+		crawlable = append(crawlable, link)
+	}
+
+	if len(quarantined) > 0 {
+		log.Printf("🪤 Quarantined %d suspected trap URLs from %s", len(quarantined), fromURL)
+	}
+	return crawlable, quarantined
+}
+
+func (s *Scheduler) quarantineHost(host string) {
+	pages, unique := s.traps.HostYield(host)
+	dropped := s.frontier.RemoveHost(host)
+	log.Printf("🪤 Quarantined host %s: only %d unique of %d pages, dropped %d queued URLs", host, unique, pages, dropped)
+
+	err := s.writer.Write(&storage.WriteRequest{URL: host, QuarantinedHost: &storage.QuarantinedHost{
+		Host:         host,
+		Reason:       traps.ReasonLowContentYield,
+		PagesCrawled: pages,
+		UniquePages:  unique,
+	}})
+	if err != nil {
+		log.Printf("🔴 Warning: Failed to save quarantined host %s: %v", host, err)
+	}
+}
+
+func (s *Scheduler) logTrapReport() {
+	summaries, err := s.db.GetQuarantineReport(20)
+	if err != nil {
+		log.Printf("🔴 Warning: Failed to build trap report: %v", err)
+		return
+	}
+	if len(summaries) == 0 {
+		return
+	}
+
+	log.Printf("🪤 Suspected crawler traps (top %d):", len(summaries))
+	for _, summary := range summaries {
+		log.Printf("   %s  %-20s %6d URLs  e.g. %s", summary.Host, summary.Reason, summary.Count, summary.Example)
+	}
+}
+
+func (s *Scheduler) flushSiteTemplates(host string) {
+	templates := s.boilerplate.Templates(host)
+
+	dbTemplates := make([]storage.SiteTemplate, len(templates))
+	for i, t := range templates {
+		dbTemplates[i] = storage.SiteTemplate{
+			Host:      t.Host,
+			Hash:      t.Hash,
+			Text:      t.Text,
+			PageCount: t.PageCount,
+			HostPages: t.HostPages,
+		}
+	}
+
+	req := &storage.WriteRequest{URL: host, SiteTemplates: &storage.SiteTemplates{Host: host, Templates: dbTemplates}}
+	if err := s.writer.Write(req); err != nil {
+		log.Printf("🔴 Warning: Failed to save site templates for %s: %v", host, err)
+	}
+}
+
+func (s *Scheduler) flushAllSiteTemplates() {
+	for _, host := range s.boilerplate.PendingHosts() {
+		s.flushSiteTemplates(host)
+	}
+}
+
+// StripLearnedBoilerplate is the post-crawl pass: pages saved before a
+// host's templates were learned still carry them, so rewrite those pages.
+func (s *Scheduler) StripLearnedBoilerplate() {
+	for _, host := range s.boilerplate.ChangedHosts() {
+		updated, err := s.db.StripSiteTemplates(host)
+		if err != nil {
+			log.Printf("🔴 Warning: Failed to strip boilerplate for %s: %v", host, err)
+			continue
+		}
+		if updated > 0 {
+			log.Printf("Stripped boilerplate from %d earlier pages of %s", updated, host)
+		}
+	}
+}
+
+func toDBLinks(links []parser.Link) []storage.Link {
+	dbLinks := make([]storage.Link, len(links))
+	for i, link := range links {
+		dbLinks[i] = storage.Link{
+			ToURL:      link.URL,
+			AnchorText: link.AnchorText,
+			Title:      link.Title,
+			Rel:        strings.Join(link.Rel, " "),
+			Region:     link.Region,
+			IsInternal: link.Internal,
+		}
+	}
+	return dbLinks
+}
+
+func toDBMetadata(url string, meta *parser.Metadata) *storage.PageMetadata {
+	return &storage.PageMetadata{
+		URL:           url,
+		Type:          meta.Type,
+		Author:        meta.Author,
+		PublishedTime: meta.PublishedTime,
+		ModifiedTime:  meta.ModifiedTime,
+		Image:         meta.Image,
+		SiteName:      meta.SiteName,
+		Breadcrumb:    marshalJSON(meta.Breadcrumb),
+		OpenGraph:     marshalJSON(meta.OpenGraph),
+		JSONLD:        marshalJSON(meta.JSONLD),
+		Microdata:     marshalJSON(meta.Microdata),
+	}
+}
+
+func toDBDates(url string, page *parser.Page) *storage.PageDates {
+	return &storage.PageDates{
+		URL:                 url,
+		PublishedAt:         page.Published.Time,
+		PublishedSource:     page.Published.Source,
+		PublishedConfidence: page.Published.Confidence,
+		ModifiedAt:          page.Modified.Time,
+		ModifiedSource:      page.Modified.Source,
+		ModifiedConfidence:  page.Modified.Confidence,
+	}
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func marshalJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func toDetectorTemplates(templates []storage.SiteTemplate) []boilerplate.Template {
+	result := make([]boilerplate.Template, len(templates))
+	for i, t := range templates {
+		result[i] = boilerplate.Template{
+			Host:      t.Host,
+			Hash:      t.Hash,
+			Text:      t.Text,
+			PageCount: t.PageCount,
+			HostPages: t.HostPages,
+		}
+	}
+	return result
+}
+
 func (s *Scheduler) incrementBrowserFetchedCount() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -246,18 +747,83 @@ func (s *Scheduler) shouldStop() bool {
 
 func (s *Scheduler) incrementPageCount() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.pageCount++
+	s.mu.Unlock()
+
+	s.metrics.recordPage()
 }
 
 func (s *Scheduler) GetStats() map[string]interface{} {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	pageCount := s.pageCount
+	browserCount := s.browserFetchedCount
+	s.mu.Unlock()
+
+	m := s.metrics
+	fetches := m.fetches.Values()
+	var latencyCount uint64
+	var latencySum float64
+	for _, mode := range []string{FetchModeHTTP, FetchModeBrowser} {
+		count, sum := m.latency.With(mode).Snapshot()
+		latencyCount += count
+		latencySum += sum
+	}
+	avgLatencyMs := 0.0
+	if latencyCount > 0 {
+		avgLatencyMs = latencySum / float64(latencyCount) * 1000
+	}
 
-	return map[string]interface{}{
-		"pages_crawled": s.pageCount,
-		"queue_size":    s.frontier.Size(),
+	writes := s.writer.Stats()
+	stats := map[string]interface{}{
+		"uptime_sec":            int64(time.Since(m.startedAt).Seconds()),
+		"pages_crawled":         pageCount,
+		"max_pages":             s.config.MaxPages,
+		"pages_per_second":      m.pageRate.Rate(),
+		"outcomes":              m.outcomes.Values(),
+		"fetches":               fetches,
+		"browser_fetched":       browserCount,
+		"browser_fallback_rate": m.browserFallbackRatio(),
+		"bytes_downloaded":      m.bytes.Value(),
+		"avg_latency_ms":        avgLatencyMs,
+		"active_workers":        m.activeWorkers.Load(),
+		"workers":               s.config.Workers,
+		"queue_size":            s.frontier.Size(),
+		"largest_host_queues":   s.frontier.LargestHosts(10),
+		"write_queue":           writes.Queued,
+		"write_stalls":          writes.Stalls,
+		"write_failures":        writes.Failed,
+	}
+	if len(s.processors) > 0 {
+		stats["processors"] = s.processorStats()
+	}
+	if s.focus != nil {
+		var count uint64
+		var sum float64
+		for _, contentType := range []string{parser.ContentTypeHTML, parser.ContentTypePDF} {
+			c, total := m.relevance.With(contentType).Snapshot()
+			count += c
+			sum += total
+		}
+		if count > 0 {
+			stats["avg_relevance"] = sum / float64(count)
+		}
 	}
+
+	if s.forwarder != nil {
+		forwarded := s.forwarder.Stats()
+		stats["shard"] = s.config.Shard.ID
+		stats["shards"] = s.config.Shard.Count()
+		stats["links_forwarded"] = forwarded.Forwarded
+		stats["links_forward_pending"] = forwarded.Pending
+		stats["links_forward_dropped"] = forwarded.Dropped
+		stats["links_received"] = m.received.Value()
+	}
+	return stats
+}
+
+func isPDFContentType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	return strings.HasPrefix(contentType, "application/pdf") || strings.HasPrefix(contentType, "application/x-pdf")
 }
 
 func isHTMLContentType(contentType string) bool {
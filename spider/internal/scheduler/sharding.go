@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"log"
+
+	"github.com/dangpham/deisearch/spider/internal/frontier"
+	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/shard"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+// forwardForeign hands links to other shards' hosts to the forwarder and
+// returns the ones this shard crawls.
+func (s *Scheduler) forwardForeign(fromURL string, relevance float64, links []parser.Link) []parser.Link {
+	if s.forwarder == nil {
+		return links
+	}
+
+	local := make([]parser.Link, 0, len(links))
+	for _, link := range links {
+		host := parser.ExtractDomain(link.URL)
+		if s.config.Shard.Owns(host) {
+			local = append(local, link)
+			continue
+		}
+		s.forwarder.Forward(host, shard.Link{URL: link.URL, FoundOn: fromURL, Priority: s.linkPriority(relevance, link)})
+	}
+	return local
+}
+
+// receiveLinks queues links forwarded by other shards, after the same
+// scope and trap checks as links found locally.
+func (s *Scheduler) receiveLinks(batch shard.Batch) {
+	var regular []frontier.ScoredURL
+	var fresh []frontier.FreshURL
+	var quarantined []storage.QuarantinedURL
+
+	for _, link := range batch.Links {
+		url := parser.NormalizeURLString(link.URL)
+		host := parser.ExtractDomain(url)
+
+		// Misrouted links (e.g. a peer with a different shard count) are dropped
+		if !s.config.Shard.Owns(host) || !s.config.Scope.Allows(url) || s.traps.IsHostQuarantined(host) {
+			continue
+		}
+
+		crawlable, trapped := s.filterTraps(link.FoundOn, []parser.Link{{URL: url}})
+		quarantined = append(quarantined, trapped...)
+		if len(crawlable) == 0 {
+			continue
+		}
+
+		if link.Fresh {
+			fresh = append(fresh, frontier.FreshURL{URL: url, PublishedAt: link.PublishedAt})
+		} else {
+			regular = append(regular, frontier.ScoredURL{URL: url, Priority: link.Priority})
+		}
+	}
+
+	if len(quarantined) > 0 {
+		if err := s.writer.Write(&storage.WriteRequest{Quarantined: quarantined}); err != nil {
+			log.Printf("🔴 Warning: Failed to save quarantined URLs from shard %d: %v", batch.From, err)
+		}
+	}
+
+	s.frontier.AddScoredURLs(regular)
+	s.frontier.AddFreshURLs(fresh)
+	s.metrics.received.Add(int64(len(batch.Links)))
+}
@@ -0,0 +1,106 @@
+package scope
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Config lists which URLs the crawl may enter. Domains match the host and
+// its subdomains; patterns are regular expressions on the full URL.
+type Config struct {
+	AllowDomains  []string `yaml:"allow_domains"`
+	DenyDomains   []string `yaml:"deny_domains"`
+	AllowPatterns []string `yaml:"allow_patterns"`
+	DenyPatterns  []string `yaml:"deny_patterns"`
+}
+
+type Rules struct {
+	allowDomains  []string
+	denyDomains   []string
+	allowPatterns []*regexp.Regexp
+	denyPatterns  []*regexp.Regexp
+}
+
+func Compile(config Config) (*Rules, error) {
+	rules := &Rules{
+		allowDomains: normalizeDomains(config.AllowDomains),
+		denyDomains:  normalizeDomains(config.DenyDomains),
+	}
+
+	var err error
+	if rules.allowPatterns, err = compilePatterns(config.AllowPatterns); err != nil {
+		return nil, fmt.Errorf("invalid allow pattern: %w", err)
+	}
+	if rules.denyPatterns, err = compilePatterns(config.DenyPatterns); err != nil {
+		return nil, fmt.Errorf("invalid deny pattern: %w", err)
+	}
+	return rules, nil
+}
+
+// Allows reports whether a URL is in scope. Deny rules win over allow
+// rules; with no allow rules everything not denied is allowed. A nil
+// *Rules allows everything.
+func (r *Rules) Allows(rawURL string) bool {
+	if r == nil {
+		return true
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	if matchesDomain(host, r.denyDomains) || matchesPattern(rawURL, r.denyPatterns) {
+		return false
+	}
+
+	if len(r.allowDomains) == 0 && len(r.allowPatterns) == 0 {
+		return true
+	}
+	return matchesDomain(host, r.allowDomains) || matchesPattern(rawURL, r.allowPatterns)
+}
+
+func normalizeDomains(domains []string) []string {
+	var result []string
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		domain = strings.TrimPrefix(domain, "*.")
+		if domain != "" {
+			result = append(result, domain)
+		}
+	}
+	return result
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", pattern, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesPattern(rawURL string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package seen
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// BloomFilter answers "definitely not seen" exactly and "maybe seen" with
+// a tunable false-positive rate, in a fixed amount of memory.
+type BloomFilter struct {
+	bits   []uint64
+	m      uint64
+	k      int
+	seeds  [2]maphash.Seed
+	filled int
+}
+
+// NewBloomFilter sizes the filter for capacity items at the given false
+// positive rate. Adding more items still works, with a rising rate.
+func NewBloomFilter(capacity int, falsePositiveRate float64) *BloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	m := math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(m / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	words := (uint64(m) + 63) / 64
+	return &BloomFilter{
+		bits:  make([]uint64, words),
+		m:     words * 64,
+		k:     k,
+		seeds: [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()},
+	}
+}
+
+func (b *BloomFilter) Add(item string) {
+	h1, h2 := b.hashes(item)
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.filled++
+}
+
+func (b *BloomFilter) MayContain(item string) bool {
+	h1, h2 := b.hashes(item)
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count is the number of Add calls, including repeats.
+func (b *BloomFilter) Count() int {
+	return b.filled
+}
+
+// SizeBytes is the memory held by the bit array.
+func (b *BloomFilter) SizeBytes() int {
+	return len(b.bits) * 8
+}
+
+// hashes returns the two hashes the k probe positions are derived from
+// (double hashing). The second is forced odd so it is never zero.
+func (b *BloomFilter) hashes(item string) (uint64, uint64) {
+	return maphash.String(b.seeds[0], item), maphash.String(b.seeds[1], item) | 1
+}
@@ -0,0 +1,224 @@
+package seen
+
+import (
+	"log"
+	"sync"
+)
+
+// Set records every URL the frontier has accepted, so a URL is queued at
+// most once per run.
+type Set interface {
+	// Add marks url as seen and reports whether it was new.
+	Add(url string) bool
+	Has(url string) bool
+}
+
+// MemorySet keeps every URL in a map. Fine for tests and small crawls.
+type MemorySet struct {
+	urls map[string]bool
+	mu   sync.Mutex
+}
+
+func NewMemorySet(urls []string) *MemorySet {
+	set := &MemorySet{urls: make(map[string]bool, len(urls))}
+	for _, url := range urls {
+		set.urls[url] = true
+	}
+	return set
+}
+
+func (s *MemorySet) Add(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.urls[url] {
+		return false
+	}
+	s.urls[url] = true
+	return true
+}
+
+func (s *MemorySet) Has(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.urls[url]
+}
+
+// Store is the exact on-disk set behind a DiskSet.
+type Store interface {
+	HasSeenURL(url string) (bool, error)
+	AddSeenURLs(urls []string) error
+}
+
+type Config struct {
+	Capacity          int     // URLs the Bloom filter is sized for
+	FalsePositiveRate float64 // at Capacity
+	RecentSize        int     // recently confirmed URLs answered from memory
+	FlushSize         int     // new URLs buffered before a batch write
+}
+
+// DiskSet puts a Bloom filter in front of an exact on-disk set. Most new
+// URLs are answered by the filter alone; "maybe seen" answers are checked
+// against recently seen URLs in memory, then the store. The store is only
+// called without the lock held, so a slow lookup or write doesn't stall
+// every worker adding links.
+type DiskSet struct {
+	config   Config
+	filter   *BloomFilter
+	store    Store
+	pending  map[string]bool
+	flushing map[string]bool // handed to the store, write not returned yet
+	recent   map[string]bool
+	previous map[string]bool
+	mu       sync.Mutex
+}
+
+func NewDiskSet(store Store, config Config) *DiskSet {
+	if config.Capacity == 0 {
+		config.Capacity = 20000000
+	}
+	if config.FalsePositiveRate == 0 {
+		config.FalsePositiveRate = 0.01
+	}
+	if config.RecentSize == 0 {
+		config.RecentSize = 100000
+	}
+	if config.FlushSize == 0 {
+		config.FlushSize = 5000
+	}
+
+	return &DiskSet{
+		config:   config,
+		filter:   NewBloomFilter(config.Capacity, config.FalsePositiveRate),
+		store:    store,
+		pending:  make(map[string]bool),
+		flushing: make(map[string]bool),
+		recent:   make(map[string]bool),
+		previous: make(map[string]bool),
+	}
+}
+
+// Preload adds URLs the store already answers for (e.g. crawled pages) to
+// the filter without writing them again.
+func (s *DiskSet) Preload(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter.Add(url)
+}
+
+func (s *DiskSet) Add(url string) bool {
+	if s.Has(url) {
+		return false
+	}
+
+	s.mu.Lock()
+	// Another worker may have added it while the store was checked
+	if s.inMemory(url) {
+		s.mu.Unlock()
+		return false
+	}
+	s.filter.Add(url)
+	s.pending[url] = true
+	var batch []string
+	if len(s.pending) >= s.config.FlushSize {
+		batch = s.takePending()
+	}
+	s.mu.Unlock()
+
+	s.write(batch)
+	return true
+}
+
+func (s *DiskSet) Has(url string) bool {
+	s.mu.Lock()
+	if !s.filter.MayContain(url) {
+		s.mu.Unlock()
+		return false
+	}
+	if s.inMemory(url) {
+		s.mu.Unlock()
+		return true
+	}
+	s.mu.Unlock()
+
+	found, err := s.store.HasSeenURL(url)
+	if err != nil {
+		// Re-queueing a URL is harmless; losing one is not
+		log.Printf("🔴 Warning: Seen-set lookup failed for %s: %v", url, err)
+		return false
+	}
+	if found {
+		s.mu.Lock()
+		s.remember(url)
+		s.mu.Unlock()
+	}
+	return found
+}
+
+// Flush writes buffered URLs to the store.
+func (s *DiskSet) Flush() {
+	s.mu.Lock()
+	batch := s.takePending()
+	s.mu.Unlock()
+	s.write(batch)
+}
+
+func (s *DiskSet) SizeBytes() int {
+	return s.filter.SizeBytes()
+}
+
+func (s *DiskSet) inMemory(url string) bool {
+	return s.pending[url] || s.flushing[url] || s.recent[url] || s.previous[url]
+}
+
+// remember keeps confirmed URLs in two rotating generations, so popular
+// URLs (nav links found on every page) skip the store.
+func (s *DiskSet) remember(url string) {
+	if len(s.recent) >= s.config.RecentSize {
+		s.previous = s.recent
+		s.recent = make(map[string]bool)
+	}
+	s.recent[url] = true
+}
+
+// takePending moves buffered URLs to flushing, where they're still answered
+// from memory until their write returns.
+func (s *DiskSet) takePending() []string {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	urls := make([]string, 0, len(s.pending))
+	for url := range s.pending {
+		urls = append(urls, url)
+		s.flushing[url] = true
+	}
+	s.pending = make(map[string]bool)
+	return urls
+}
+
+// write stores a batch taken by takePending. It must be called without the
+// lock held.
+func (s *DiskSet) write(urls []string) {
+	if len(urls) == 0 {
+		return
+	}
+
+	err := s.store.AddSeenURLs(urls)
+	if err != nil {
+		log.Printf("🔴 Warning: Failed to write %d seen URLs: %v", len(urls), err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, url := range urls {
+		delete(s.flushing, url)
+		if err != nil {
+			// Keep them pending so they're still answered from memory
+			s.pending[url] = true
+		} else {
+			// The store may write them asynchronously; answer from memory meanwhile
+			s.remember(url)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package shard
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LinksPath is where shards accept forwarded links.
+const LinksPath = "/shard/links"
+
+const (
+	forwardBatchSize  = 500
+	forwardInterval   = time.Second
+	maxPendingPerPeer = 200000 // links held for an unreachable peer before dropping
+	maxBatchBytes     = 32 * 1024 * 1024
+)
+
+type ForwarderStats struct {
+	Forwarded int64 // delivered to their owner
+	Dropped   int64 // discarded because a peer stayed unreachable
+	Failures  int64 // failed POSTs, retried later
+	Pending   int
+}
+
+// Forwarder batches links per peer shard and POSTs them to their owners.
+// Batches that fail are kept and retried on the next flush.
+type Forwarder struct {
+	config  *Config
+	client  *http.Client
+	pending [][]Link
+	failing []bool // peers whose last delivery failed, to log once per outage
+	stats   ForwarderStats
+	flushCh chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+}
+
+func NewForwarder(config *Config) *Forwarder {
+	f := &Forwarder{
+		config:  config,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		pending: make([][]Link, len(config.Peers)),
+		failing: make([]bool, len(config.Peers)),
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	f.wg.Add(1)
+	go f.run()
+	return f
+}
+
+// Forward queues a link for the shard that owns its host.
+func (f *Forwarder) Forward(host string, link Link) {
+	owner := Owner(host, len(f.config.Peers))
+	if owner == f.config.ID {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.pending[owner]) >= maxPendingPerPeer {
+		f.stats.Dropped++
+		return
+	}
+	f.pending[owner] = append(f.pending[owner], link)
+
+	if len(f.pending[owner]) == forwardBatchSize {
+		select {
+		case f.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (f *Forwarder) Stats() ForwarderStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stats := f.stats
+	for _, links := range f.pending {
+		stats.Pending += len(links)
+	}
+	return stats
+}
+
+// Close makes a last delivery attempt and stops the forwarder. Links for
+// peers that are still unreachable are lost.
+func (f *Forwarder) Close() {
+	close(f.done)
+	f.wg.Wait()
+
+	f.flush()
+	if stats := f.Stats(); stats.Pending > 0 {
+		log.Printf("⚠️  Shutting down with %d links not delivered to their shards", stats.Pending)
+	}
+}
+
+func (f *Forwarder) run() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(forwardInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+		case <-f.flushCh:
+		}
+		f.flush()
+	}
+}
+
+func (f *Forwarder) flush() {
+	for peer := range f.config.Peers {
+		f.mu.Lock()
+		links := f.pending[peer]
+		f.pending[peer] = nil
+		f.mu.Unlock()
+
+		for len(links) > 0 {
+			n := min(len(links), forwardBatchSize)
+			if err := f.send(peer, links[:n]); err != nil {
+				if !f.failing[peer] {
+					log.Printf("⚠️  Failed to forward links to shard %d, holding them for retry: %v", peer, err)
+				}
+				f.failing[peer] = true
+				f.requeue(peer, links)
+				break
+			}
+
+			if f.failing[peer] {
+				log.Printf("Shard %d reachable again", peer)
+				f.failing[peer] = false
+			}
+			f.mu.Lock()
+			f.stats.Forwarded += int64(n)
+			f.mu.Unlock()
+			links = links[n:]
+		}
+	}
+}
+
+// requeue puts undelivered links back in front of links queued meanwhile.
+func (f *Forwarder) requeue(peer int, links []Link) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stats.Failures++
+	merged := append(links, f.pending[peer]...)
+	if len(merged) > maxPendingPerPeer {
+		f.stats.Dropped += int64(len(merged) - maxPendingPerPeer)
+		merged = merged[:maxPendingPerPeer]
+	}
+	f.pending[peer] = merged
+}
+
+func (f *Forwarder) send(peer int, links []Link) error {
+	body, err := json.Marshal(Batch{From: f.config.ID, Links: links})
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), f.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+f.config.Peers[peer]+LinksPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.config.Token)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("non-200 status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Handler accepts batches from other shards and passes them to receive.
+// Requests without the shared token are rejected.
+func Handler(token string, receive func(Batch)) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var batch Batch
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxBatchBytes)).Decode(&batch); err != nil {
+			http.Error(w, "invalid batch: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		receive(batch)
+		w.WriteHeader(http.StatusOK)
+	})
+}
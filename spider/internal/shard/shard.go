@@ -0,0 +1,108 @@
+// Package shard splits a crawl across spider processes. Every host belongs
+// to exactly one shard, so per-host politeness holds without coordination;
+// links to hosts owned elsewhere are forwarded to their owner.
+package shard
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	// This process's shard, an index into Peers
+	ID int `yaml:"id"`
+
+	// Link exchange address (host:port) of every shard, in shard order.
+	// Fewer than two peers means the crawl isn't sharded.
+	Peers []string `yaml:"peers"`
+
+	// Address to accept forwarded links on; defaults to Peers[ID]
+	Listen string `yaml:"listen"`
+
+	// Shared secret every shard sends with forwarded links and requires on
+	// the ones it receives
+	Token string `yaml:"token"`
+}
+
+func (c *Config) Enabled() bool {
+	return c != nil && len(c.Peers) > 1
+}
+
+func (c *Config) Count() int {
+	if !c.Enabled() {
+		return 1
+	}
+	return len(c.Peers)
+}
+
+func (c *Config) ListenAddr() string {
+	if c.Listen != "" {
+		return c.Listen
+	}
+	return c.Peers[c.ID]
+}
+
+// Owns reports whether this shard crawls the host. Everything is owned
+// when sharding is off.
+func (c *Config) Owns(host string) bool {
+	return !c.Enabled() || Owner(host, len(c.Peers)) == c.ID
+}
+
+func (c *Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	var errs []error
+	if c.ID < 0 || c.ID >= len(c.Peers) {
+		errs = append(errs, fmt.Errorf("id %d is out of range for %d peers", c.ID, len(c.Peers)))
+	}
+	for i, peer := range c.Peers {
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			errs = append(errs, fmt.Errorf("peer %d %q must be host:port", i, peer))
+		}
+	}
+	if c.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+			errs = append(errs, fmt.Errorf("listen %q must be host:port or :port", c.Listen))
+		}
+	}
+	if c.Token == "" {
+		errs = append(errs, errors.New("token must be set, or anyone reaching the link exchange can queue URLs"))
+	}
+	return errors.Join(errs...)
+}
+
+// Owner maps a host to a shard. The mapping only depends on the host and
+// the shard count, so every process agrees on it.
+func Owner(host string, shards int) int {
+	if shards <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(host)))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// Link is a discovered URL sent to the shard owning its host.
+type Link struct {
+	URL     string `json:"url"`
+	FoundOn string `json:"found_on,omitempty"`
+
+	// Set for feed items, which the owner queues ahead of regular links
+	PublishedAt time.Time `json:"published_at,omitzero"`
+	Fresh       bool      `json:"fresh,omitempty"`
+
+	// Frontier priority the sender gave the link in a focused crawl
+	Priority float64 `json:"priority,omitempty"`
+}
+
+// Batch is the body of POST /shard/links.
+type Batch struct {
+	From  int    `json:"from"`
+	Links []Link `json:"links"`
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// PageAnnotation is a key/value field a page processor stored for a page.
+type PageAnnotation struct {
+	Processor string
+	Key       string
+	Value     string
+}
+
+func (d *Database) SavePageAnnotations(url string, annotations []PageAnnotation) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := savePageAnnotations(tx, url, annotations); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// savePageAnnotations replaces every annotation of a page, so fields a
+// processor no longer sets don't linger from an earlier crawl.
+func savePageAnnotations(tx *sql.Tx, url string, annotations []PageAnnotation) error {
+	if _, err := tx.Exec("DELETE FROM page_annotations WHERE url = ?", url); err != nil {
+		return fmt.Errorf("failed to clear annotations: %w", err)
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO page_annotations (url, processor, key, value, updated_at) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(url, processor, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, a := range annotations {
+		if _, err := stmt.Exec(url, a.Processor, a.Key, a.Value); err != nil {
+			return fmt.Errorf("failed to save annotation %s.%s: %w", a.Processor, a.Key, err)
+		}
+	}
+	return nil
+}
+
+// GetPageAnnotations returns a page's annotations ordered by processor
+// and key.
+func (d *Database) GetPageAnnotations(url string) ([]PageAnnotation, error) {
+	rows, err := d.db.Query("SELECT processor, key, value FROM page_annotations WHERE url = ? ORDER BY processor, key", url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var annotations []PageAnnotation
+	for rows.Next() {
+		var a PageAnnotation
+		if err := rows.Scan(&a.Processor, &a.Key, &a.Value); err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, rows.Err()
+}
@@ -3,13 +3,26 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/dangpham/deisearch/spider/internal/boilerplate"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Database struct {
-	db *sql.DB
+	db        *sql.DB
+	path      string
+	retention VersionRetention
+	eventLog  bool
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx, so single-row writes
+// work on their own and inside the Writer's batched transactions.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 func NewDatabase(dbPath string) (*Database, error) {
@@ -22,7 +35,7 @@ func NewDatabase(dbPath string) (*Database, error) {
 		return nil, fmt.Errorf("failed to enable WAL: %w", err)
 	}
 
-	database := &Database{db: db}
+	database := &Database{db: db, path: dbPath, retention: DefaultVersionRetention}
 
 	if err := database.initSchema(); err != nil {
 		return nil, err
@@ -41,22 +54,274 @@ func (d *Database) initSchema() error {
 		description TEXT,
 		content TEXT,
 		status_code INTEGER,
-		crawled_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		crawled_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		outline TEXT DEFAULT '',   -- JSON array of {level, text, id, content} heading sections
+		content_type TEXT DEFAULT 'text/html',
+		content_hash TEXT DEFAULT '',  -- sha256 of content, for per-host duplicate yield
+		error_class TEXT DEFAULT ''    -- set for soft 404s, error and login pages served with 200
 	);
 	CREATE INDEX IF NOT EXISTS idx_pages_url ON pages(url);
 
 	-- Links: Link graph for PageRank
-	-- Stores: from_url links to to_url
+	-- Stores: from_url links to to_url, with the anchor text and attributes of the first link seen
+	-- rel holds space-separated tokens, region is nav/header/content/sidebar/footer
 	CREATE TABLE IF NOT EXISTS links (
 		from_url TEXT,
 		to_url TEXT,
+		anchor_text TEXT DEFAULT '',
+		title TEXT DEFAULT '',
+		rel TEXT DEFAULT '',
+		region TEXT DEFAULT '',
+		is_internal INTEGER DEFAULT 0,
 		PRIMARY KEY (from_url, to_url)
 	);
+	CREATE INDEX IF NOT EXISTS idx_links_to_url ON links(to_url);
+
+	-- Page metadata: structured data (JSON-LD, microdata/RDFa, OpenGraph/Twitter cards)
+	-- breadcrumb, opengraph, json_ld and microdata hold JSON
+	CREATE TABLE IF NOT EXISTS page_metadata (
+		url TEXT PRIMARY KEY,
+		type TEXT,
+		author TEXT,
+		published_time TEXT,
+		modified_time TEXT,
+		image TEXT,
+		site_name TEXT,
+		breadcrumb TEXT,
+		opengraph TEXT,
+		json_ld TEXT,
+		microdata TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Page dates: publication and last modification dates with where they were
+	-- found (json_ld, meta, microdata, pdf, time_element, url, last_modified)
+	-- and how far that source is trusted, from 0 to 1
+	CREATE TABLE IF NOT EXISTS page_dates (
+		url TEXT PRIMARY KEY,
+		published_at DATETIME,
+		published_source TEXT DEFAULT '',
+		published_confidence REAL DEFAULT 0,
+		modified_at DATETIME,
+		modified_source TEXT DEFAULT '',
+		modified_confidence REAL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_page_dates_published ON page_dates(published_at);
+
+	-- Page annotations: key/value fields set by the scheduler's page processors
+	CREATE TABLE IF NOT EXISTS page_annotations (
+		url TEXT NOT NULL,
+		processor TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (url, processor, key)
+	);
+
+	-- Page events: outbox of new, updated and deleted pages, delivered to the
+	-- configured sinks; seq is the offset consumers resume from
+	CREATE TABLE IF NOT EXISTS page_events (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		page_id INTEGER,
+		url TEXT NOT NULL,
+		content_hash TEXT DEFAULT '',
+		error_class TEXT DEFAULT '',
+		change TEXT NOT NULL,
+		at DATETIME NOT NULL
+	);
+
+	-- Event cursors: the last page event delivered to each sink
+	CREATE TABLE IF NOT EXISTS event_cursors (
+		sink TEXT PRIMARY KEY,
+		seq INTEGER NOT NULL,
+		updated_at DATETIME
+	);
+
+	-- Feeds: RSS/Atom feeds discovered on crawled pages, polled for new URLs
+	CREATE TABLE IF NOT EXISTS feeds (
+		url TEXT PRIMARY KEY,
+		site_url TEXT,
+		etag TEXT DEFAULT '',
+		last_modified TEXT DEFAULT '',
+		last_polled_at DATETIME,
+		last_item_at DATETIME,
+		consecutive_errors INTEGER DEFAULT 0,
+		discovered_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_feeds_last_polled ON feeds(last_polled_at);
+
+	-- Site templates: recurring text blocks learned per host (footers, legal notices)
+	CREATE TABLE IF NOT EXISTS site_templates (
+		host TEXT NOT NULL,
+		block_hash INTEGER NOT NULL,
+		block_text TEXT NOT NULL,
+		page_count INTEGER NOT NULL,
+		host_pages INTEGER NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (host, block_hash)
+	);
+
+	-- Quarantine: URLs and hosts suspected to be crawler traps, skipped instead of crawled
+	CREATE TABLE IF NOT EXISTS quarantined_urls (
+		url TEXT PRIMARY KEY,
+		host TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		found_on TEXT,
+		detected_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_quarantined_urls_host ON quarantined_urls(host);
+
+	-- Seeds: start URLs added with "spider seeds add", crawled along with the config file's seeds
+	CREATE TABLE IF NOT EXISTS seeds (
+		url TEXT PRIMARY KEY,
+		added_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Seen URLs: every URL queued during the current run, the exact set behind
+	-- the frontier's Bloom filter. Cleared at startup; crawled URLs are in pages.
+	CREATE TABLE IF NOT EXISTS seen_urls (
+		url TEXT PRIMARY KEY
+	) WITHOUT ROWID;
+
+	-- Crawl attempts: one row per fetch attempt with its outcome, successful or not
+	CREATE TABLE IF NOT EXISTS crawl_attempts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		host TEXT NOT NULL,
+		attempted_at DATETIME NOT NULL,
+		outcome TEXT NOT NULL,
+		status_code INTEGER,
+		error TEXT DEFAULT '',
+		latency_ms INTEGER,
+		bytes INTEGER,
+		fetch_mode TEXT DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_crawl_attempts_time ON crawl_attempts(attempted_at);
+	CREATE INDEX IF NOT EXISTS idx_crawl_attempts_host ON crawl_attempts(host, attempted_at);
+
+	-- Host probes: what each host serves for a path that cannot exist, used to spot soft 404s
+	CREATE TABLE IF NOT EXISTS host_probes (
+		host TEXT PRIMARY KEY,
+		status_code INTEGER,
+		final_url TEXT DEFAULT '',
+		title TEXT DEFAULT '',
+		fingerprint INTEGER,
+		words INTEGER,
+		probed_at DATETIME
+	);
+
+	-- Language variants: pages linked by hreflang alternates. Variants of the
+	-- same page share a cluster (the smallest URL among them), so results can
+	-- be deduplicated across languages
+	CREATE TABLE IF NOT EXISTS language_variants (
+		url TEXT PRIMARY KEY,
+		cluster TEXT NOT NULL,
+		lang TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_language_variants_cluster ON language_variants(cluster);
+
+	-- Page versions: earlier states of re-crawled pages whose title, description
+	-- or content changed; content is gzip-compressed
+	CREATE TABLE IF NOT EXISTS page_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		host TEXT NOT NULL,
+		title TEXT,
+		description TEXT,
+		content BLOB,
+		content_hash TEXT,
+		crawled_at DATETIME,
+		archived_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_page_versions_url ON page_versions(url, crawled_at);
+	CREATE INDEX IF NOT EXISTS idx_page_versions_host ON page_versions(host);
+
+	-- Quarantined hosts: hosts whose pages were mostly duplicate content,
+	-- with how many pages were crawled and how many were distinct; their
+	-- URLs are skipped on later crawls
+	CREATE TABLE IF NOT EXISTS quarantined_hosts (
+		host TEXT PRIMARY KEY,
+		reason TEXT NOT NULL,
+		pages_crawled INTEGER,
+		unique_pages INTEGER,
+		detected_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
 	`
+	if err := d.migrate(); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	_, err := d.db.Exec(schema)
 	return err
 }
 
+// migrate adds columns introduced after a table was first created, so
+// spider.db files from earlier crawls keep working.
+func (d *Database) migrate() error {
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"pages", "outline", "TEXT DEFAULT ''"},
+		{"pages", "content_type", "TEXT DEFAULT 'text/html'"},
+		{"pages", "content_hash", "TEXT DEFAULT ''"},
+		{"pages", "error_class", "TEXT DEFAULT ''"},
+		{"links", "anchor_text", "TEXT DEFAULT ''"},
+		{"links", "title", "TEXT DEFAULT ''"},
+		{"links", "rel", "TEXT DEFAULT ''"},
+		{"links", "region", "TEXT DEFAULT ''"},
+		{"links", "is_internal", "INTEGER DEFAULT 0"},
+		{"host_probes", "words", "INTEGER"},
+	}
+
+	for _, c := range columns {
+		if err := d.ensureColumn(c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Database) ensureColumn(table, column, definition string) error {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tableExists := false
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		tableExists = true
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	// Fresh databases get the column from CREATE TABLE
+	if !tableExists {
+		return nil
+	}
+
+	_, err = d.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
 type Page struct {
 	URL         string
 	Title       string
@@ -64,34 +329,160 @@ type Page struct {
 	Content     string
 	StatusCode  int
 	CrawledAt   time.Time
+	Outline     string // JSON array of heading sections
+	ContentType string
+	ContentHash string
+	ErrorClass  string // empty for normal pages
 }
 
 func (d *Database) SavePage(page *Page) error {
+	return d.savePage(d.db, page)
+}
+
+// savePage archives the stored version of a page when it changed, then
+// overwrites it. With the event log on, new and changed pages add an event.
+func (d *Database) savePage(ex execer, page *Page) error {
+	var existed bool
+	var oldHash, oldErrorClass string
+	if d.eventLog {
+		var err error
+		if existed, oldHash, oldErrorClass, err = previousPage(ex, page.URL); err != nil {
+			return err
+		}
+	}
+
+	if err := d.archivePage(ex, page); err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO pages (url, title, description, content, status_code, crawled_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO pages (url, title, description, content, status_code, crawled_at, outline, content_type, content_hash, error_class)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(url) DO UPDATE SET
 			title = excluded.title,
 			description = excluded.description,
 			content = excluded.content,
 			status_code = excluded.status_code,
-			crawled_at = excluded.crawled_at
+			crawled_at = excluded.crawled_at,
+			outline = excluded.outline,
+			content_type = excluded.content_type,
+			content_hash = excluded.content_hash,
+			error_class = excluded.error_class
 	`
 
-	_, err := d.db.Exec(query,
+	contentType := page.ContentType
+	if contentType == "" {
+		contentType = "text/html"
+	}
+
+	_, err := ex.Exec(query,
 		page.URL,
 		page.Title,
 		page.Description,
 		page.Content,
 		page.StatusCode,
 		page.CrawledAt,
+		page.Outline,
+		contentType,
+		page.ContentHash,
+		page.ErrorClass,
+	)
+	if err != nil || !d.eventLog {
+		return err
+	}
+
+	switch {
+	case !existed:
+		return recordPageEvents(ex, ChangeNew, "url = ?", page.URL)
+	case oldHash != page.ContentHash || oldErrorClass != page.ErrorClass:
+		return recordPageEvents(ex, ChangeUpdated, "url = ?", page.URL)
+	}
+	return nil
+}
+
+type PageMetadata struct {
+	URL           string
+	Type          string
+	Author        string
+	PublishedTime string
+	ModifiedTime  string
+	Image         string
+	SiteName      string
+	Breadcrumb    string // JSON array
+	OpenGraph     string // JSON object
+	JSONLD        string // JSON array
+	Microdata     string // JSON array
+}
+
+func (d *Database) SavePageMetadata(meta *PageMetadata) error {
+	return savePageMetadata(d.db, meta)
+}
+
+func savePageMetadata(ex execer, meta *PageMetadata) error {
+	query := `
+		INSERT INTO page_metadata (url, type, author, published_time, modified_time, image, site_name, breadcrumb, opengraph, json_ld, microdata, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(url) DO UPDATE SET
+			type = excluded.type,
+			author = excluded.author,
+			published_time = excluded.published_time,
+			modified_time = excluded.modified_time,
+			image = excluded.image,
+			site_name = excluded.site_name,
+			breadcrumb = excluded.breadcrumb,
+			opengraph = excluded.opengraph,
+			json_ld = excluded.json_ld,
+			microdata = excluded.microdata,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := ex.Exec(query,
+		meta.URL,
+		meta.Type,
+		meta.Author,
+		meta.PublishedTime,
+		meta.ModifiedTime,
+		meta.Image,
+		meta.SiteName,
+		meta.Breadcrumb,
+		meta.OpenGraph,
+		meta.JSONLD,
+		meta.Microdata,
 	)
 
 	return err
 }
 
+func (d *Database) GetPageMetadata(url string) (*PageMetadata, error) {
+	query := `
+		SELECT url, type, author, published_time, modified_time, image, site_name, breadcrumb, opengraph, json_ld, microdata
+		FROM page_metadata WHERE url = ?
+	`
+
+	var meta PageMetadata
+	err := d.db.QueryRow(query, url).Scan(
+		&meta.URL,
+		&meta.Type,
+		&meta.Author,
+		&meta.PublishedTime,
+		&meta.ModifiedTime,
+		&meta.Image,
+		&meta.SiteName,
+		&meta.Breadcrumb,
+		&meta.OpenGraph,
+		&meta.JSONLD,
+		&meta.Microdata,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return &meta, err
+}
+
 func (d *Database) GetPage(url string) (*Page, error) {
-	query := "SELECT url, title, description, content, status_code, crawled_at FROM pages WHERE url = ?"
+	query := "SELECT url, title, description, content, status_code, crawled_at, COALESCE(outline, ''), COALESCE(content_type, 'text/html'), COALESCE(content_hash, ''), COALESCE(error_class, '') FROM pages WHERE url = ?"
 
 	var page Page
 	err := d.db.QueryRow(query, url).Scan(
@@ -101,6 +492,10 @@ func (d *Database) GetPage(url string) (*Page, error) {
 		&page.Content,
 		&page.StatusCode,
 		&page.CrawledAt,
+		&page.Outline,
+		&page.ContentType,
+		&page.ContentHash,
+		&page.ErrorClass,
 	)
 
 	if err == sql.ErrNoRows {
@@ -110,6 +505,73 @@ func (d *Database) GetPage(url string) (*Page, error) {
 	return &page, err
 }
 
+// ForEachCrawledURL streams the URLs of all crawled pages without loading
+// them into memory at once.
+func (d *Database) ForEachCrawledURL(fn func(url string)) error {
+	rows, err := d.db.Query("SELECT url FROM pages")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return err
+		}
+		fn(url)
+	}
+	return rows.Err()
+}
+
+// HasSeenURL reports whether a URL was queued this run or crawled before.
+func (d *Database) HasSeenURL(url string) (bool, error) {
+	var found bool
+	err := d.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM seen_urls WHERE url = ?) OR EXISTS(SELECT 1 FROM pages WHERE url = ?)
+	`, url, url).Scan(&found)
+	return found, err
+}
+
+func (d *Database) AddSeenURLs(urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := addSeenURLs(tx, urls); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func addSeenURLs(tx *sql.Tx, urls []string) error {
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO seen_urls (url) VALUES (?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, url := range urls {
+		if _, err := stmt.Exec(url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetSeenURLs forgets URLs queued by a previous run. The frontier isn't
+// persisted, so those URLs must be discoverable again.
+func (d *Database) ResetSeenURLs() error {
+	_, err := d.db.Exec("DELETE FROM seen_urls")
+	return err
+}
+
 func (d *Database) LoadAllCrawledURLs() ([]string, error) {
 	rows, err := d.db.Query("SELECT url FROM pages")
 	if err != nil {
@@ -161,6 +623,814 @@ func (d *Database) SaveLinks(fromURL string, toURLs []string) error {
 	return tx.Commit()
 }
 
+type Feed struct {
+	URL               string
+	SiteURL           string
+	ETag              string
+	LastModified      string
+	LastPolledAt      time.Time
+	LastItemAt        time.Time
+	ConsecutiveErrors int
+}
+
+func (d *Database) SaveFeeds(siteURL string, feedURLs []string) error {
+	if len(feedURLs) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveFeeds(tx, siteURL, feedURLs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveFeeds(tx *sql.Tx, siteURL string, feedURLs []string) error {
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO feeds (url, site_url) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, feedURL := range feedURLs {
+		if _, err := stmt.Exec(feedURL, siteURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetFeedsDue returns feeds never polled or last polled before the cutoff,
+// least recently polled first.
+func (d *Database) GetFeedsDue(polledBefore time.Time, limit int) ([]Feed, error) {
+	rows, err := d.db.Query(`
+		SELECT url, COALESCE(site_url, ''), COALESCE(etag, ''), COALESCE(last_modified, ''),
+			last_polled_at, last_item_at, COALESCE(consecutive_errors, 0)
+		FROM feeds
+		WHERE last_polled_at IS NULL OR last_polled_at < ?
+		ORDER BY last_polled_at IS NOT NULL, last_polled_at
+		LIMIT ?
+	`, polledBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []Feed
+	for rows.Next() {
+		var feed Feed
+		var lastPolled, lastItem sql.NullTime
+		if err := rows.Scan(&feed.URL, &feed.SiteURL, &feed.ETag, &feed.LastModified, &lastPolled, &lastItem, &feed.ConsecutiveErrors); err != nil {
+			return nil, err
+		}
+		feed.LastPolledAt = lastPolled.Time
+		feed.LastItemAt = lastItem.Time
+		feeds = append(feeds, feed)
+	}
+	return feeds, rows.Err()
+}
+
+func (d *Database) UpdateFeedPoll(feed *Feed) error {
+	var lastItem interface{}
+	if !feed.LastItemAt.IsZero() {
+		lastItem = feed.LastItemAt
+	}
+
+	_, err := d.db.Exec(`
+		UPDATE feeds SET
+			etag = ?,
+			last_modified = ?,
+			last_polled_at = ?,
+			last_item_at = COALESCE(?, last_item_at),
+			consecutive_errors = ?
+		WHERE url = ?
+	`, feed.ETag, feed.LastModified, feed.LastPolledAt, lastItem, feed.ConsecutiveErrors, feed.URL)
+	return err
+}
+
+type SiteTemplate struct {
+	Host      string
+	Hash      uint64
+	Text      string
+	PageCount int
+	HostPages int
+}
+
+// SiteTemplates are all the templates learned for one host.
+type SiteTemplates struct {
+	Host      string
+	Templates []SiteTemplate
+}
+
+// SaveSiteTemplates replaces the stored templates of a host.
+func (d *Database) SaveSiteTemplates(host string, templates []SiteTemplate) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveSiteTemplates(tx, host, templates); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveSiteTemplates(tx *sql.Tx, host string, templates []SiteTemplate) error {
+	if _, err := tx.Exec("DELETE FROM site_templates WHERE host = ?", host); err != nil {
+		return fmt.Errorf("failed to clear templates for %s: %w", host, err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO site_templates (host, block_hash, block_text, page_count, host_pages, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range templates {
+		if _, err := stmt.Exec(host, int64(t.Hash), t.Text, t.PageCount, t.HostPages); err != nil {
+			return fmt.Errorf("failed to save template for %s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+func (d *Database) LoadSiteTemplates() ([]SiteTemplate, error) {
+	rows, err := d.db.Query("SELECT host, block_hash, block_text, page_count, host_pages FROM site_templates")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []SiteTemplate
+	for rows.Next() {
+		var t SiteTemplate
+		var hash int64
+		if err := rows.Scan(&t.Host, &hash, &t.Text, &t.PageCount, &t.HostPages); err != nil {
+			return nil, err
+		}
+		t.Hash = uint64(hash)
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// StripSiteTemplates removes the host's stored template blocks from pages
+// that were saved before those templates were learned. Each rewritten page
+// gets a new content hash and its previous content archived, like a
+// changed re-crawl. Returns the number of pages rewritten.
+func (d *Database) StripSiteTemplates(host string) (int, error) {
+	var blocks []string
+	rows, err := d.db.Query("SELECT block_text FROM site_templates WHERE host = ?", host)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		blocks = append(blocks, text)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+
+	type storedPage struct {
+		id int64
+		Page
+	}
+
+	var pages []storedPage
+	rows, err = d.db.Query(
+		`SELECT id, url, COALESCE(title, ''), COALESCE(description, ''), content FROM pages WHERE url LIKE ? ESCAPE '\' OR url LIKE ? ESCAPE '\'`,
+		"http://"+escapeLike(host)+"%", "https://"+escapeLike(host)+"%",
+	)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var page storedPage
+		var content sql.NullString
+		if err := rows.Scan(&page.id, &page.URL, &page.Title, &page.Description, &content); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if u, err := url.Parse(page.URL); err != nil || u.Host != host {
+			continue
+		}
+		page.Content = content.String
+		pages = append(pages, page)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE pages SET content = ?, content_hash = ? WHERE id = ?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	templates := make(map[string]bool, len(blocks))
+	for _, block := range blocks {
+		templates[block] = true
+	}
+	isTemplate := func(block string) bool { return templates[block] }
+
+	updated := 0
+	for _, page := range pages {
+		stripped := boilerplate.RemoveBlocks(page.Content, isTemplate)
+		if stripped == page.Content {
+			continue
+		}
+
+		page.Content = stripped
+		if err := d.archivePage(tx, &page.Page); err != nil {
+			return 0, err
+		}
+		if _, err := stmt.Exec(stripped, contentHash(stripped), page.id); err != nil {
+			return 0, fmt.Errorf("failed to strip templates from page %d: %w", page.id, err)
+		}
+		if d.eventLog {
+			if err := recordPageEvents(tx, ChangeUpdated, "id = ?", page.id); err != nil {
+				return 0, err
+			}
+		}
+		updated++
+	}
+
+	return updated, tx.Commit()
+}
+
+type Link struct {
+	FromURL    string
+	ToURL      string
+	AnchorText string
+	Title      string
+	Rel        string
+	Region     string
+	IsInternal bool
+}
+
+// SaveOutlinks stores links with their anchor text and attributes. When a
+// page links to the same target more than once, the first non-empty anchor
+// text is kept.
+func (d *Database) SaveOutlinks(fromURL string, links []Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveOutlinks(tx, fromURL, links); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveOutlinks(tx *sql.Tx, fromURL string, links []Link) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO links (from_url, to_url, anchor_text, title, rel, region, is_internal)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(from_url, to_url) DO UPDATE SET
+			anchor_text = CASE WHEN COALESCE(links.anchor_text, '') = '' THEN excluded.anchor_text ELSE links.anchor_text END,
+			title = CASE WHEN COALESCE(links.title, '') = '' THEN excluded.title ELSE links.title END,
+			rel = CASE WHEN COALESCE(links.rel, '') = '' THEN excluded.rel ELSE links.rel END,
+			region = CASE WHEN COALESCE(links.region, '') = '' THEN excluded.region ELSE links.region END,
+			is_internal = excluded.is_internal
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, link := range links {
+		if _, err := stmt.Exec(fromURL, link.ToURL, link.AnchorText, link.Title, link.Rel, link.Region, link.IsInternal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Database) GetInlinks(toURL string) ([]Link, error) {
+	rows, err := d.db.Query(`
+		SELECT from_url, to_url, COALESCE(anchor_text, ''), COALESCE(title, ''), COALESCE(rel, ''), COALESCE(region, ''), COALESCE(is_internal, 0)
+		FROM links WHERE to_url = ?
+	`, toURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		var link Link
+		if err := rows.Scan(&link.FromURL, &link.ToURL, &link.AnchorText, &link.Title, &link.Rel, &link.Region, &link.IsInternal); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+type CrawlAttempt struct {
+	URL         string
+	Host        string
+	AttemptedAt time.Time
+	Outcome     string
+	StatusCode  int
+	Error       string
+	LatencyMs   int64
+	Bytes       int64
+	FetchMode   string // "http", "browser", or empty when nothing was fetched
+}
+
+func (d *Database) SaveCrawlAttempt(attempt *CrawlAttempt) error {
+	return saveCrawlAttempt(d.db, attempt)
+}
+
+func saveCrawlAttempt(ex execer, attempt *CrawlAttempt) error {
+	_, err := ex.Exec(`
+		INSERT INTO crawl_attempts (url, host, attempted_at, outcome, status_code, error, latency_ms, bytes, fetch_mode)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		attempt.URL,
+		attempt.Host,
+		attempt.AttemptedAt.UTC(),
+		attempt.Outcome,
+		attempt.StatusCode,
+		attempt.Error,
+		attempt.LatencyMs,
+		attempt.Bytes,
+		attempt.FetchMode,
+	)
+	return err
+}
+
+type AttemptSummary struct {
+	Host         string // empty in per-outcome totals
+	Outcome      string
+	Count        int
+	AvgLatencyMs float64
+	Bytes        int64
+	Example      string // one URL or error message of the group
+}
+
+// GetAttemptTotals counts attempts per outcome in [from, to). An empty
+// host covers all hosts.
+func (d *Database) GetAttemptTotals(from, to time.Time, host string) ([]AttemptSummary, error) {
+	rows, err := d.db.Query(`
+		SELECT outcome, COUNT(*), COALESCE(AVG(latency_ms), 0), COALESCE(SUM(bytes), 0), COALESCE(MAX(error), '')
+		FROM crawl_attempts
+		WHERE attempted_at >= ? AND attempted_at < ? AND (? = '' OR host = ?)
+		GROUP BY outcome
+		ORDER BY COUNT(*) DESC
+	`, from.UTC(), to.UTC(), host, host)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []AttemptSummary
+	for rows.Next() {
+		s := AttemptSummary{Host: host}
+		if err := rows.Scan(&s.Outcome, &s.Count, &s.AvgLatencyMs, &s.Bytes, &s.Example); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// GetFailuresByHost groups unsuccessful attempts in [from, to) by host and
+// outcome, largest groups first, with one example URL each.
+func (d *Database) GetFailuresByHost(from, to time.Time, limit int) ([]AttemptSummary, error) {
+	rows, err := d.db.Query(`
+		SELECT host, outcome, COUNT(*), COALESCE(AVG(latency_ms), 0), COALESCE(SUM(bytes), 0), MIN(url)
+		FROM crawl_attempts
+		WHERE attempted_at >= ? AND attempted_at < ? AND outcome != 'crawled'
+		GROUP BY host, outcome
+		ORDER BY COUNT(*) DESC
+		LIMIT ?
+	`, from.UTC(), to.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []AttemptSummary
+	for rows.Next() {
+		var s AttemptSummary
+		if err := rows.Scan(&s.Host, &s.Outcome, &s.Count, &s.AvgLatencyMs, &s.Bytes, &s.Example); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+type HostProbe struct {
+	Host        string
+	StatusCode  int
+	FinalURL    string
+	Title       string
+	Fingerprint uint64
+	Words       int
+	ProbedAt    time.Time
+}
+
+func (d *Database) SaveHostProbe(probe *HostProbe) error {
+	return saveHostProbe(d.db, probe)
+}
+
+func saveHostProbe(e execer, probe *HostProbe) error {
+	_, err := e.Exec(`
+		INSERT INTO host_probes (host, status_code, final_url, title, fingerprint, words, probed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(host) DO UPDATE SET
+			status_code = excluded.status_code,
+			final_url = excluded.final_url,
+			title = excluded.title,
+			fingerprint = excluded.fingerprint,
+			words = excluded.words,
+			probed_at = excluded.probed_at
+	`, probe.Host, probe.StatusCode, probe.FinalURL, probe.Title, int64(probe.Fingerprint), probe.Words, probe.ProbedAt)
+	return err
+}
+
+// LoadHostProbes returns probes taken after the cutoff; older ones, and ones
+// stored before word counts were kept, are re-probed.
+func (d *Database) LoadHostProbes(probedAfter time.Time) ([]HostProbe, error) {
+	rows, err := d.db.Query(`
+		SELECT host, status_code, COALESCE(final_url, ''), COALESCE(title, ''), fingerprint, words, probed_at
+		FROM host_probes WHERE probed_at > ? AND words IS NOT NULL
+	`, probedAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var probes []HostProbe
+	for rows.Next() {
+		var p HostProbe
+		var fingerprint int64
+		if err := rows.Scan(&p.Host, &p.StatusCode, &p.FinalURL, &p.Title, &fingerprint, &p.Words, &p.ProbedAt); err != nil {
+			return nil, err
+		}
+		p.Fingerprint = uint64(fingerprint)
+		probes = append(probes, p)
+	}
+	return probes, rows.Err()
+}
+
+type QuarantinedURL struct {
+	URL     string
+	Host    string
+	Reason  string
+	FoundOn string
+}
+
+func (d *Database) QuarantineURLs(urls []QuarantinedURL) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := quarantineURLs(tx, urls); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func quarantineURLs(tx *sql.Tx, urls []QuarantinedURL) error {
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO quarantined_urls (url, host, reason, found_on) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, u := range urls {
+		if _, err := stmt.Exec(u.URL, u.Host, u.Reason, u.FoundOn); err != nil {
+			return fmt.Errorf("failed to quarantine %s: %w", u.URL, err)
+		}
+	}
+	return nil
+}
+
+type QuarantinedHost struct {
+	Host         string
+	Reason       string
+	PagesCrawled int
+	UniquePages  int
+}
+
+func (d *Database) QuarantineHost(host *QuarantinedHost) error {
+	return quarantineHost(d.db, host)
+}
+
+func quarantineHost(e execer, host *QuarantinedHost) error {
+	_, err := e.Exec(`
+		INSERT INTO quarantined_hosts (host, reason, pages_crawled, unique_pages)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(host) DO UPDATE SET
+			reason = excluded.reason,
+			pages_crawled = excluded.pages_crawled,
+			unique_pages = excluded.unique_pages
+	`, host.Host, host.Reason, host.PagesCrawled, host.UniquePages)
+	return err
+}
+
+func (d *Database) LoadQuarantinedHosts() ([]QuarantinedHost, error) {
+	rows, err := d.db.Query("SELECT host, reason, COALESCE(pages_crawled, 0), COALESCE(unique_pages, 0) FROM quarantined_hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hosts []QuarantinedHost
+	for rows.Next() {
+		var h QuarantinedHost
+		if err := rows.Scan(&h.Host, &h.Reason, &h.PagesCrawled, &h.UniquePages); err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts, rows.Err()
+}
+
+type QuarantineSummary struct {
+	Host    string
+	Reason  string
+	Count   int
+	Example string
+}
+
+// GetQuarantineReport groups quarantined URLs by host and reason, largest
+// groups first, with one example URL each.
+func (d *Database) GetQuarantineReport(limit int) ([]QuarantineSummary, error) {
+	rows, err := d.db.Query(`
+		SELECT host, reason, COUNT(*), MIN(url)
+		FROM quarantined_urls
+		GROUP BY host, reason
+		ORDER BY COUNT(*) DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []QuarantineSummary
+	for rows.Next() {
+		var s QuarantineSummary
+		if err := rows.Scan(&s.Host, &s.Reason, &s.Count, &s.Example); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+type Seed struct {
+	URL     string
+	AddedAt time.Time
+}
+
+// AddSeed reports whether the seed was new.
+func (d *Database) AddSeed(url string) (bool, error) {
+	result, err := d.db.Exec("INSERT OR IGNORE INTO seeds (url, added_at) VALUES (?, ?)", url, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// RemoveSeed reports whether the seed existed.
+func (d *Database) RemoveSeed(url string) (bool, error) {
+	result, err := d.db.Exec("DELETE FROM seeds WHERE url = ?", url)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (d *Database) ListSeeds() ([]Seed, error) {
+	rows, err := d.db.Query("SELECT url, added_at FROM seeds ORDER BY added_at, url")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seeds []Seed
+	for rows.Next() {
+		var seed Seed
+		if err := rows.Scan(&seed.URL, &seed.AddedAt); err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, seed)
+	}
+	return seeds, rows.Err()
+}
+
+type CrawlStats struct {
+	Pages            int
+	ContentTypes     map[string]int
+	ErrorClasses     map[string]int
+	Hosts            int
+	Links            int
+	Feeds            int
+	Seeds            int
+	QuarantinedURLs  int
+	QuarantinedHosts int
+	PageVersions     int
+	LanguageClusters int
+	DatedPages       int
+	FirstCrawledAt   time.Time
+	LastCrawledAt    time.Time
+}
+
+// hostCountQuery counts distinct hosts in SQL: the host (with port) is the
+// text between "://" and the first '/', '?' or '#' of the page URL.
+const hostCountQuery = `SELECT COUNT(DISTINCT substr(rest, 1, instr(rest, '/') - 1)) FROM (
+	SELECT replace(replace(substr(url, instr(url, '://') + 3), '?', '/'), '#', '/') || '/' AS rest
+	FROM pages
+)`
+
+func (d *Database) GetCrawlStats() (*CrawlStats, error) {
+	stats := &CrawlStats{
+		ContentTypes: make(map[string]int),
+		ErrorClasses: make(map[string]int),
+	}
+
+	counts := []struct {
+		query string
+		dest  *int
+	}{
+		{"SELECT COUNT(*) FROM pages", &stats.Pages},
+		{hostCountQuery, &stats.Hosts},
+		{"SELECT COUNT(*) FROM links", &stats.Links},
+		{"SELECT COUNT(*) FROM feeds", &stats.Feeds},
+		{"SELECT COUNT(*) FROM seeds", &stats.Seeds},
+		{"SELECT COUNT(*) FROM quarantined_urls", &stats.QuarantinedURLs},
+		{"SELECT COUNT(*) FROM quarantined_hosts", &stats.QuarantinedHosts},
+		{"SELECT COUNT(*) FROM page_versions", &stats.PageVersions},
+		{"SELECT COUNT(DISTINCT cluster) FROM language_variants", &stats.LanguageClusters},
+		{"SELECT COUNT(*) FROM page_dates WHERE published_at IS NOT NULL", &stats.DatedPages},
+	}
+	for _, c := range counts {
+		if err := d.db.QueryRow(c.query).Scan(c.dest); err != nil {
+			return nil, fmt.Errorf("failed to run %q: %w", c.query, err)
+		}
+	}
+
+	groups := []struct {
+		query string
+		dest  map[string]int
+	}{
+		{"SELECT COALESCE(content_type, 'text/html'), COUNT(*) FROM pages GROUP BY 1", stats.ContentTypes},
+		{"SELECT error_class, COUNT(*) FROM pages WHERE COALESCE(error_class, '') != '' GROUP BY 1", stats.ErrorClasses},
+	}
+	for _, g := range groups {
+		rows, err := d.db.Query(g.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run %q: %w", g.query, err)
+		}
+		for rows.Next() {
+			var key string
+			var count int
+			if err := rows.Scan(&key, &count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			g.dest[key] = count
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var first, last sql.NullString
+	if err := d.db.QueryRow("SELECT MIN(crawled_at), MAX(crawled_at) FROM pages").Scan(&first, &last); err != nil {
+		return nil, err
+	}
+	stats.FirstCrawledAt = parseSQLiteTime(first.String)
+	stats.LastCrawledAt = parseSQLiteTime(last.String)
+
+	return stats, nil
+}
+
+// parseSQLiteTime reads timestamps returned by aggregates, which the
+// driver hands back as text rather than time.Time.
+func parseSQLiteTime(value string) time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999-07:00", "2006-01-02T15:04:05.999999999-07:00", "2006-01-02 15:04:05", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike escapes the wildcards of a LIKE pattern matched with
+// ESCAPE '\', so a host like "my_site.com" only matches itself.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// PurgeHost deletes everything stored about a host: its pages with their
+// outlinks, metadata, dates, annotations, versions and language variants,
+// feeds, templates, probes, quarantine entries, crawl attempts and seen
+// URLs. Links from other hosts to it are kept. Returns the rows deleted
+// per table.
+func (d *Database) PurgeHost(host string) (map[string]int64, error) {
+	urlMatch := `(%[1]s = ? OR %[1]s = ? OR %[1]s LIKE ? ESCAPE '\' OR %[1]s LIKE ? ESCAPE '\')`
+	urlArgs := []interface{}{"http://" + host, "https://" + host, "http://" + escapeLike(host) + "/%", "https://" + escapeLike(host) + "/%"}
+
+	statements := []struct {
+		table string
+		query string
+		args  []interface{}
+	}{
+		{"page_metadata", "DELETE FROM page_metadata WHERE " + fmt.Sprintf(urlMatch, "url"), urlArgs},
+		{"page_dates", "DELETE FROM page_dates WHERE " + fmt.Sprintf(urlMatch, "url"), urlArgs},
+		{"page_annotations", "DELETE FROM page_annotations WHERE " + fmt.Sprintf(urlMatch, "url"), urlArgs},
+		{"links", "DELETE FROM links WHERE " + fmt.Sprintf(urlMatch, "from_url"), urlArgs},
+		{"pages", "DELETE FROM pages WHERE " + fmt.Sprintf(urlMatch, "url"), urlArgs},
+		{"page_versions", "DELETE FROM page_versions WHERE host = ?", []interface{}{host}},
+		{"feeds", "DELETE FROM feeds WHERE " + fmt.Sprintf(urlMatch, "site_url"), urlArgs},
+		{"seen_urls", "DELETE FROM seen_urls WHERE " + fmt.Sprintf(urlMatch, "url"), urlArgs},
+		{"language_variants", "DELETE FROM language_variants WHERE " + fmt.Sprintf(urlMatch, "url"), urlArgs},
+		{"site_templates", "DELETE FROM site_templates WHERE host = ?", []interface{}{host}},
+		{"host_probes", "DELETE FROM host_probes WHERE host = ?", []interface{}{host}},
+		{"quarantined_urls", "DELETE FROM quarantined_urls WHERE host = ?", []interface{}{host}},
+		{"quarantined_hosts", "DELETE FROM quarantined_hosts WHERE host = ?", []interface{}{host}},
+		{"crawl_attempts", "DELETE FROM crawl_attempts WHERE host = ?", []interface{}{host}},
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Consumers of the event log drop purged pages from their indexes
+	if d.eventLog {
+		if err := recordPageEvents(tx, ChangeDeleted, fmt.Sprintf(urlMatch, "url"), urlArgs...); err != nil {
+			return nil, err
+		}
+	}
+
+	deleted := make(map[string]int64)
+	for _, stmt := range statements {
+		result, err := tx.Exec(stmt.query, stmt.args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", stmt.table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		deleted[stmt.table] = n
+	}
+
+	return deleted, tx.Commit()
+}
+
 func (d *Database) GetPageCount() (int, error) {
 	var count int
 	err := d.db.QueryRow("SELECT COUNT(*) FROM pages").Scan(&count)
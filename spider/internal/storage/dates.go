@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PageDates is when a page was published and last modified, as far as its
+// markup, URL or headers tell. Sources are the parser's DateSource values;
+// zero times mean the date is unknown.
+type PageDates struct {
+	URL                 string
+	PublishedAt         time.Time
+	PublishedSource     string
+	PublishedConfidence float64
+	ModifiedAt          time.Time
+	ModifiedSource      string
+	ModifiedConfidence  float64
+}
+
+func (d *Database) SavePageDates(dates *PageDates) error {
+	return savePageDates(d.db, dates)
+}
+
+// savePageDates replaces a page's dates. A page that no longer carries
+// any loses its row.
+func savePageDates(ex execer, dates *PageDates) error {
+	if dates.PublishedAt.IsZero() && dates.ModifiedAt.IsZero() {
+		_, err := ex.Exec("DELETE FROM page_dates WHERE url = ?", dates.URL)
+		return err
+	}
+
+	query := `
+		INSERT INTO page_dates (url, published_at, published_source, published_confidence, modified_at, modified_source, modified_confidence, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(url) DO UPDATE SET
+			published_at = excluded.published_at,
+			published_source = excluded.published_source,
+			published_confidence = excluded.published_confidence,
+			modified_at = excluded.modified_at,
+			modified_source = excluded.modified_source,
+			modified_confidence = excluded.modified_confidence,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := ex.Exec(query,
+		dates.URL,
+		nullTime(dates.PublishedAt),
+		dates.PublishedSource,
+		dates.PublishedConfidence,
+		nullTime(dates.ModifiedAt),
+		dates.ModifiedSource,
+		dates.ModifiedConfidence,
+	)
+	return err
+}
+
+// GetPageDates returns nil if nothing dates the page.
+func (d *Database) GetPageDates(url string) (*PageDates, error) {
+	query := `
+		SELECT url, published_at, published_source, published_confidence, modified_at, modified_source, modified_confidence
+		FROM page_dates WHERE url = ?
+	`
+
+	var dates PageDates
+	var published, modified sql.NullTime
+	err := d.db.QueryRow(query, url).Scan(
+		&dates.URL,
+		&published,
+		&dates.PublishedSource,
+		&dates.PublishedConfidence,
+		&modified,
+		&dates.ModifiedSource,
+		&dates.ModifiedConfidence,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dates.PublishedAt = published.Time
+	dates.ModifiedAt = modified.Time
+	return &dates, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Change types of page events
+const (
+	ChangeNew     = "new"
+	ChangeUpdated = "updated" // content hash or error class changed
+	ChangeDeleted = "deleted" // removed with PurgeHost
+)
+
+// PageEvent is one entry of the page_events outbox. Seq only grows, so a
+// consumer can resume from the last one it handled.
+type PageEvent struct {
+	Seq         int64     `json:"seq"`
+	PageID      int64     `json:"page_id"`
+	URL         string    `json:"url"`
+	ContentHash string    `json:"content_hash,omitempty"`
+	ErrorClass  string    `json:"error_class,omitempty"`
+	Change      string    `json:"change"`
+	At          time.Time `json:"at"`
+}
+
+type EventCursor struct {
+	Sink      string
+	Seq       int64 // last event delivered to the sink
+	UpdatedAt time.Time
+}
+
+// SetEventLog turns the page_events outbox on or off. With it on, every
+// saved page that is new or changed, and every purged page, adds an event
+// in the same transaction.
+func (d *Database) SetEventLog(enabled bool) {
+	d.eventLog = enabled
+}
+
+// previousPage returns what savePage needs to tell new, changed and
+// unchanged pages apart.
+func previousPage(ex execer, url string) (exists bool, contentHash, errorClass string, err error) {
+	err = ex.QueryRow("SELECT COALESCE(content_hash, ''), COALESCE(error_class, '') FROM pages WHERE url = ?", url).
+		Scan(&contentHash, &errorClass)
+	if err == sql.ErrNoRows {
+		return false, "", "", nil
+	}
+	if err != nil {
+		return false, "", "", fmt.Errorf("failed to read previous page: %w", err)
+	}
+	return true, contentHash, errorClass, nil
+}
+
+// recordPageEvents adds an event for each page matching where.
+func recordPageEvents(ex execer, change, where string, args ...interface{}) error {
+	_, err := ex.Exec(`
+		INSERT INTO page_events (page_id, url, content_hash, error_class, change, at)
+		SELECT id, url, COALESCE(content_hash, ''), COALESCE(error_class, ''), ?, ? FROM pages WHERE `+where,
+		append([]interface{}{change, time.Now().UTC()}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to record page event: %w", err)
+	}
+	return nil
+}
+
+// ListPageEvents returns up to limit events after seq, oldest first.
+func (d *Database) ListPageEvents(after int64, limit int) ([]PageEvent, error) {
+	rows, err := d.db.Query(`
+		SELECT seq, page_id, url, content_hash, error_class, change, at
+		FROM page_events WHERE seq > ? ORDER BY seq LIMIT ?`, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []PageEvent
+	for rows.Next() {
+		var e PageEvent
+		if err := rows.Scan(&e.Seq, &e.PageID, &e.URL, &e.ContentHash, &e.ErrorClass, &e.Change, &e.At); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// LastEventSeq returns the newest event's seq, or 0 if there are none.
+func (d *Database) LastEventSeq() (int64, error) {
+	var seq sql.NullInt64
+	err := d.db.QueryRow("SELECT MAX(seq) FROM page_events").Scan(&seq)
+	return seq.Int64, err
+}
+
+// GetEventCursor returns 0 for sinks that never received an event.
+func (d *Database) GetEventCursor(sink string) (int64, error) {
+	var seq int64
+	err := d.db.QueryRow("SELECT seq FROM event_cursors WHERE sink = ?", sink).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// SetEventCursor records the last event delivered to a sink. Setting it
+// back replays everything after seq.
+func (d *Database) SetEventCursor(sink string, seq int64) error {
+	_, err := d.db.Exec(`
+		INSERT INTO event_cursors (sink, seq, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(sink) DO UPDATE SET seq = excluded.seq, updated_at = excluded.updated_at`,
+		sink, seq, time.Now())
+	return err
+}
+
+func (d *Database) ListEventCursors() ([]EventCursor, error) {
+	rows, err := d.db.Query("SELECT sink, seq, updated_at FROM event_cursors ORDER BY sink")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cursors []EventCursor
+	for rows.Next() {
+		var c EventCursor
+		if err := rows.Scan(&c.Sink, &c.Seq, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		cursors = append(cursors, c)
+	}
+	return cursors, rows.Err()
+}
+
+// PruneEvents deletes events up to seq delivered that are older than
+// before, keeping recent ones available for replay.
+func (d *Database) PruneEvents(delivered int64, before time.Time) (int64, error) {
+	result, err := d.db.Exec("DELETE FROM page_events WHERE seq <= ? AND at < ?", delivered, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune events: %w", err)
+	}
+	return result.RowsAffected()
+}
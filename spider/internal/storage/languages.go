@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LanguageVariant is one language version of a page, from hreflang.
+type LanguageVariant struct {
+	URL     string
+	Lang    string
+	Cluster string // set when read; computed on save
+}
+
+// SaveLanguageVariants records a set of pages as variants of each other.
+func (d *Database) SaveLanguageVariants(variants []LanguageVariant) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveLanguageVariants(tx, variants); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveLanguageVariants stores variants as one cluster. Clusters that
+// already hold any of the URLs are merged into it, since sites often list
+// only some of their variants on each page.
+func saveLanguageVariants(tx *sql.Tx, variants []LanguageVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	urls := make([]interface{}, len(variants))
+	cluster := variants[0].URL
+	for i, v := range variants {
+		urls[i] = v.URL
+		cluster = min(cluster, v.URL)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(urls)), ", ")
+	rows, err := tx.Query("SELECT DISTINCT cluster FROM language_variants WHERE url IN ("+placeholders+")", urls...)
+	if err != nil {
+		return fmt.Errorf("failed to look up language clusters: %w", err)
+	}
+	var existing []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			rows.Close()
+			return err
+		}
+		existing = append(existing, c)
+		cluster = min(cluster, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, c := range existing {
+		if c == cluster {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE language_variants SET cluster = ?, updated_at = ? WHERE cluster = ?", cluster, now, c); err != nil {
+			return fmt.Errorf("failed to merge language clusters: %w", err)
+		}
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO language_variants (url, cluster, lang, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET cluster = excluded.cluster, lang = excluded.lang, updated_at = excluded.updated_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, v := range variants {
+		if _, err := stmt.Exec(v.URL, cluster, v.Lang, now); err != nil {
+			return fmt.Errorf("failed to save language variant %s: %w", v.URL, err)
+		}
+	}
+	return nil
+}
+
+// GetLanguageVariants returns every known variant of a page, including
+// the page itself, or nil if it has none.
+func (d *Database) GetLanguageVariants(pageURL string) ([]LanguageVariant, error) {
+	rows, err := d.db.Query(`
+		SELECT url, lang, cluster FROM language_variants
+		WHERE cluster = (SELECT cluster FROM language_variants WHERE url = ?)
+		ORDER BY lang, url`, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []LanguageVariant
+	for rows.Next() {
+		var v LanguageVariant
+		if err := rows.Scan(&v.URL, &v.Lang, &v.Cluster); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// mergeTables lists what Merge copies from a shard. Rows already in the
+// target are kept unless newerColumn shows the shard's copy is newer.
+// seen_urls is per-run state and the event log is per database, so neither
+// is merged.
+var mergeTables = []struct {
+	table       string
+	key         []string // conflict target, or what identifies a row of an append-only table
+	newerColumn string
+	appendOnly  bool
+}{
+	{"pages", []string{"url"}, "crawled_at", false},
+	{"page_metadata", []string{"url"}, "updated_at", false},
+	{"page_dates", []string{"url"}, "updated_at", false},
+	{"page_annotations", []string{"url", "processor", "key"}, "updated_at", false},
+	{"links", []string{"from_url", "to_url"}, "", false},
+	{"feeds", []string{"url"}, "last_polled_at", false},
+	{"site_templates", []string{"host", "block_hash"}, "updated_at", false},
+	{"host_probes", []string{"host"}, "probed_at", false},
+	{"quarantined_urls", []string{"url"}, "", false},
+	{"quarantined_hosts", []string{"host"}, "", false},
+	{"seeds", []string{"url"}, "", false},
+	{"language_variants", []string{"url"}, "updated_at", false},
+	{"page_versions", []string{"url", "crawled_at", "content_hash"}, "", true},
+	{"crawl_attempts", []string{"host", "attempted_at", "url"}, "", true},
+}
+
+// Merge copies every table of the spider database at shardPath into this
+// one and returns the rows written per table. Page IDs are reassigned, so
+// indexers see merged pages as new. Merging the same shard twice doesn't
+// duplicate rows.
+func (d *Database) Merge(shardPath string) (map[string]int64, error) {
+	// Opening the shard brings its schema up to date
+	shardDB, err := NewDatabase(shardPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shard: %w", err)
+	}
+	shardDB.Close()
+
+	ctx := context.Background()
+
+	// ATTACH is per connection, so everything runs on one
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS shard", shardPath); err != nil {
+		return nil, fmt.Errorf("failed to attach shard: %w", err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE shard")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	merged := make(map[string]int64)
+	for _, t := range mergeTables {
+		rows, err := tx.QueryContext(ctx, "SELECT name FROM pragma_table_info(?) WHERE name != 'id'", t.table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read columns of %s: %w", t.table, err)
+		}
+		var columns []string
+		for rows.Next() {
+			var column string
+			if err := rows.Scan(&column); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			columns = append(columns, column)
+		}
+		rows.Close()
+
+		result, err := tx.ExecContext(ctx, mergeQuery(t.table, columns, t.key, t.newerColumn, t.appendOnly))
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", t.table, err)
+		}
+		merged[t.table], _ = result.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+	return merged, nil
+}
+
+func mergeQuery(table string, columns, key []string, newerColumn string, appendOnly bool) string {
+	list := strings.Join(columns, ", ")
+	query := fmt.Sprintf("INSERT INTO main.%s (%s) SELECT %s FROM shard.%s AS s", table, list, list, table)
+
+	switch {
+	case appendOnly:
+		// Skip rows an earlier merge of this shard already copied
+		var match []string
+		for _, column := range key {
+			match = append(match, fmt.Sprintf("m.%s = s.%s", column, column))
+		}
+		return query + fmt.Sprintf(" WHERE NOT EXISTS (SELECT 1 FROM main.%s m WHERE %s)", table, strings.Join(match, " AND "))
+
+	case newerColumn == "":
+		return query + fmt.Sprintf(" WHERE true ON CONFLICT(%s) DO NOTHING", strings.Join(key, ", "))
+
+	default:
+		var updates []string
+		for _, column := range columns {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+		return query + fmt.Sprintf(" WHERE true ON CONFLICT(%s) DO UPDATE SET %s WHERE excluded.%s > COALESCE(%s.%s, '')",
+			strings.Join(key, ", "), strings.Join(updates, ", "), newerColumn, table, newerColumn)
+	}
+}
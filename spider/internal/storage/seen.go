@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SeenStore is the on-disk seen set as crawl workers use it. Lookups go
+// through a read-only connection, which WAL lets read while the Writer
+// holds a transaction, and new URLs are queued on the Writer, so neither
+// waits on SQLite locks.
+type SeenStore struct {
+	reader *sql.DB
+	writer *Writer
+}
+
+func (d *Database) NewSeenStore(writer *Writer) (*SeenStore, error) {
+	reader, err := sql.Open("sqlite3", "file:"+d.path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read-only connection: %w", err)
+	}
+	return &SeenStore{reader: reader, writer: writer}, nil
+}
+
+// HasSeenURL reports whether a URL was queued this run or crawled before.
+// URLs still queued on the Writer aren't visible yet.
+func (s *SeenStore) HasSeenURL(url string) (bool, error) {
+	var found bool
+	err := s.reader.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM seen_urls WHERE url = ?) OR EXISTS(SELECT 1 FROM pages WHERE url = ?)
+	`, url, url).Scan(&found)
+	return found, err
+}
+
+func (s *SeenStore) AddSeenURLs(urls []string) error {
+	return s.writer.Write(&WriteRequest{SeenURLs: urls})
+}
+
+func (s *SeenStore) Close() error {
+	return s.reader.Close()
+}
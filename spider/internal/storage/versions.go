@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy bounds the prior versions kept of each page.
+type RetentionPolicy struct {
+	Keep   int           `yaml:"keep"`    // newest versions kept per page; 0 keeps no history
+	MaxAge time.Duration `yaml:"max_age"` // versions archived longer ago are dropped; 0 keeps them
+}
+
+// VersionRetention is the default policy plus per-host overrides. A host
+// entry also applies to its subdomains and replaces the default entirely.
+type VersionRetention struct {
+	RetentionPolicy `yaml:",inline"`
+	Hosts           map[string]RetentionPolicy `yaml:"hosts"`
+}
+
+// DefaultVersionRetention keeps the last 10 versions of every page.
+var DefaultVersionRetention = VersionRetention{RetentionPolicy: RetentionPolicy{Keep: 10}}
+
+func (r *VersionRetention) For(host string) RetentionPolicy {
+	host = strings.ToLower(host)
+	for {
+		if policy, exists := r.Hosts[host]; exists {
+			return policy
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			return r.RetentionPolicy
+		}
+		host = host[dot+1:]
+	}
+}
+
+// SetVersionRetention changes how much page history is kept. Call it
+// before writes start.
+func (d *Database) SetVersionRetention(retention VersionRetention) {
+	d.retention = retention
+}
+
+// PageVersion is an earlier state of a page. ID 0 stands for the current
+// row in pages.
+type PageVersion struct {
+	ID          int64
+	URL         string
+	Title       string
+	Description string
+	Content     string // only filled by GetPageVersion
+	ContentHash string
+	CrawledAt   time.Time
+	ArchivedAt  time.Time
+	Size        int // compressed bytes
+}
+
+// archivePage copies the stored row of a page into page_versions before
+// it is overwritten with a changed version, then applies retention.
+func (d *Database) archivePage(ex execer, page *Page) error {
+	host := ""
+	if u, err := url.Parse(page.URL); err == nil {
+		host = u.Host
+	}
+	policy := d.retention.For(host)
+	if policy.Keep <= 0 {
+		return nil
+	}
+
+	var old Page
+	err := ex.QueryRow("SELECT COALESCE(title, ''), COALESCE(description, ''), COALESCE(content, ''), COALESCE(content_hash, ''), crawled_at FROM pages WHERE url = ?", page.URL).
+		Scan(&old.Title, &old.Description, &old.Content, &old.ContentHash, &old.CrawledAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read previous version: %w", err)
+	}
+
+	// Re-crawls that found nothing new don't make a version
+	if old.Title == page.Title && old.Description == page.Description && old.Content == page.Content {
+		return nil
+	}
+
+	if old.ContentHash == "" {
+		old.ContentHash = contentHash(old.Content)
+	}
+	compressed, err := compress(old.Content)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = ex.Exec(`
+		INSERT INTO page_versions (url, host, title, description, content, content_hash, crawled_at, archived_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		page.URL, host, old.Title, old.Description, compressed, old.ContentHash, old.CrawledAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to archive version: %w", err)
+	}
+
+	return pruneVersions(ex, "url = ?", page.URL, policy, now)
+}
+
+// contentHash is how the crawler hashes page content.
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// pruneVersions drops versions beyond the policy among rows matching where.
+func pruneVersions(ex execer, where string, arg interface{}, policy RetentionPolicy, now time.Time) error {
+	query := fmt.Sprintf(`
+		DELETE FROM page_versions WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY url ORDER BY crawled_at DESC, id DESC) AS n
+				FROM page_versions WHERE %s
+			) WHERE n > ?
+		)`, where)
+	if _, err := ex.Exec(query, arg, policy.Keep); err != nil {
+		return fmt.Errorf("failed to prune versions: %w", err)
+	}
+
+	if policy.MaxAge > 0 {
+		query := fmt.Sprintf("DELETE FROM page_versions WHERE %s AND archived_at < ?", where)
+		if _, err := ex.Exec(query, arg, now.Add(-policy.MaxAge)); err != nil {
+			return fmt.Errorf("failed to prune versions: %w", err)
+		}
+	}
+	return nil
+}
+
+// PruneVersions applies the current retention to every host's history,
+// e.g. after the policy was tightened. Returns the versions deleted.
+func (d *Database) PruneVersions() (int64, error) {
+	rows, err := d.db.Query("SELECT DISTINCT host FROM page_versions")
+	if err != nil {
+		return 0, err
+	}
+	var hosts []string
+	for rows.Next() {
+		var host string
+		if err := rows.Scan(&host); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		hosts = append(hosts, host)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var before, after int64
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM page_versions").Scan(&before); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, host := range hosts {
+		if err := pruneVersions(d.db, "host = ?", host, d.retention.For(host), now); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM page_versions").Scan(&after); err != nil {
+		return 0, err
+	}
+	return before - after, nil
+}
+
+// ListPageVersions returns a page's archived versions, newest first,
+// without their content.
+func (d *Database) ListPageVersions(pageURL string) ([]PageVersion, error) {
+	rows, err := d.db.Query(`
+		SELECT id, url, title, description, content_hash, crawled_at, archived_at, LENGTH(content)
+		FROM page_versions WHERE url = ?
+		ORDER BY crawled_at DESC, id DESC`, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []PageVersion
+	for rows.Next() {
+		var v PageVersion
+		if err := rows.Scan(&v.ID, &v.URL, &v.Title, &v.Description, &v.ContentHash, &v.CrawledAt, &v.ArchivedAt, &v.Size); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetPageVersion returns an archived version with its content, or nil if
+// there is none with that ID.
+func (d *Database) GetPageVersion(id int64) (*PageVersion, error) {
+	var v PageVersion
+	var compressed []byte
+	err := d.db.QueryRow(`
+		SELECT id, url, title, description, content, content_hash, crawled_at, archived_at
+		FROM page_versions WHERE id = ?`, id).
+		Scan(&v.ID, &v.URL, &v.Title, &v.Description, &compressed, &v.ContentHash, &v.CrawledAt, &v.ArchivedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v.Size = len(compressed)
+	if v.Content, err = decompress(compressed); err != nil {
+		return nil, fmt.Errorf("failed to decompress version %d: %w", id, err)
+	}
+	return &v, nil
+}
+
+func compress(text string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(text)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	text, err := io.ReadAll(zr)
+	return string(text), err
+}
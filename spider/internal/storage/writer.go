@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrWriterClosed = errors.New("writer is closed")
+
+// WriteRequest is everything one crawl step persists. Requests are
+// grouped into batched transactions by the Writer.
+type WriteRequest struct {
+	URL         string // page the outlinks, feeds and quarantined URLs were found on
+	Page        *Page
+	Metadata    *PageMetadata
+	Dates       *PageDates
+	Outlinks    []Link
+	Feeds       []string
+	Quarantined []QuarantinedURL
+	Variants    []LanguageVariant
+	Annotations []PageAnnotation // nil leaves stored annotations alone; empty clears them
+	Attempt     *CrawlAttempt
+
+	SiteTemplates   *SiteTemplates // replaces the host's stored templates
+	QuarantinedHost *QuarantinedHost
+	Probe           *HostProbe
+	SeenURLs        []string
+}
+
+type WriterConfig struct {
+	BatchSize     int           // requests per transaction
+	FlushInterval time.Duration // max time a request waits for its batch
+	QueueSize     int           // requests buffered before Write blocks
+}
+
+type WriterStats struct {
+	Queued      int
+	Written     int64
+	Failed      int64
+	Batches     int64
+	Stalls      int64 // Writes that blocked because the queue was full
+	LastBatchMs int64
+}
+
+// Writer is the single goroutine that writes crawl results, so workers
+// never wait on SQLite locks. When it falls behind, the bounded queue
+// makes Write block, slowing the workers down.
+type Writer struct {
+	db       *Database
+	config   WriterConfig
+	requests chan *WriteRequest
+	done     chan struct{}
+	closed   bool
+	mu       sync.RWMutex
+
+	written     atomic.Int64
+	failed      atomic.Int64
+	batches     atomic.Int64
+	stalls      atomic.Int64
+	lastBatchMs atomic.Int64
+}
+
+func NewWriter(db *Database, config WriterConfig) *Writer {
+	if config.BatchSize == 0 {
+		config.BatchSize = 200
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.QueueSize == 0 {
+		config.QueueSize = 2000
+	}
+
+	w := &Writer{
+		db:       db,
+		config:   config,
+		requests: make(chan *WriteRequest, config.QueueSize),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a request, blocking while the queue is full.
+func (w *Writer) Write(req *WriteRequest) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	select {
+	case w.requests <- req:
+	default:
+		w.stalls.Add(1)
+		w.requests <- req
+	}
+	return nil
+}
+
+// Close flushes everything queued and stops the writer.
+func (w *Writer) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.requests)
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+func (w *Writer) Stats() WriterStats {
+	return WriterStats{
+		Queued:      len(w.requests),
+		Written:     w.written.Load(),
+		Failed:      w.failed.Load(),
+		Batches:     w.batches.Load(),
+		Stalls:      w.stalls.Load(),
+		LastBatchMs: w.lastBatchMs.Load(),
+	}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []*WriteRequest
+	for {
+		select {
+		case req, ok := <-w.requests:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, req)
+			if len(batch) >= w.config.BatchSize {
+				w.flush(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = nil
+			}
+		}
+	}
+}
+
+func (w *Writer) flush(batch []*WriteRequest) {
+	if len(batch) == 0 {
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		w.batches.Add(1)
+		w.lastBatchMs.Store(time.Since(start).Milliseconds())
+	}()
+
+	err := w.writeBatch(batch)
+	if err == nil {
+		w.written.Add(int64(len(batch)))
+		return
+	}
+	log.Printf("🔴 Warning: Batch of %d writes failed, retrying one by one: %v", len(batch), err)
+
+	// One bad request shouldn't cost the rest of the batch
+	for _, req := range batch {
+		if err := w.writeBatch([]*WriteRequest{req}); err != nil {
+			w.failed.Add(1)
+			log.Printf("🔴 Warning: Failed to write %s: %v", req.URL, err)
+			continue
+		}
+		w.written.Add(1)
+	}
+}
+
+func (w *Writer) writeBatch(batch []*WriteRequest) error {
+	tx, err := w.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, req := range batch {
+		if err := w.writeRequest(tx, req); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (w *Writer) writeRequest(tx *sql.Tx, req *WriteRequest) error {
+	if req.Page != nil {
+		if err := w.db.savePage(tx, req.Page); err != nil {
+			return err
+		}
+	}
+	if req.Metadata != nil {
+		if err := savePageMetadata(tx, req.Metadata); err != nil {
+			return err
+		}
+	}
+	if req.Dates != nil {
+		if err := savePageDates(tx, req.Dates); err != nil {
+			return err
+		}
+	}
+	if req.Annotations != nil {
+		if err := savePageAnnotations(tx, req.URL, req.Annotations); err != nil {
+			return err
+		}
+	}
+	if len(req.Feeds) > 0 {
+		if err := saveFeeds(tx, req.URL, req.Feeds); err != nil {
+			return err
+		}
+	}
+	if len(req.Outlinks) > 0 {
+		if err := saveOutlinks(tx, req.URL, req.Outlinks); err != nil {
+			return err
+		}
+	}
+	if len(req.Quarantined) > 0 {
+		if err := quarantineURLs(tx, req.Quarantined); err != nil {
+			return err
+		}
+	}
+	if len(req.Variants) > 0 {
+		if err := saveLanguageVariants(tx, req.Variants); err != nil {
+			return err
+		}
+	}
+	if req.Attempt != nil {
+		if err := saveCrawlAttempt(tx, req.Attempt); err != nil {
+			return err
+		}
+	}
+	if req.SiteTemplates != nil {
+		if err := saveSiteTemplates(tx, req.SiteTemplates.Host, req.SiteTemplates.Templates); err != nil {
+			return err
+		}
+	}
+	if req.QuarantinedHost != nil {
+		if err := quarantineHost(tx, req.QuarantinedHost); err != nil {
+			return err
+		}
+	}
+	if req.Probe != nil {
+		if err := saveHostProbe(tx, req.Probe); err != nil {
+			return err
+		}
+	}
+	if len(req.SeenURLs) > 0 {
+		if err := addSeenURLs(tx, req.SeenURLs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package textdiff computes line diffs between two versions of a page.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// Lines returns the shortest edit script turning a into b, line by line
+// (Myers' algorithm).
+func Lines(a, b string) []Edit {
+	return diff(splitLines(a), splitLines(b))
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func diff(a, b []string) []Edit {
+	// Common prefix and suffix are cheap to peel off and usually most of a page
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []Edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	return edits
+}
+
+// maxEditDistance bounds the search; beyond it the changed region is shown
+// as replaced wholesale instead of spending O(D²) memory on the trace.
+const maxEditDistance = 2000
+
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	total := n + m
+	if total == 0 {
+		return nil
+	}
+
+	// v[k+offset] is the furthest x reached on diagonal k. trace keeps the
+	// part of v each step reads (diagonals -d-1..d+1) to walk the path back.
+	offset := total + 1
+	v := make([]int, 2*total+3)
+	var trace [][]int
+
+	for d := 0; d <= total && d <= maxEditDistance; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset] // down: insert
+			} else {
+				x = v[k-1+offset] + 1 // right: delete
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	edits := make([]Edit, 0, total)
+	for _, line := range a {
+		edits = append(edits, Edit{Delete, line})
+	}
+	for _, line := range b {
+		edits = append(edits, Edit{Insert, line})
+	}
+	return edits
+}
+
+func backtrack(a, b []string, trace [][]int) []Edit {
+	x, y := len(a), len(b)
+	var reversed []Edit
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d][k+d+1] holds diagonal k
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d+1] < v[k+1+d+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d+1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Edit{Equal, a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				reversed = append(reversed, Edit{Insert, b[y]})
+			} else {
+				x--
+				reversed = append(reversed, Edit{Delete, a[x]})
+			}
+		}
+	}
+
+	edits := make([]Edit, len(reversed))
+	for i, edit := range reversed {
+		edits[len(reversed)-1-i] = edit
+	}
+	return edits
+}
+
+// Unified formats a diff of a and b like "diff -u", with context lines
+// around each change. Returns "" when they are equal.
+func Unified(a, b, fromName, toName string, context int) string {
+	edits := Lines(a, b)
+
+	changed := false
+	for _, edit := range edits {
+		if edit.Op != Equal {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// Line numbers in a and b at the start of each edit
+	aLine := make([]int, len(edits)+1)
+	bLine := make([]int, len(edits)+1)
+	for i, edit := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if edit.Op != Insert {
+			aLine[i+1]++
+		}
+		if edit.Op != Delete {
+			bLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			i++
+			continue
+		}
+
+		// Grow the hunk until two changes are more than 2*context lines apart
+		start := max(i-context, 0)
+		end := i
+		for end < len(edits) {
+			if edits[end].Op != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].Op == Equal {
+				run++
+			}
+			if run == len(edits) || run-end > 2*context {
+				end = min(end+context, len(edits))
+				break
+			}
+			end = run
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine[start], aLine[end]), hunkRange(bLine[start], bLine[end]))
+		for _, edit := range edits[start:end] {
+			switch edit.Op {
+			case Equal:
+				out.WriteString(" ")
+			case Delete:
+				out.WriteString("-")
+			case Insert:
+				out.WriteString("+")
+			}
+			out.WriteString(edit.Line)
+			out.WriteString("\n")
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func hunkRange(from, to int) string {
+	count := to - from
+	if count == 0 {
+		return fmt.Sprintf("%d,0", from)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", from+1)
+	}
+	return fmt.Sprintf("%d,%d", from+1, count)
+}
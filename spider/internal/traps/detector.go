@@ -0,0 +1,324 @@
+package traps
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ReasonURLTooLong        = "url_too_long"
+	ReasonPathTooDeep       = "path_too_deep"
+	ReasonRepeatedSegments  = "repeated_segments"
+	ReasonCalendar          = "calendar"
+	ReasonTemplateExplosion = "template_explosion"
+	ReasonLowContentYield   = "low_content_yield"
+)
+
+const (
+	// Only the first pages of a host are judged for yield, so memory stays bounded
+	maxJudgedPagesPerHost = 10000
+	maxTrackedTemplates   = 100000
+
+	calendarYearsAhead   = 2
+	calendarEarliestYear = 1990
+)
+
+type Config struct {
+	MaxURLLength      int     // longer URLs are rejected outright
+	MaxPathDepth      int     // max number of path segments
+	MaxSegmentRepeats int     // max times one segment may appear in a path
+	MaxTemplateValues int     // distinct URLs allowed per host path template
+	MinYieldPages     int     // pages of a host crawled before its yield is judged
+	MinUniqueRatio    float64 // share of those pages that must have distinct content
+}
+
+type Detector struct {
+	config    Config
+	templates map[string]*templateStats
+	hosts     map[string]*hostYield
+	mu        sync.Mutex
+}
+
+type templateStats struct {
+	values   map[uint64]bool
+	exploded bool
+}
+
+type hostYield struct {
+	pages       int
+	hashes      map[string]bool
+	quarantined string
+}
+
+func NewDetector(config Config) *Detector {
+	if config.MaxURLLength == 0 {
+		config.MaxURLLength = 512
+	}
+	if config.MaxPathDepth == 0 {
+		config.MaxPathDepth = 12
+	}
+	if config.MaxSegmentRepeats == 0 {
+		config.MaxSegmentRepeats = 3
+	}
+	if config.MaxTemplateValues == 0 {
+		config.MaxTemplateValues = 5000
+	}
+	if config.MinYieldPages == 0 {
+		config.MinYieldPages = 50
+	}
+	if config.MinUniqueRatio == 0 {
+		config.MinUniqueRatio = 0.2
+	}
+
+	return &Detector{
+		config:    config,
+		templates: make(map[string]*templateStats),
+		hosts:     make(map[string]*hostYield),
+	}
+}
+
+var (
+	numericSegment = regexp.MustCompile(`^\d+$`)
+	idSegment      = regexp.MustCompile(`^(?i)([0-9a-f]*\d[0-9a-f]*|[0-9a-f-]{36})$`)
+	dateSegment    = regexp.MustCompile(`^\d{4}-\d{1,2}(-\d{1,2})?$`)
+)
+
+// CheckURL returns the trap reason for a URL, or "" if it looks safe to
+// crawl. It also records the URL against its path template, so a template
+// that keeps producing new values eventually trips.
+func (d *Detector) CheckURL(rawURL string) string {
+	if len(rawURL) > d.config.MaxURLLength {
+		return ReasonURLTooLong
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if host, ok := d.hosts[u.Host]; ok && host.quarantined != "" {
+		return host.quarantined
+	}
+
+	segments := splitPath(u.Path)
+	if len(segments) > d.config.MaxPathDepth {
+		return ReasonPathTooDeep
+	}
+	if hasRepeatedSegments(segments, d.config.MaxSegmentRepeats) {
+		return ReasonRepeatedSegments
+	}
+	if isCalendarOutOfRange(segments) {
+		return ReasonCalendar
+	}
+
+	template := u.Host + templatePath(segments, u.Query())
+	stats, exists := d.templates[template]
+	if !exists {
+		if len(d.templates) >= maxTrackedTemplates {
+			return ""
+		}
+		stats = &templateStats{values: make(map[uint64]bool)}
+		d.templates[template] = stats
+	}
+	if stats.exploded {
+		return ReasonTemplateExplosion
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(u.Path + "?" + u.RawQuery))
+	stats.values[h.Sum64()] = true
+
+	// Templates with no variable part can't explode
+	if strings.Contains(template, "{") && len(stats.values) > d.config.MaxTemplateValues {
+		stats.exploded = true
+		stats.values = nil
+		return ReasonTemplateExplosion
+	}
+	return ""
+}
+
+// RecordContent tracks how many distinct pages a host yields. Returns true
+// when this page gets the host quarantined for producing mostly duplicates.
+func (d *Detector) RecordContent(host, contentHash string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	yield, exists := d.hosts[host]
+	if !exists {
+		yield = &hostYield{hashes: make(map[string]bool)}
+		d.hosts[host] = yield
+	}
+	if yield.quarantined != "" || yield.pages >= maxJudgedPagesPerHost {
+		return false
+	}
+
+	yield.pages++
+	yield.hashes[contentHash] = true
+
+	if yield.pages >= d.config.MinYieldPages {
+		ratio := float64(len(yield.hashes)) / float64(yield.pages)
+		if ratio < d.config.MinUniqueRatio {
+			yield.quarantined = ReasonLowContentYield
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Detector) IsHostQuarantined(host string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	yield, exists := d.hosts[host]
+	return exists && yield.quarantined != ""
+}
+
+func (d *Detector) HostYield(host string) (pages int, unique int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	yield, exists := d.hosts[host]
+	if !exists {
+		return 0, 0
+	}
+	return yield.pages, len(yield.hashes)
+}
+
+// QuarantineHost marks a host as a trap, e.g. when restoring state from a
+// previous crawl.
+func (d *Detector) QuarantineHost(host, reason string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	yield, exists := d.hosts[host]
+	if !exists {
+		yield = &hostYield{hashes: make(map[string]bool)}
+		d.hosts[host] = yield
+	}
+	yield.quarantined = reason
+}
+
+func splitPath(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// hasRepeatedSegments catches /a/a/a/a as well as cycles like /a/b/a/b
+// produced by relative links resolving against ever-deeper paths.
+func hasRepeatedSegments(segments []string, maxRepeats int) bool {
+	counts := make(map[string]int)
+	for _, segment := range segments {
+		counts[segment]++
+		if counts[segment] > maxRepeats {
+			return true
+		}
+	}
+
+	// A doubled single segment (/docs/docs) is common and harmless
+	for size := 2; size <= len(segments)/2; size++ {
+		for start := 0; start+2*size <= len(segments); start++ {
+			if equalSegments(segments[start:start+size], segments[start+size:start+2*size]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equalSegments(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isCalendarOutOfRange flags date paths far in the past or future, which
+// are what "next month" links on calendar widgets walk into.
+func isCalendarOutOfRange(segments []string) bool {
+	maxYear := time.Now().Year() + calendarYearsAhead
+
+	for _, segment := range segments {
+		year := 0
+		switch {
+		case dateSegment.MatchString(segment):
+			fmt.Sscanf(segment[:4], "%d", &year)
+		case len(segment) == 4 && numericSegment.MatchString(segment):
+			fmt.Sscanf(segment, "%d", &year)
+			// Plain four-digit segments are only dates next to a month-like segment
+			if !hasMonthSibling(segments, segment) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		if year > maxYear || (year < calendarEarliestYear && year >= 1000) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMonthSibling(segments []string, yearSegment string) bool {
+	for i, segment := range segments {
+		if segment != yearSegment || i+1 >= len(segments) {
+			continue
+		}
+		var month int
+		if _, err := fmt.Sscanf(segments[i+1], "%d", &month); err == nil && month >= 1 && month <= 12 && len(segments[i+1]) <= 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// templatePath replaces the variable-looking parts of a path with
+// placeholders and appends the sorted query keys.
+func templatePath(segments []string, query url.Values) string {
+	var sb strings.Builder
+	for _, segment := range segments {
+		sb.WriteString("/")
+		switch {
+		case numericSegment.MatchString(segment), dateSegment.MatchString(segment):
+			sb.WriteString("{n}")
+		case idSegment.MatchString(segment):
+			sb.WriteString("{id}")
+		default:
+			sb.WriteString(segment)
+		}
+	}
+
+	if len(query) > 0 {
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		sb.WriteString("?")
+		for i, key := range keys {
+			if i > 0 {
+				sb.WriteString("&")
+			}
+			sb.WriteString(key)
+			sb.WriteString("={v}")
+		}
+	}
+
+	return sb.String()
+}
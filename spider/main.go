@@ -1,105 +1,69 @@
 package main
 
 import (
-	"context"
-	"io"
+	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
-
-	"github.com/dangpham/deisearch/spider/internal/scheduler"
-	"github.com/dangpham/deisearch/spider/internal/storage"
+	"strings"
 )
 
-func main() {
-	dbPath := "/Users/dangpham/Dev/deisearch/spider.db"
-	logPath := "/Users/dangpham/Dev/deisearch/crawler.log"
-	seedURLs := []string{
-		"https://www.nature.com/",
-		"https://www.britannica.com/",
-		"https://www.seriouseats.com/",
-		"https://www.zen-habits.net/",
-		"https://www.goodreads.com/",
-		"https://www.pcgamer.com/",
-		"https://www.economist.com/",
-		"https://www.lonelyplanet.com/",
-		"https://www.metmuseum.org/",
-		"https://go.dev/blog",
-		"https://www.youtube.com",
-		"https://www.hellointerview.com",
-		"https://github.com",
-		"https://github.com/donnemartin/system-design-primer",
-		"https://news.ycombinator.com",
-		"https://www.reddit.com",
-		"https://stackoverflow.com",
-		"https://www.medium.com",
-		"https://www.wikipedia.org",
-		"https://www.bbc.com",
-		"https://www.cnn.com",
-		"https://www.nytimes.com",
-		"https://arxiv.org",
-		"https://pubmed.ncbi.nlm.nih.gov",
-		"https://www.nih.gov",
-		"https://archive.org",
-		"https://khanacademy.org",
-		"https://www.freecodecamp.org",
-		"https://dev.to",
-		"https://css-tricks.com",
-		"https://uxdesign.cc",
-		"https://www.producthunt.com",
-		"https://www.stackexchange.com",
-		"https://www.researchgate.net",
-		"https://www.opensource.org",
-	}
+const usage = `Usage: spider <command> [flags]
 
-	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("Failed to open log file: %v", err)
-	}
-	defer logFile.Close()
+Commands:
+  crawl                          Crawl from the configured and stored seeds (default)
+  seeds add <url>...             Add seed URLs
+  seeds list                     List seeds from the config file and the database
+  seeds remove <url>...          Remove seed URLs added with "seeds add"
+  stats                          Show what the database holds
+  purge-host <host>...           Delete everything stored about a host
+  report                         Summarize crawl outcomes and failing hosts
+  versions list <url>            List the stored versions of a page
+  versions diff <url> [a [b]]    Diff two versions (default: newest earlier vs current)
+  versions prune                 Apply the retention policy to all stored versions
+  events list [-from seq]        Print page events as NDJSON
+  events cursors                 Show how far each sink has received events
+  events rewind <sink> <seq>     Replay events after seq to a sink
+  events publish                 Deliver pending events to the sinks now
+  merge -o <db> <shard>...       Combine shard databases into one
 
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
-	log.SetOutput(multiWriter)
+Every command but merge accepts -config <file> (default spider.yaml or
+$SPIDER_CONFIG) and -db <path>. Run "spider <command> -h" for the
+command's flags.
+`
 
-	log.Println("Initializing database...")
-	db, err := storage.NewDatabase(dbPath)
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+func main() {
+	command := "crawl"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
-	defer db.Close()
-
-	log.Println("Creating scheduler...")
-	sched := scheduler.New(db, &scheduler.Config{
-		Workers:      40,
-		RateLimitSec: 0.05,
-		MaxPages:     500000,
-		UserAgent:    "DeiSearchBot/1.0",
-	})
 
-	log.Println("Adding seed URLs...")
-	for _, url := range seedURLs {
-		if err := sched.AddSeed(url); err != nil {
-			log.Printf("Failed to add seed %s: %v", url, err)
-		}
+	var err error
+	switch command {
+	case "crawl":
+		err = runCrawl(args)
+	case "seeds":
+		err = runSeeds(args)
+	case "stats":
+		err = runStats(args)
+	case "purge-host":
+		err = runPurgeHost(args)
+	case "report":
+		err = runReport(args)
+	case "versions":
+		err = runVersions(args)
+	case "events":
+		err = runEvents(args)
+	case "merge":
+		err = runMerge(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		log.Println("\nShutting down gracefully...")
-		cancel()
-	}()
-
-	log.Println("Starting crawler...")
-	if err := sched.Start(ctx); err != nil {
-		log.Fatalf("Scheduler error: %v", err)
+	if err != nil {
+		log.Fatalf("%s: %v", command, err)
 	}
-
-	log.Println("Crawling completed!")
-	log.Printf("Database saved to: %s", dbPath)
 }
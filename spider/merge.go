@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func runMerge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := fs.String("o", "", "spider database to merge into, created if missing")
+	fs.Parse(args)
+
+	if *output == "" || fs.NArg() == 0 {
+		return fmt.Errorf("usage: spider merge -o spider.db <shard.db>...")
+	}
+
+	db, err := storage.NewDatabase(*output)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	target, _ := filepath.Abs(*output)
+	for _, shardPath := range fs.Args() {
+		if path, _ := filepath.Abs(shardPath); path == target {
+			return fmt.Errorf("cannot merge %s into itself", shardPath)
+		}
+
+		merged, err := db.Merge(shardPath)
+		if err != nil {
+			return fmt.Errorf("failed to merge %s: %w", shardPath, err)
+		}
+
+		fmt.Printf("Merged %s:\n", shardPath)
+		for _, table := range sortedKeys(merged) {
+			if merged[table] > 0 {
+				fmt.Printf("  %-18s %d rows\n", table, merged[table])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runReport prints crawl outcomes and the worst failing hosts for a time
+// range, read from crawl_attempts:
+//
+//	spider report -since 24h
+//	spider report -since 2025-01-01 -until 2025-01-08 -host example.com
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	common := addCommonFlags(fs)
+	since := fs.String("since", "24h", "start of the range: a duration ago (24h) or a date (2006-01-02)")
+	until := fs.String("until", "", "end of the range, same formats; defaults to now")
+	host := fs.String("host", "", "only report this host")
+	top := fs.Int("top", 25, "number of host/outcome rows to show")
+	fs.Parse(args)
+
+	now := time.Now()
+	from, err := parseReportTime(*since, now)
+	if err != nil {
+		return fmt.Errorf("invalid -since: %w", err)
+	}
+	to := now
+	if *until != "" {
+		if to, err = parseReportTime(*until, now); err != nil {
+			return fmt.Errorf("invalid -until: %w", err)
+		}
+	}
+
+	_, database, err := common.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	totals, err := database.GetAttemptTotals(from, to, *host)
+	if err != nil {
+		return fmt.Errorf("failed to load outcome totals: %w", err)
+	}
+
+	fmt.Printf("Crawl attempts from %s to %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	if *host != "" {
+		fmt.Printf(" for %s", *host)
+	}
+	fmt.Println()
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OUTCOME\tCOUNT\tSHARE\tAVG LATENCY\tBYTES")
+	total := 0
+	for _, t := range totals {
+		total += t.Count
+	}
+	for _, t := range totals {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%.0fms\t%d\n", t.Outcome, t.Count, 100*float64(t.Count)/float64(total), t.AvgLatencyMs, t.Bytes)
+	}
+	fmt.Fprintf(w, "total\t%d\t\t\t\n", total)
+	w.Flush()
+
+	if *host != "" {
+		return nil
+	}
+
+	failures, err := database.GetFailuresByHost(from, to, *top)
+	if err != nil {
+		return fmt.Errorf("failed to load failures by host: %w", err)
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tOUTCOME\tCOUNT\tEXAMPLE")
+	for _, f := range failures {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", f.Host, f.Outcome, f.Count, f.Example)
+	}
+	return w.Flush()
+}
+
+func parseReportTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dangpham/deisearch/spider/internal/config"
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+func runSeeds(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected add, list or remove")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("seeds "+action, flag.ExitOnError)
+	common := addCommonFlags(fs)
+	fs.Parse(args[1:])
+
+	if (action == "add" || action == "remove") && fs.NArg() == 0 {
+		return fmt.Errorf("seeds %s needs at least one URL", action)
+	}
+
+	cfg, db, err := common.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "add":
+		for _, url := range fs.Args() {
+			if err := config.ValidateURL(url); err != nil {
+				return err
+			}
+			url = parser.NormalizeURLString(url)
+
+			added, err := db.AddSeed(url)
+			if err != nil {
+				return fmt.Errorf("failed to add %s: %w", url, err)
+			}
+			if added {
+				fmt.Printf("Added %s\n", url)
+			} else {
+				fmt.Printf("Already a seed: %s\n", url)
+			}
+		}
+
+	case "remove":
+		for _, url := range fs.Args() {
+			url = parser.NormalizeURLString(url)
+
+			removed, err := db.RemoveSeed(url)
+			if err != nil {
+				return fmt.Errorf("failed to remove %s: %w", url, err)
+			}
+			if removed {
+				fmt.Printf("Removed %s\n", url)
+			} else {
+				fmt.Printf("Not a stored seed: %s (seeds from the config file are removed by editing it)\n", url)
+			}
+		}
+
+	case "list":
+		stored, err := db.ListSeeds()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "URL\tSOURCE\tADDED")
+		for _, url := range cfg.Seeds {
+			fmt.Fprintf(w, "%s\tconfig\t\n", url)
+		}
+		for _, seed := range stored {
+			fmt.Fprintf(w, "%s\tdatabase\t%s\n", seed.URL, seed.AddedAt.Local().Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+
+	default:
+		return fmt.Errorf("unknown seeds action %q, expected add, list or remove", action)
+	}
+	return nil
+}
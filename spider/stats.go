@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	common := addCommonFlags(fs)
+	fs.Parse(args)
+
+	cfg, db, err := common.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stats, err := db.GetCrawlStats()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Database\t%s\n", cfg.DBPath)
+	fmt.Fprintf(w, "Pages\t%d\n", stats.Pages)
+	for _, key := range sortedKeys(stats.ContentTypes) {
+		fmt.Fprintf(w, "  %s\t%d\n", key, stats.ContentTypes[key])
+	}
+	if len(stats.ErrorClasses) > 0 {
+		fmt.Fprintf(w, "Error pages\t\n")
+		for _, key := range sortedKeys(stats.ErrorClasses) {
+			fmt.Fprintf(w, "  %s\t%d\n", key, stats.ErrorClasses[key])
+		}
+	}
+	fmt.Fprintf(w, "Hosts\t%d\n", stats.Hosts)
+	fmt.Fprintf(w, "Links\t%d\n", stats.Links)
+	fmt.Fprintf(w, "Feeds\t%d\n", stats.Feeds)
+	fmt.Fprintf(w, "Seeds\t%d in config, %d in database\n", len(cfg.Seeds), stats.Seeds)
+	fmt.Fprintf(w, "Page versions\t%d\n", stats.PageVersions)
+	fmt.Fprintf(w, "Language clusters\t%d\n", stats.LanguageClusters)
+	fmt.Fprintf(w, "Pages with a publish date\t%d\n", stats.DatedPages)
+	fmt.Fprintf(w, "Quarantined\t%d URLs, %d hosts\n", stats.QuarantinedURLs, stats.QuarantinedHosts)
+	if !stats.FirstCrawledAt.IsZero() {
+		fmt.Fprintf(w, "Crawled\t%s to %s\n", stats.FirstCrawledAt.Local().Format(time.DateTime), stats.LastCrawledAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
+
+func runPurgeHost(args []string) error {
+	fs := flag.NewFlagSet("purge-host", flag.ExitOnError)
+	common := addCommonFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("expected at least one host")
+	}
+
+	_, db, err := common.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, host := range fs.Args() {
+		deleted, err := db.PurgeHost(host)
+		if err != nil {
+			return fmt.Errorf("failed to purge %s: %w", host, err)
+		}
+
+		fmt.Printf("Purged %s:\n", host)
+		for _, table := range sortedKeys(deleted) {
+			if deleted[table] > 0 {
+				fmt.Printf("  %-18s %d rows\n", table, deleted[table])
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
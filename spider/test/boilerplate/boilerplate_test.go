@@ -0,0 +1,106 @@
+package boilerplate_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/boilerplate"
+)
+
+const footer = "Copyright 2024 Example Media Group. All rights reserved. Terms of use and privacy policy apply."
+
+func TestDetectorLearnsRepeatedBlocks(t *testing.T) {
+	d := boilerplate.NewDetector(5, 0.5)
+
+	for i := 0; i < 10; i++ {
+		body := fmt.Sprintf("Article number %d talks about something completely different from the others.", i)
+		d.Observe("example.com", []string{body, footer})
+	}
+
+	templates := d.Templates("example.com")
+	if len(templates) != 1 {
+		t.Fatalf("Expected 1 template, got %d", len(templates))
+	}
+	if templates[0].Text != footer {
+		t.Errorf("Expected footer as template, got %q", templates[0].Text)
+	}
+	t.Logf("Learned template seen on %d/%d pages", templates[0].PageCount, templates[0].HostPages)
+
+	content := "Article number 11 is fresh content worth indexing.\n" + footer
+	stripped := d.Strip("example.com", content)
+	if strings.Contains(stripped, "All rights reserved") {
+		t.Errorf("Footer should be stripped, got %q", stripped)
+	}
+	if !strings.Contains(stripped, "fresh content") {
+		t.Errorf("Body text should survive stripping, got %q", stripped)
+	}
+
+	if other := d.Strip("other.com", content); other != content {
+		t.Error("Templates must not leak across hosts")
+	}
+}
+
+func TestDetectorWaitsForMinPages(t *testing.T) {
+	d := boilerplate.NewDetector(10, 0.5)
+
+	for i := 0; i < 3; i++ {
+		d.Observe("example.com", []string{footer})
+	}
+
+	if stripped := d.Strip("example.com", footer); stripped != footer {
+		t.Error("Nothing should be stripped before MinPages pages are observed")
+	}
+
+	hosts := d.ChangedHosts()
+	if len(hosts) != 0 {
+		t.Errorf("Expected no changed hosts yet, got %v", hosts)
+	}
+}
+
+func TestDetectorLoad(t *testing.T) {
+	d := boilerplate.NewDetector(5, 0.5)
+	d.Load([]boilerplate.Template{{
+		Host:      "example.com",
+		Hash:      boilerplate.HashBlock(footer),
+		Text:      footer,
+		PageCount: 20,
+		HostPages: 20,
+	}})
+
+	stripped := d.Strip("example.com", "Some body text.\n"+footer)
+	if stripped != "Some body text." {
+		t.Errorf("Loaded template should be stripped, got %q", stripped)
+	}
+}
+
+func TestStripRemovesWholeBlocksOnly(t *testing.T) {
+	d := boilerplate.NewDetector(5, 0.5)
+	d.Load([]boilerplate.Template{{
+		Host:      "example.com",
+		Hash:      boilerplate.HashBlock(footer),
+		Text:      footer,
+		PageCount: 20,
+		HostPages: 20,
+	}})
+
+	quoted := "Our lawyers wrote: " + footer + " That line is on every page."
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"template line", "Body.\n" + footer + "\nMore body.", "Body.\nMore body."},
+		{"template inside a paragraph", quoted, quoted},
+		{"template as part of a line", "Intro\n" + footer + " Extra words.", "Intro\n" + footer + " Extra words."},
+		{"no template", "Body only.", "Body only."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if stripped := d.Strip("example.com", tt.content); stripped != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stripped)
+			}
+		})
+	}
+}
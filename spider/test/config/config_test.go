@@ -0,0 +1,145 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/config"
+	"github.com/dangpham/deisearch/spider/internal/scope"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFileAndEnvOverrides(t *testing.T) {
+	path := writeConfig(t, `
+db_path: /data/spider.db
+workers: 8
+feed_poll_interval: 10m
+scope:
+  deny_domains: [facebook.com]
+seeds:
+  - https://example.com
+`)
+	t.Setenv("SPIDER_WORKERS", "16")
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	t.Logf("db=%s workers=%d feeds=%v seeds=%v", cfg.DBPath, cfg.Workers, cfg.FeedPollInterval, cfg.Seeds)
+
+	if cfg.DBPath != "/data/spider.db" {
+		t.Errorf("Expected db_path from file, got %q", cfg.DBPath)
+	}
+	if cfg.Workers != 16 {
+		t.Errorf("Expected SPIDER_WORKERS to override file, got %d", cfg.Workers)
+	}
+	if cfg.FeedPollInterval != 10*time.Minute {
+		t.Errorf("Expected 10m feed interval, got %v", cfg.FeedPollInterval)
+	}
+	if cfg.UserAgent != config.Default().UserAgent {
+		t.Errorf("Expected default user agent, got %q", cfg.UserAgent)
+	}
+	if len(cfg.Seeds) != 1 || len(cfg.Scope.DenyDomains) != 1 {
+		t.Errorf("Expected one seed and one deny domain, got %v / %v", cfg.Seeds, cfg.Scope.DenyDomains)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+}
+
+func TestLoadBareDuration(t *testing.T) {
+	cfg, err := config.Load(writeConfig(t, "feed_poll_interval: 90\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	t.Logf("feed_poll_interval: 90 -> %v", cfg.FeedPollInterval)
+	if cfg.FeedPollInterval != 90*time.Second {
+		t.Errorf("Expected 90s feed interval, got %v", cfg.FeedPollInterval)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("SPIDER_CONFIG", "")
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Expected defaults without spider.yaml, got %v", err)
+	}
+	t.Logf("Defaults: db=%s workers=%d", cfg.DBPath, cfg.Workers)
+	if cfg.FeedPollInterval != 0 {
+		t.Errorf("Feed polling keeps the crawl running and must be opt-in, got %v", cfg.FeedPollInterval)
+	}
+
+	if _, err := config.Load("missing.yaml"); err == nil {
+		t.Error("Expected an error for an explicit missing config file")
+	}
+}
+
+func TestValidateReportsEverything(t *testing.T) {
+	cfg := config.Default()
+	cfg.Workers = 0
+	cfg.RateLimitSec = -1
+	cfg.Seeds = []string{"ftp://example.com"}
+	cfg.Scope.DenyPatterns = []string{"("}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	t.Logf("Validation errors:\n%v", err)
+
+	for _, want := range []string{"workers", "rate_limit_sec", "ftp://example.com", "scope"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error mentioning %q", want)
+		}
+	}
+}
+
+func TestScopeRules(t *testing.T) {
+	rules, err := scope.Compile(scope.Config{
+		AllowDomains: []string{"example.com"},
+		DenyDomains:  []string{"ads.example.com"},
+		DenyPatterns: []string{`/login`},
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/docs", true},
+		{"https://blog.example.com/post", true},
+		{"https://ads.example.com/banner", false},
+		{"https://example.com/login?next=/", false},
+		{"https://notexample.com/", false},
+		{"https://other.org/", false},
+	}
+
+	for _, tt := range tests {
+		got := rules.Allows(tt.url)
+		t.Logf("%-35s allowed=%v", tt.url, got)
+		if got != tt.want {
+			t.Errorf("Allows(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	var none *scope.Rules
+	if !none.Allows("https://anything.net/") {
+		t.Error("Expected nil rules to allow everything")
+	}
+}
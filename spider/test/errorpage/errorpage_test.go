@@ -0,0 +1,117 @@
+package errorpage_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/errorpage"
+)
+
+func TestClassify(t *testing.T) {
+	article := strings.Repeat("Search engines index pages by following links and reading their text. ", 60)
+
+	cases := []struct {
+		title   string
+		content string
+		class   string
+	}{
+		{"Page Not Found | Example", "Sorry, we couldn't find that page. Go back home.", errorpage.ClassNotFound},
+		{"Example", "Oops! The page you are looking for does not exist.", errorpage.ClassNotFound},
+		{"Access Denied", "You don't have permission to access this server.", errorpage.ClassAccessDenied},
+		{"Sign in - Example", "Email Password Forgot password?", errorpage.ClassLoginWall},
+		{"Example Forum", "Please log in to continue reading this thread.", errorpage.ClassLoginWall},
+		{"503 Service Unavailable", "The server is temporarily unable to service your request.", errorpage.ClassServerError},
+		{"404 - Page Not Found | Example", "Try the search box above.", errorpage.ClassNotFound},
+		{"How to fix 404 Not Found errors", article, ""},
+		{"Not Found (album)", "Tracklist and credits for the 2009 release.", ""},
+		{"Error 502 explained", "A short note on gateways and upstream servers.", ""},
+		{"Something went wrong: a history of the Apollo 13 mission", "Three astronauts, one oxygen tank and a long trip home.", ""},
+		{"Forbidden Planet (1956)", "A science fiction film starring Walter Pidgeon.", ""},
+		{"Log in to your garden: a beginner's guide", "Choosing the right wood for raised beds.", ""},
+		{"Cooking rice", "Rinse the rice, add water and simmer for eighteen minutes.", ""},
+	}
+
+	for _, c := range cases {
+		if got := errorpage.Classify(c.title, c.content); got != c.class {
+			t.Errorf("Classify(%q) = %q, expected %q", c.title, got, c.class)
+		}
+	}
+}
+
+func TestProbeMatchesSoft404(t *testing.T) {
+	notFound := "We looked everywhere but could not find %s. Try searching our archive or head back to the homepage for the latest stories and guides."
+
+	probed := strings.Replace(notFound, "%s", "/deisearch-probe-abc123", 1)
+	probe := &errorpage.Probe{
+		Host:        "example.com",
+		StatusCode:  200,
+		Fingerprint: errorpage.Fingerprint(probed),
+		Words:       errorpage.WordCount(probed),
+	}
+
+	// The not-found page echoes the requested path, so it is only near-identical
+	missing := strings.Replace(notFound, "%s", "/old-blog-post", 1)
+	if !probe.Matches("https://example.com/old-blog-post", missing) {
+		t.Error("Page matching the probe fingerprint should be a soft 404")
+	}
+
+	real := "Our guide to sourdough covers starters, hydration, folding and baking times for a crisp crust and open crumb."
+	if probe.Matches("https://example.com/sourdough", real) {
+		t.Error("Real content should not match the probe")
+	}
+
+	hard404 := &errorpage.Probe{Host: "example.com", StatusCode: 404}
+	if hard404.Matches("https://example.com/old-blog-post", missing) {
+		t.Error("Hosts returning real 404s never produce soft 404 matches")
+	}
+
+	// A host that redirects missing paths to its homepage
+	redirecting := &errorpage.Probe{
+		Host:        "example.com",
+		StatusCode:  200,
+		FinalURL:    "https://example.com/",
+		Fingerprint: errorpage.Fingerprint(real),
+		Words:       errorpage.WordCount(real),
+	}
+	if redirecting.Matches("https://example.com/", real) {
+		t.Error("The redirect target itself is not a soft 404")
+	}
+	if !redirecting.Matches("https://example.com/gone", real) {
+		t.Error("Other URLs landing on the redirect target are soft 404s")
+	}
+}
+
+func TestProbeWithFewWordsIsInconclusive(t *testing.T) {
+	// Near-empty pages all hash to about 0, so they'd match each other
+	empty := &errorpage.Probe{
+		Host:        "example.com",
+		StatusCode:  200,
+		Fingerprint: errorpage.Fingerprint(""),
+		Words:       errorpage.WordCount(""),
+	}
+	if empty.Matches("https://example.com/gallery", "Photos") {
+		t.Error("A near-empty probe should not match near-empty pages")
+	}
+
+	notFound := "We looked everywhere but could not find that page. Try searching our archive or head back home."
+	probe := &errorpage.Probe{
+		Host:        "example.com",
+		StatusCode:  200,
+		Fingerprint: errorpage.Fingerprint(notFound),
+		Words:       errorpage.WordCount(notFound),
+	}
+	if probe.Matches("https://example.com/app", "") {
+		t.Error("A page with no words should not match the probe")
+	}
+	if !probe.Matches("https://example.com/gone", notFound) {
+		t.Error("Pages with enough words should still be compared")
+	}
+}
+
+func TestProbePathIsRandom(t *testing.T) {
+	a, b := errorpage.ProbePath(), errorpage.ProbePath()
+	if a == b {
+		t.Errorf("Expected distinct probe paths, got %s twice", a)
+	}
+	t.Logf("Probe path: %s", a)
+}
@@ -0,0 +1,252 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/events"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func openDatabase(t *testing.T) *storage.Database {
+	t.Helper()
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "spider.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetEventLog(true)
+	return db
+}
+
+func savePage(t *testing.T, db *storage.Database, url, content string) {
+	t.Helper()
+	err := db.SavePage(&storage.Page{URL: url, Title: "Page", Content: content, StatusCode: 200, CrawledAt: time.Now(), ContentHash: "hash-" + content})
+	if err != nil {
+		t.Fatalf("Failed to save %s: %v", url, err)
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	db := openDatabase(t)
+
+	savePage(t, db, "https://a.com/1", "first")
+	savePage(t, db, "https://a.com/1", "first") // unchanged re-crawl
+	savePage(t, db, "https://a.com/1", "second")
+	savePage(t, db, "https://b.com/1", "other")
+	if _, err := db.PurgeHost("a.com"); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+
+	log, err := db.ListPageEvents(0, 100)
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+
+	var changes []string
+	for _, e := range log {
+		t.Logf("#%d page %d %-8s %s %s", e.Seq, e.PageID, e.Change, e.URL, e.ContentHash)
+		changes = append(changes, e.Change+" "+e.URL)
+	}
+	want := []string{"new https://a.com/1", "updated https://a.com/1", "new https://b.com/1", "deleted https://a.com/1"}
+	if strings.Join(changes, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Expected %v, got %v", want, changes)
+	}
+	if log[0].PageID == 0 || log[0].PageID != log[1].PageID || log[1].ContentHash != "hash-second" {
+		t.Errorf("Events should carry the page ID and current hash: %+v", log[:2])
+	}
+
+	// Without the event log, saves leave no events
+	db.SetEventLog(false)
+	savePage(t, db, "https://c.com/1", "quiet")
+	if last, _ := db.LastEventSeq(); last != log[len(log)-1].Seq {
+		t.Errorf("Expected no new events with the log off, last seq is %d", last)
+	}
+}
+
+// unixConsumer acknowledges every event with its seq.
+func unixConsumer(t *testing.T, path string, received *eventList) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", path, err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					var e storage.PageEvent
+					if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+						return
+					}
+					received.add(e)
+					fmt.Fprintf(conn, "%d\n", e.Seq)
+				}
+			}()
+		}
+	}()
+}
+
+type eventList struct {
+	events []storage.PageEvent
+	mu     sync.Mutex
+}
+
+func (l *eventList) add(e storage.PageEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+func (l *eventList) seqs() []int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	seqs := make([]int64, len(l.events))
+	for i, e := range l.events {
+		seqs[i] = e.Seq
+	}
+	return seqs
+}
+
+func TestPublisherSinks(t *testing.T) {
+	db := openDatabase(t)
+	for i := 1; i <= 3; i++ {
+		savePage(t, db, fmt.Sprintf("https://a.com/%d", i), "content")
+	}
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "events.ndjson")
+	socketPath := filepath.Join(dir, "events.sock")
+
+	var viaSocket, viaWebhook eventList
+	unixConsumer(t, socketPath, &viaSocket)
+
+	// The webhook is down for its first delivery
+	var webhookCalls int
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookCalls++
+		if webhookCalls == 1 {
+			http.Error(w, "deploying", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		decoder := json.NewDecoder(r.Body)
+		for {
+			var e storage.PageEvent
+			if err := decoder.Decode(&e); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("Bad webhook body: %v", err)
+				break
+			}
+			viaWebhook.add(e)
+		}
+	}))
+	defer webhook.Close()
+
+	config := events.Config{Sinks: []events.SinkConfig{
+		{Type: events.SinkFile, Path: filePath},
+		{Type: events.SinkUnix, Path: socketPath},
+		{Name: "indexer", Type: events.SinkWebhook, URL: webhook.URL},
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Config should be valid: %v", err)
+	}
+
+	publisher, err := events.NewPublisher(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create publisher: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := publisher.Publish(ctx); err == nil {
+		t.Error("Expected the webhook's 503 to be reported")
+	}
+	for _, s := range publisher.Stats() {
+		t.Logf("After first publish: %+v", s)
+	}
+	if cursor, _ := db.GetEventCursor("indexer"); cursor != 0 {
+		t.Errorf("Failed delivery must not move the cursor, got %d", cursor)
+	}
+
+	if err := publisher.Publish(ctx); err != nil {
+		t.Fatalf("Second publish failed: %v", err)
+	}
+	publisher.Close()
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read file sink: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	t.Logf("File sink:\n%s", data)
+	t.Logf("Socket got %v, webhook got %v", viaSocket.seqs(), viaWebhook.seqs())
+
+	if len(lines) != 3 || len(viaSocket.seqs()) != 3 || len(viaWebhook.seqs()) != 3 {
+		t.Errorf("Expected 3 events per sink, got file=%d socket=%d webhook=%d", len(lines), len(viaSocket.seqs()), len(viaWebhook.seqs()))
+	}
+	for _, sink := range config.Sinks {
+		if cursor, _ := db.GetEventCursor(sink.ID()); cursor != 3 {
+			t.Errorf("Expected %s cursor at 3, got %d", sink.ID(), cursor)
+		}
+	}
+
+	// Rewinding replays everything after the new cursor
+	if err := db.SetEventCursor("indexer", 1); err != nil {
+		t.Fatalf("Failed to rewind: %v", err)
+	}
+	replay, err := events.NewPublisher(db, events.Config{Sinks: config.Sinks[2:]})
+	if err != nil {
+		t.Fatalf("Failed to create publisher: %v", err)
+	}
+	if err := replay.Publish(ctx); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	replay.Close()
+
+	if got := fmt.Sprint(viaWebhook.seqs()); got != "[1 2 3 2 3]" {
+		t.Errorf("Expected seqs 2 and 3 to be replayed, webhook got %s", got)
+	}
+}
+
+func TestSinkConfigValidation(t *testing.T) {
+	config := events.Config{Sinks: []events.SinkConfig{
+		{Type: events.SinkFile},
+		{Type: events.SinkWebhook, URL: "ftp://example.com"},
+		{Type: "kafka"},
+		{Type: events.SinkUnix, Path: "/tmp/a.sock"},
+		{Type: events.SinkUnix, Path: "/tmp/a.sock"},
+	}}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	t.Logf("Errors:\n%v", err)
+	for _, want := range []string{"needs a path", "not an absolute http(s) URL", `got "kafka"`, "duplicate sink"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error containing %q", want)
+		}
+	}
+}
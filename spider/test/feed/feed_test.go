@@ -0,0 +1,74 @@
+package feed_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/feed"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+	<title>The Go Blog</title>
+	<item>
+		<title>Go 1.25 is released</title>
+		<link>https://go.dev/blog/go1.25</link>
+		<pubDate>Tue, 12 Aug 2025 10:00:00 +0000</pubDate>
+	</item>
+	<item>
+		<title>No link, only a permalink guid</title>
+		<guid>https://go.dev/blog/guid-only</guid>
+	</item>
+</channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>dev.to</title>
+	<entry>
+		<title>Designing a database</title>
+		<link rel="alternate" href="https://dev.to/ada/designing-a-database"/>
+		<link rel="edit" href="https://dev.to/api/articles/1"/>
+		<updated>2025-08-10T08:30:00Z</updated>
+	</entry>
+</feed>`
+
+func TestParseRSS(t *testing.T) {
+	items, err := feed.Parse(strings.NewReader(rssFeed))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0].URL != "https://go.dev/blog/go1.25" {
+		t.Errorf("Unexpected URL: %s", items[0].URL)
+	}
+	expected := time.Date(2025, 8, 12, 10, 0, 0, 0, time.UTC)
+	if !items[0].PublishedAt.Equal(expected) {
+		t.Errorf("Expected pubDate %v, got %v", expected, items[0].PublishedAt)
+	}
+	if items[1].URL != "https://go.dev/blog/guid-only" {
+		t.Errorf("Expected guid fallback, got %s", items[1].URL)
+	}
+}
+
+func TestParseAtom(t *testing.T) {
+	items, err := feed.Parse(strings.NewReader(atomFeed))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(items))
+	}
+	if items[0].URL != "https://dev.to/ada/designing-a-database" {
+		t.Errorf("Expected alternate link, got %s", items[0].URL)
+	}
+	if items[0].PublishedAt.IsZero() {
+		t.Error("Expected updated date to be used when published is missing")
+	}
+}
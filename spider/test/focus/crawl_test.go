@@ -0,0 +1,102 @@
+package focus_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/focus"
+	"github.com/dangpham/deisearch/spider/internal/scheduler"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestFocusedCrawl(t *testing.T) {
+	pages := map[string]string{
+		"/":        `<title>Engineering blog</title><p>` + strings.Repeat("Notes on software engineering and database design. ", 8) + `</p><a href="/recipes">Weekend recipes</a><a href="/indexes">Database indexes</a>`,
+		"/indexes": `<title>Database indexes</title><p>` + strings.Repeat("A B-tree index lets the database query optimizer avoid full scans. ", 8) + `</p>`,
+		"/recipes": `<title>Weekend recipes</title><p>` + strings.Repeat("Slow roasted tomatoes with garlic, basil and olive oil. ", 8) + `</p><a href="/pasta">Pasta</a>`,
+		"/pasta":   `<title>Pasta</title><p>` + strings.Repeat("Fresh pasta needs flour, eggs and patience. ", 8) + `</p>`,
+	}
+	mux := http.NewServeMux()
+	for path, body := range pages {
+		pattern := path
+		if path == "/" {
+			pattern = "/{$}"
+		}
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `<html lang="en"><head></head><body>%s</body></html>`, body)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	dbPath := "./test_focus.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	sched := scheduler.New(db, &scheduler.Config{
+		Workers:      1,
+		RateLimitSec: 0.01,
+		MaxPages:     10,
+		Focus: &focus.Config{
+			Keywords:     []string{"database", "software engineering", "query optimizer"},
+			MinRelevance: 0.1,
+		},
+	})
+	if err := sched.AddSeed(server.URL); err != nil {
+		t.Fatalf("Failed to add seed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := sched.Start(ctx); err != nil {
+		t.Fatalf("Crawl failed: %v", err)
+	}
+
+	relevance := make(map[string]float64)
+	crawledAt := make(map[string]time.Time)
+	for _, path := range []string{"/", "/indexes", "/recipes"} {
+		url := server.URL + path
+		if path == "/" {
+			url = server.URL
+		}
+		page, err := db.GetPage(url)
+		if err != nil || page == nil {
+			t.Fatalf("Expected %s to be crawled (err %v)", url, err)
+		}
+		crawledAt[path] = page.CrawledAt
+
+		annotations, err := db.GetPageAnnotations(url)
+		if err != nil || len(annotations) != 1 || annotations[0].Processor != "focus" {
+			t.Fatalf("Expected a focus annotation on %s, got %+v (err %v)", url, annotations, err)
+		}
+		relevance[path], _ = strconv.ParseFloat(annotations[0].Value, 64)
+		t.Logf("%-9s relevance %.4f", path, relevance[path])
+	}
+
+	if relevance["/recipes"] >= 0.1 || relevance["/indexes"] < 0.1 {
+		t.Errorf("Expected /indexes relevant and /recipes not, got %v", relevance)
+	}
+	if !crawledAt["/indexes"].Before(crawledAt["/recipes"]) {
+		t.Errorf("The relevant link should be crawled first")
+	}
+
+	if pasta, _ := db.GetPage(server.URL + "/pasta"); pasta != nil {
+		t.Errorf("Links of pages below min_relevance should not be followed")
+	}
+
+	if avg, ok := sched.GetStats()["avg_relevance"].(float64); !ok || avg <= 0 {
+		t.Errorf("Expected an average relevance in the stats, got %v", sched.GetStats()["avg_relevance"])
+	}
+}
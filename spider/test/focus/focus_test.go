@@ -0,0 +1,95 @@
+package focus_test
+
+import (
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/focus"
+)
+
+func newClassifier() *focus.Classifier {
+	return focus.NewClassifier(focus.Config{
+		Keywords: []string{"database", "software engineering", "query optimizer"},
+	})
+}
+
+func TestPageRelevance(t *testing.T) {
+	c := newClassifier()
+
+	onTopic := c.PageRelevance("How the Postgres query optimizer works",
+		"Databases plan each query by estimating costs. The optimizer picks indexes and join orders for the database.")
+	offTopic := c.PageRelevance("Ten easy pasta recipes",
+		"Boil water, add salt and cook the pasta until it is al dente. Serve with tomato sauce.")
+
+	t.Logf("On topic: %.3f, off topic: %.3f", onTopic, offTopic)
+	if onTopic <= offTopic {
+		t.Errorf("Database page should score above a recipe, got %.3f <= %.3f", onTopic, offTopic)
+	}
+	if offTopic != 0 {
+		t.Errorf("Page sharing no terms with the profile should score 0, got %.3f", offTopic)
+	}
+	if onTopic <= 0 || onTopic > 1 {
+		t.Errorf("Relevance should be in (0, 1], got %.3f", onTopic)
+	}
+}
+
+func TestSeedPagesExtendProfile(t *testing.T) {
+	c := focus.NewClassifier(focus.Config{SeedPages: []string{"https://example.com/seed"}})
+
+	page := "Write-ahead logging makes transactions durable. Replication ships the log to replicas."
+	if got := c.PageRelevance("Replication", page); got != 0 {
+		t.Errorf("Empty profile should score 0, got %.3f", got)
+	}
+
+	if !c.IsSeedPage("https://example.com/seed") {
+		t.Errorf("Seed page not recognized")
+	}
+	c.AddSeedPage("https://example.com/seed", "Write-ahead logging", "The write-ahead log records every transaction before it is applied.")
+
+	got := c.PageRelevance("Replication", page)
+	t.Logf("Relevance after adding the seed page: %.3f", got)
+	if got <= 0 {
+		t.Errorf("Page sharing terms with the seed page should score above 0")
+	}
+}
+
+func TestLinkPriority(t *testing.T) {
+	c := newClassifier()
+
+	relevant := c.LinkPriority(0.6, "Database internals", "", "https://example.com/blog/database-indexes")
+	sameParent := c.LinkPriority(0.6, "Contact us", "", "https://example.com/contact")
+	offTopicParent := c.LinkPriority(0.05, "Database internals", "", "https://example.com/blog/database-indexes")
+
+	t.Logf("Relevant anchor: %.3f, unrelated anchor: %.3f, off-topic parent: %.3f", relevant, sameParent, offTopicParent)
+	if relevant <= sameParent {
+		t.Errorf("Anchor text and URL words should raise the priority")
+	}
+	if relevant <= offTopicParent {
+		t.Errorf("Links should inherit the parent's relevance")
+	}
+	if relevant > 1 {
+		t.Errorf("Priority should be at most 1, got %.3f", relevant)
+	}
+}
+
+func TestPluralsMatchKeywords(t *testing.T) {
+	c := newClassifier()
+
+	if got := c.TextRelevance("Databases"); got != 1 {
+		t.Errorf("Plural of a keyword should match it, got %.3f", got)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (focus.Config{Keywords: []string{"sql"}, MinRelevance: 0.2}).Validate(); err != nil {
+		t.Errorf("Valid config rejected: %v", err)
+	}
+	if err := (focus.Config{Keywords: []string{"the"}}).Validate(); err == nil {
+		t.Errorf("Keyword of only stopwords should be rejected")
+	}
+	if err := (focus.Config{Keywords: []string{"sql"}, MinRelevance: 2}).Validate(); err == nil {
+		t.Errorf("min_relevance above 1 should be rejected")
+	}
+	if (focus.Config{}).Enabled() {
+		t.Errorf("Empty config should not enable focused crawling")
+	}
+}
@@ -0,0 +1,54 @@
+package frontier_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/frontier"
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+func TestFreshURLsJumpTheQueue(t *testing.T) {
+	f := frontier.New([]string{}, 0)
+
+	f.AddURLs([]parser.Link{
+		{URL: "https://example.com/old1"},
+		{URL: "https://other.com/old2"},
+	})
+
+	now := time.Now()
+	added := f.AddFreshURLs([]frontier.FreshURL{
+		{URL: "https://news.com/yesterday", PublishedAt: now.Add(-24 * time.Hour)},
+		{URL: "https://news2.com/just-now", PublishedAt: now},
+		{URL: "https://example.com/old1"},
+	})
+	if added != 2 {
+		t.Errorf("Expected 2 fresh URLs added (one already seen), got %d", added)
+	}
+
+	expected := []string{
+		"https://news2.com/just-now",
+		"https://news.com/yesterday",
+	}
+	for _, want := range expected {
+		url, wait := f.GetNext()
+		if wait != 0 || url != want {
+			t.Errorf("Expected %s first, got %s (wait %v)", want, url, wait)
+		}
+	}
+}
+
+func TestFreshURLsRespectRateLimit(t *testing.T) {
+	f := frontier.New([]string{}, 2)
+
+	f.AddURL("https://example.com/page")
+	url, _ := f.GetNext()
+	t.Logf("Crawled %s", url)
+
+	f.AddFreshURLs([]frontier.FreshURL{{URL: "https://example.com/breaking", PublishedAt: time.Now()}})
+
+	url, wait := f.GetNext()
+	if url != "" || wait == 0 {
+		t.Errorf("Fresh URL on a just-crawled host must wait, got %q (wait %v)", url, wait)
+	}
+}
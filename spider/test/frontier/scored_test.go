@@ -0,0 +1,67 @@
+package frontier_test
+
+import (
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/frontier"
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+func TestScoredURLsOrderByPriority(t *testing.T) {
+	f := frontier.New([]string{}, 0)
+
+	f.AddURLs([]parser.Link{{URL: "https://unscored.com/page"}})
+	f.AddScoredURLs([]frontier.ScoredURL{
+		{URL: "https://example.com/cooking", Priority: 0.1},
+		{URL: "https://other.com/postgres-internals", Priority: 0.8},
+		{URL: "https://example.com/btree-indexes", Priority: 0.6},
+		{URL: "https://unscored.com/page", Priority: 0.9},
+	})
+
+	expected := []string{
+		"https://other.com/postgres-internals",
+		"https://example.com/btree-indexes",
+		"https://example.com/cooking",
+		// Already queued, so the later score is ignored
+		"https://unscored.com/page",
+	}
+	for _, want := range expected {
+		url, wait := f.GetNext()
+		t.Logf("Got %s", url)
+		if wait != 0 || url != want {
+			t.Errorf("Expected %s, got %s (wait %v)", want, url, wait)
+		}
+	}
+}
+
+func TestScoredURLsKeepPoliteness(t *testing.T) {
+	f := frontier.New([]string{}, 2)
+
+	f.AddScoredURLs([]frontier.ScoredURL{
+		{URL: "https://example.com/relevant", Priority: 0.9},
+		{URL: "https://example.com/also-relevant", Priority: 0.8},
+		{URL: "https://other.com/barely", Priority: 0.05},
+	})
+
+	first, _ := f.GetNext()
+	second, _ := f.GetNext()
+	if first != "https://example.com/relevant" || second != "https://other.com/barely" {
+		t.Errorf("Expected the best URL, then another host while example.com waits; got %s, %s", first, second)
+	}
+
+	url, wait := f.GetNext()
+	if url != "" || wait == 0 {
+		t.Errorf("example.com must wait for its rate limit, got %q (wait %v)", url, wait)
+	}
+}
+
+func TestScoredURLsStayBehindFreshURLs(t *testing.T) {
+	f := frontier.New([]string{}, 0)
+
+	f.AddScoredURLs([]frontier.ScoredURL{{URL: "https://example.com/relevant", Priority: 1000}})
+	f.AddFreshURLs([]frontier.FreshURL{{URL: "https://news.com/breaking"}})
+
+	if url, _ := f.GetNext(); url != "https://news.com/breaking" {
+		t.Errorf("Fresh feed items must come before scored links, got %s", url)
+	}
+}
@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/metrics"
+)
+
+func TestPrometheusTextFormat(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	pages := r.NewCounter("spider_pages_crawled_total", "Pages saved.")
+	pages.Add(3)
+
+	outcomes := r.NewCounterVec("spider_crawl_attempts_total", "Attempts by outcome.", "outcome")
+	outcomes.With("crawled").Inc()
+	outcomes.With("crawled").Inc()
+	outcomes.With("fetch_error").Inc()
+
+	r.NewGaugeVecFunc("spider_frontier_host_urls", "Queued URLs per host.", "host", func() map[string]float64 {
+		return map[string]float64{`we"ird.example`: 7}
+	})
+
+	latency := r.NewHistogramVec("spider_fetch_latency_seconds", "Fetch latency.", "mode", []float64{0.1, 1})
+	latency.With("http").Observe(0.05)
+	latency.With("http").Observe(0.5)
+	latency.With("http").Observe(3)
+
+	var out strings.Builder
+	if _, err := r.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	t.Logf("Exposition:\n%s", out.String())
+
+	expected := []string{
+		"# TYPE spider_pages_crawled_total counter",
+		"spider_pages_crawled_total 3",
+		`spider_crawl_attempts_total{outcome="crawled"} 2`,
+		`spider_crawl_attempts_total{outcome="fetch_error"} 1`,
+		`spider_frontier_host_urls{host="we\"ird.example"} 7`,
+		"# TYPE spider_fetch_latency_seconds histogram",
+		`spider_fetch_latency_seconds_bucket{mode="http",le="0.1"} 1`,
+		`spider_fetch_latency_seconds_bucket{mode="http",le="1"} 2`,
+		`spider_fetch_latency_seconds_bucket{mode="http",le="+Inf"} 3`,
+		`spider_fetch_latency_seconds_sum{mode="http"} 3.55`,
+		`spider_fetch_latency_seconds_count{mode="http"} 3`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("Missing line: %s", line)
+		}
+	}
+}
+
+func TestMeterRate(t *testing.T) {
+	m := metrics.NewMeter()
+	m.Mark(30)
+	m.Mark(20)
+
+	// Within the first second the rate is taken over one second
+	rate := m.Rate()
+	t.Logf("Rate after 50 events: %.1f/s", rate)
+	if rate != 50 {
+		t.Errorf("Expected 50/s, got %.1f", rate)
+	}
+}
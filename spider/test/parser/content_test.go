@@ -0,0 +1,38 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+func TestContentKeepsBlocksOnLines(t *testing.T) {
+	p := parser.New()
+
+	page, _, err := p.ParseHTML(`<html><body><article>
+		<h1>Database Internals</h1>
+		<p>An overview of how
+		   databases <em>store</em> data.</p>
+		<ul><li>B-trees</li><li>LSM trees</li></ul>
+		<div><p>Subscribe to our newsletter for weekly updates.</p></div>
+	</article></body></html>`, "https://example.com/db")
+	if err != nil {
+		t.Fatalf("ParseHTML error: %v", err)
+	}
+
+	expected := "Database Internals\nAn overview of how databases store data.\nB-trees\nLSM trees\nSubscribe to our newsletter for weekly updates."
+	if page.Content != expected {
+		t.Errorf("Expected one block per line:\n%s\ngot:\n%s", expected, page.Content)
+	}
+
+	// Every leaf block is a whole line, which is what template stripping removes
+	lines := make(map[string]bool)
+	for _, block := range page.Blocks {
+		lines[block] = true
+	}
+	for _, block := range []string{"An overview of how databases store data.", "Subscribe to our newsletter for weekly updates."} {
+		if !lines[block] {
+			t.Errorf("Expected %q among the blocks %q", block, page.Blocks)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package parser_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+func TestDateSources(t *testing.T) {
+	p := parser.New()
+
+	tests := []struct {
+		name            string
+		html            string
+		url             string
+		headers         map[string]string
+		published       string
+		publishedSource string
+		modified        string
+		modifiedSource  string
+	}{
+		{
+			name: "JSON-LD beats OpenGraph",
+			html: `<html><head>
+				<script type="application/ld+json">{"@type":"NewsArticle","datePublished":"2024-03-05T09:30:00+01:00","dateModified":"2024-03-06"}</script>
+				<meta property="article:published_time" content="2024-03-01T00:00:00Z">
+			</head><body></body></html>`,
+			url:             "https://news.example.com/story",
+			published:       "2024-03-05T08:30:00Z",
+			publishedSource: parser.DateSourceJSONLD,
+			modified:        "2024-03-06T00:00:00Z",
+			modifiedSource:  parser.DateSourceJSONLD,
+		},
+		{
+			name: "OpenGraph article times",
+			html: `<html><head>
+				<meta property="article:published_time" content="2023-11-20T14:00:00Z">
+				<meta property="og:updated_time" content="2023-11-21T10:00:00Z">
+			</head><body></body></html>`,
+			url:             "https://blog.example.com/post",
+			published:       "2023-11-20T14:00:00Z",
+			publishedSource: parser.DateSourceMeta,
+			modified:        "2023-11-21T10:00:00Z",
+			modifiedSource:  parser.DateSourceMeta,
+		},
+		{
+			name:            "time element inside the article",
+			html:            `<html><body><time datetime="2001-01-01">old footer</time><article><p>Post</p><time datetime="2022-07-04T12:00">July 4</time></article></body></html>`,
+			url:             "https://example.com/post",
+			published:       "2022-07-04T12:00:00Z",
+			publishedSource: parser.DateSourceTimeElement,
+		},
+		{
+			name:            "URL date beats a time element",
+			html:            `<html><body><article><time datetime="2020-01-01">x</time></article></body></html>`,
+			url:             "https://example.com/2021/09/14/launch",
+			published:       "2021-09-14T00:00:00Z",
+			publishedSource: parser.DateSourceURL,
+		},
+		{
+			name:           "Last-Modified header only",
+			html:           `<html><body><p>Undated</p></body></html>`,
+			url:            "https://example.com/about",
+			headers:        map[string]string{"Last-Modified": "Wed, 21 Oct 2015 07:28:00 GMT"},
+			modified:       "2015-10-21T07:28:00Z",
+			modifiedSource: parser.DateSourceLastModified,
+		},
+		{
+			name: "implausible dates are ignored",
+			html: `<html><head><meta name="date" content="1970-01-01"></head>
+				<body><article><time datetime="2999-12-31">event</time></article></body></html>`,
+			url: "https://example.com/events/2024/13/01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, _, err := p.Parse(htmlResponse(tt.html, tt.headers), tt.url)
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+
+			t.Logf("Published: %v (%s, %.2f)", page.Published.Time, page.Published.Source, page.Published.Confidence)
+			t.Logf("Modified:  %v (%s, %.2f)", page.Modified.Time, page.Modified.Source, page.Modified.Confidence)
+
+			checkDate(t, "published", page.Published, tt.published, tt.publishedSource)
+			checkDate(t, "modified", page.Modified, tt.modified, tt.modifiedSource)
+		})
+	}
+}
+
+func checkDate(t *testing.T, kind string, got parser.PageDate, want, wantSource string) {
+	t.Helper()
+
+	if want == "" {
+		if !got.IsZero() {
+			t.Errorf("Expected no %s date, got %v from %s", kind, got.Time, got.Source)
+		}
+		return
+	}
+
+	wantTime, err := time.Parse(time.RFC3339, want)
+	if err != nil {
+		t.Fatalf("Bad test date %q: %v", want, err)
+	}
+	if !got.Time.Equal(wantTime) {
+		t.Errorf("Expected %s %v, got %v", kind, wantTime, got.Time)
+	}
+	if got.Source != wantSource {
+		t.Errorf("Expected %s source %q, got %q", kind, wantSource, got.Source)
+	}
+	if got.Confidence <= 0 || got.Confidence > 1 {
+		t.Errorf("Expected %s confidence in (0, 1], got %.2f", kind, got.Confidence)
+	}
+}
@@ -0,0 +1,102 @@
+package parser_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+const germanHTML = `<!DOCTYPE html>
+<html lang="de-DE">
+<head>
+	<title>Über uns</title>
+	<link rel="alternate" hreflang="de-de" href="https://example.com/de/about">
+	<link rel="alternate" hreflang="en-GB" href="https://example.com/uk/about">
+	<link rel="alternate" hreflang="en" href="/en/about/">
+	<link rel="alternate" hreflang="x-default" href="https://example.com/about">
+	<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+</head>
+<body><p>Wir sind ein kleines Team aus Berlin, das an Suchmaschinen arbeitet.</p><a href="/de/kontakt">Kontakt</a></body>
+</html>`
+
+func htmlResponse(body string, headers map[string]string) *http.Response {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	for key, value := range headers {
+		resp.Header.Set(key, value)
+	}
+	return resp
+}
+
+func TestNonEnglishPageKeepsAlternates(t *testing.T) {
+	p := parser.New()
+
+	page, links, err := p.Parse(htmlResponse(germanHTML, nil), "https://example.com/de/about")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if page == nil {
+		t.Fatal("Expected a page describing the non-English document")
+	}
+
+	t.Logf("Language: %s, alternates:", page.Language)
+	for _, alt := range page.Alternates {
+		t.Logf("  %-10s %s", alt.Lang, alt.URL)
+	}
+
+	if page.IsEnglish() || page.Language != "de" {
+		t.Errorf("Expected language de, got %q", page.Language)
+	}
+	if page.Content != "" || len(links) != 0 {
+		t.Error("Non-English pages should come back without content or links")
+	}
+	if len(page.Alternates) != 4 {
+		t.Errorf("Expected 4 hreflang alternates (feeds excluded), got %d", len(page.Alternates))
+	}
+	if english := page.EnglishAlternate(); english != "https://example.com/en/about" {
+		t.Errorf("Expected plain en to win over en-gb, got %q", english)
+	}
+}
+
+func TestLanguageDetection(t *testing.T) {
+	p := parser.New()
+	english := `<html lang="en-US"><head><title>About</title><link rel="alternate" hreflang="fr" href="/fr/about"></head><body><p>About us</p></body></html>`
+
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		want    string
+	}{
+		{"html lang", english, nil, "en"},
+		{"header overrides html", english, map[string]string{"Content-Language": "fr-FR, en"}, "fr"},
+		{"undeclared", "<html><body><p>Hi</p></body></html>", nil, ""},
+		{"german", germanHTML, map[string]string{"Content-Language": "en"}, "de"},
+	}
+
+	for _, tt := range tests {
+		page, _, err := p.Parse(htmlResponse(tt.body, tt.headers), "https://example.com/about")
+		if err != nil {
+			t.Fatalf("%s: Parse error: %v", tt.name, err)
+		}
+		t.Logf("%-22s language=%q english=%v", tt.name, page.Language, page.IsEnglish())
+		if page.Language != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, page.Language)
+		}
+	}
+
+	// English pages keep their alternates too, for language clustering
+	page, _, _ := p.Parse(htmlResponse(english, nil), "https://example.com/about")
+	if len(page.Alternates) != 1 || page.Alternates[0].URL != "https://example.com/fr/about" {
+		t.Errorf("Expected the fr alternate on the English page, got %v", page.Alternates)
+	}
+	if page.EnglishAlternate() != "" {
+		t.Error("An English page has no English alternate to follow")
+	}
+}
@@ -0,0 +1,49 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+const outlineHTML = `<html><body>
+	<nav><h2>Site menu</h2><a href="/">Home</a></nav>
+	<main>
+		<h1>Database Internals</h1>
+		<p>An overview of how databases store data.</p>
+		<section id="storage">
+			<h2>Storage engines</h2>
+			<p>B-trees and LSM trees.</p>
+			<h3><a id="wal"></a>Write-ahead logging</h3>
+			<p>Durability comes from the log.</p>
+		</section>
+		<h2 id="indexes">Indexes</h2>
+		<p>Secondary indexes speed up lookups.</p>
+	</main>
+</body></html>`
+
+func TestExtractOutline(t *testing.T) {
+	p := parser.New()
+
+	page, _, err := p.ParseHTML(outlineHTML, "https://example.com/db")
+	if err != nil {
+		t.Fatalf("ParseHTML error: %v", err)
+	}
+
+	expected := []parser.Section{
+		{Level: 1, Text: "Database Internals", Content: "An overview of how databases store data."},
+		{Level: 2, Text: "Storage engines", ID: "storage", Content: "B-trees and LSM trees."},
+		{Level: 3, Text: "Write-ahead logging", ID: "wal", Content: "Durability comes from the log."},
+		{Level: 2, Text: "Indexes", ID: "indexes", Content: "Secondary indexes speed up lookups."},
+	}
+
+	if len(page.Outline) != len(expected) {
+		t.Fatalf("Expected %d sections, got %d: %+v", len(expected), len(page.Outline), page.Outline)
+	}
+
+	for i, section := range page.Outline {
+		if section != expected[i] {
+			t.Errorf("Section %d: expected %+v, got %+v", i, expected[i], section)
+		}
+	}
+}
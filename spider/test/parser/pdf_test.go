@@ -0,0 +1,93 @@
+package parser_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+// buildPDF writes a minimal single-font PDF with one text line per page.
+func buildPDF(info string, pages []string) []byte {
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+	for i, text := range pages {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+	objects = append(objects, info)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, len(objects), xref)
+
+	return buf.Bytes()
+}
+
+func TestParsePDF(t *testing.T) {
+	data := buildPDF(
+		"<< /Title (Consensus in Distributed Databases) /Author (Ada Writer) /CreationDate (D:20230115093000Z) >>",
+		[]string{
+			"Raft and Paxos keep replicated logs consistent across failures in distributed databases.",
+			"Leader election and log replication are the two halves of the Raft protocol design.",
+			"This third page is beyond the page limit and must not be extracted by the parser.",
+		},
+	)
+
+	p := parser.New()
+	page, err := p.ParsePDF(data, "https://example.org/papers/raft.pdf", 2)
+	if err != nil {
+		t.Fatalf("ParsePDF error: %v", err)
+	}
+
+	if page.Title != "Consensus in Distributed Databases" {
+		t.Errorf("Unexpected title: %q", page.Title)
+	}
+	if page.Metadata.Author != "Ada Writer" {
+		t.Errorf("Unexpected author: %q", page.Metadata.Author)
+	}
+	if page.Metadata.PublishedTime != "2023-01-15T09:30:00Z" {
+		t.Errorf("Unexpected creation date: %q", page.Metadata.PublishedTime)
+	}
+	if page.ContentType != parser.ContentTypePDF {
+		t.Errorf("Expected PDF content type, got %q", page.ContentType)
+	}
+	if !strings.Contains(page.Content, "Raft") || !strings.Contains(page.Content, "Leader election") {
+		t.Errorf("Expected text from the first two pages, got %q", page.Content)
+	}
+	if strings.Contains(page.Content, "third page") {
+		t.Error("Pages beyond the limit should not be extracted")
+	}
+	t.Logf("Extracted %d chars: %s", len(page.Content), page.Content)
+}
+
+func TestParsePDFMalformed(t *testing.T) {
+	p := parser.New()
+	if _, err := p.ParsePDF([]byte("%PDF-1.4 not really a pdf"), "https://example.org/bad.pdf", 10); err == nil {
+		t.Error("Expected an error for a malformed PDF")
+	}
+}
@@ -0,0 +1,142 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+)
+
+const articleHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<title>Designing a Database From Scratch</title>
+	<meta property="og:type" content="article">
+	<meta property="og:site_name" content="Example Engineering">
+	<meta property="og:image" content="https://example.com/cover.png">
+	<meta property="article:published_time" content="2024-03-01T09:00:00Z">
+	<meta name="twitter:card" content="summary_large_image">
+	<script type="application/ld+json">
+	{
+		"@context": "https://schema.org",
+		"@graph": [
+			{"@type": "BlogPosting", "headline": "Designing a Database", "author": {"@type": "Person", "name": "Ada Writer"},
+			 "datePublished": "2024-03-01", "dateModified": "2024-03-05"},
+			{"@type": "BreadcrumbList", "itemListElement": [
+				{"@type": "ListItem", "position": 1, "name": "Blog"},
+				{"@type": "ListItem", "position": 2, "name": "Databases"}
+			]}
+		]
+	}
+	</script>
+</head>
+<body>
+	<article>
+		<p>Storage engines, write-ahead logs and B-trees are the backbone of every relational database you will ever use.</p>
+	</article>
+	<div itemscope itemtype="https://schema.org/Product">
+		<span itemprop="name">DeiDB</span>
+		<span itemprop="brand" itemscope itemtype="https://schema.org/Brand"><span itemprop="name">Dei</span></span>
+	</div>
+</body>
+</html>`
+
+func TestParseStructuredData(t *testing.T) {
+	p := parser.New()
+
+	page, _, err := p.ParseHTML(articleHTML, "https://example.com/blog/database")
+	if err != nil {
+		t.Fatalf("ParseHTML error: %v", err)
+	}
+
+	meta := page.Metadata
+	if meta == nil {
+		t.Fatal("Expected metadata to be extracted")
+	}
+
+	if meta.Type != "BlogPosting" {
+		t.Errorf("Expected JSON-LD type BlogPosting to win over og:type, got %q", meta.Type)
+	}
+	if meta.Author != "Ada Writer" {
+		t.Errorf("Expected author 'Ada Writer', got %q", meta.Author)
+	}
+	if meta.PublishedTime != "2024-03-01" || meta.ModifiedTime != "2024-03-05" {
+		t.Errorf("Unexpected dates: published=%q modified=%q", meta.PublishedTime, meta.ModifiedTime)
+	}
+	if meta.SiteName != "Example Engineering" {
+		t.Errorf("Expected site name from og:site_name, got %q", meta.SiteName)
+	}
+	if meta.Image != "https://example.com/cover.png" {
+		t.Errorf("Expected image from og:image, got %q", meta.Image)
+	}
+	if strings.Join(meta.Breadcrumb, " > ") != "Blog > Databases" {
+		t.Errorf("Unexpected breadcrumb: %v", meta.Breadcrumb)
+	}
+	if meta.OpenGraph["twitter:card"] != "summary_large_image" {
+		t.Errorf("Twitter card tags should be kept, got %v", meta.OpenGraph)
+	}
+	if len(meta.JSONLD) != 1 {
+		t.Errorf("Expected 1 JSON-LD block, got %d", len(meta.JSONLD))
+	}
+
+	var product *parser.MicrodataItem
+	for i := range meta.Microdata {
+		if meta.Microdata[i].Type == "Product" {
+			product = &meta.Microdata[i]
+		}
+	}
+	if product == nil {
+		t.Fatalf("Expected a Product microdata item, got %+v", meta.Microdata)
+	}
+	if got := product.Properties["name"]; len(got) != 1 || got[0] != "DeiDB" {
+		t.Errorf("Nested item names must not leak into the parent, got %v", got)
+	}
+	if got := product.Properties["brand"]; len(got) != 1 || got[0] != "Dei" {
+		t.Errorf("Expected nested brand name 'Dei', got %v", got)
+	}
+}
+
+const linksHTML = `<html><body>
+	<nav><a href="/docs" title="Documentation">Docs</a></nav>
+	<article>
+		<header><a href="https://other.org/paper" rel="nofollow noopener">the original paper</a></header>
+		<a href="/img"><img src="/x.png" alt="Architecture diagram"></a>
+	</article>
+	<footer><a href="https://www.example.com/privacy">Privacy</a></footer>
+</body></html>`
+
+func TestExtractLinkAttributes(t *testing.T) {
+	p := parser.New()
+
+	_, links, err := p.ParseHTML(linksHTML, "https://example.com/post")
+	if err != nil {
+		t.Fatalf("ParseHTML error: %v", err)
+	}
+
+	byURL := make(map[string]parser.Link)
+	for _, link := range links {
+		byURL[link.URL] = link
+	}
+
+	docs := byURL["https://example.com/docs"]
+	if docs.Region != "nav" || docs.Title != "Documentation" || !docs.Internal {
+		t.Errorf("Unexpected nav link: %+v", docs)
+	}
+
+	paper := byURL["https://other.org/paper"]
+	if paper.Region != "content" || paper.Internal || strings.Join(paper.Rel, " ") != "nofollow noopener" {
+		t.Errorf("Unexpected external link: %+v", paper)
+	}
+	if paper.AnchorText != "the original paper" {
+		t.Errorf("Unexpected anchor text: %q", paper.AnchorText)
+	}
+
+	if img := byURL["https://example.com/img"]; img.AnchorText != "Architecture diagram" {
+		t.Errorf("Image link should use alt text, got %q", img.AnchorText)
+	}
+
+	privacy := byURL["https://www.example.com/privacy"]
+	if privacy.Region != "footer" || !privacy.Internal {
+		t.Errorf("www. host should count as internal footer link: %+v", privacy)
+	}
+}
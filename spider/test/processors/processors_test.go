@@ -0,0 +1,125 @@
+package processors_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/scheduler"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+type wordCounter struct{}
+
+func (wordCounter) Name() string { return "words" }
+
+func (wordCounter) Process(page *scheduler.ProcessedPage) error {
+	page.Set("count", strconv.Itoa(len(strings.Fields(page.Content))))
+	return nil
+}
+
+// draftFilter rejects drafts and stops following links to them
+type draftFilter struct{}
+
+func (draftFilter) Name() string { return "drafts" }
+
+func (draftFilter) Process(page *scheduler.ProcessedPage) error {
+	if strings.Contains(page.URL, "/draft") {
+		page.Reject("draft page")
+	}
+	return nil
+}
+
+type failing struct{}
+
+func (failing) Name() string { return "failing" }
+
+func (failing) Process(page *scheduler.ProcessedPage) error {
+	return errors.New("model not loaded")
+}
+
+func TestPageProcessors(t *testing.T) {
+	body := strings.Repeat("Search engines crawl, parse and index documents. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html lang="en"><head><title>Home</title></head><body><p>%s</p><a href="/draft">Draft</a></body></html>`, body)
+	})
+	mux.HandleFunc("/draft", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html lang="en"><head><title>Draft</title></head><body><p>%s</p></body></html>`, body)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	dbPath := "./test_processors.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	sched := scheduler.New(db, &scheduler.Config{Workers: 1, RateLimitSec: 0.01, MaxPages: 10})
+	sched.AddProcessor(wordCounter{})
+	sched.AddProcessor(draftFilter{})
+	sched.AddProcessor(failing{})
+
+	if err := sched.AddSeed(server.URL); err != nil {
+		t.Fatalf("Failed to add seed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := sched.Start(ctx); err != nil {
+		t.Fatalf("Crawl failed: %v", err)
+	}
+
+	annotations, err := db.GetPageAnnotations(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to read annotations: %v", err)
+	}
+	t.Logf("Annotations of %s: %+v", server.URL, annotations)
+	if len(annotations) != 1 || annotations[0].Processor != "words" || annotations[0].Key != "count" || annotations[0].Value != "70" {
+		t.Errorf("Expected words.count = 70, got %+v", annotations)
+	}
+
+	draft, err := db.GetPage(server.URL + "/draft")
+	if err != nil {
+		t.Fatalf("Failed to look up draft: %v", err)
+	}
+	if draft != nil {
+		t.Error("Rejected page should not be saved")
+	}
+
+	totals, err := db.GetAttemptTotals(time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "")
+	if err != nil {
+		t.Fatalf("Failed to get attempt totals: %v", err)
+	}
+	outcomes := make(map[string]int)
+	for _, total := range totals {
+		outcomes[total.Outcome] = total.Count
+	}
+	t.Logf("Outcomes: %v", outcomes)
+	if outcomes[scheduler.OutcomeRejected] != 1 || outcomes[scheduler.OutcomeCrawled] != 1 {
+		t.Errorf("Expected one crawled and one rejected attempt, got %v", outcomes)
+	}
+
+	stats, ok := sched.GetStats()["processors"].([]map[string]interface{})
+	if !ok || len(stats) != 3 {
+		t.Fatalf("Expected stats for 3 processors, got %v", sched.GetStats()["processors"])
+	}
+	for _, s := range stats {
+		t.Logf("%-8s pages=%v avg=%.3fms rejected=%v errors=%v", s["name"], s["pages"], s["avg_ms"], s["rejected"], s["errors"])
+	}
+	// The failing processor never sees the rejected draft
+	if stats[0]["pages"] != uint64(2) || stats[1]["rejected"] != int64(1) || stats[2]["pages"] != uint64(1) || stats[2]["errors"] != int64(1) {
+		t.Errorf("Unexpected processor stats: %v", stats)
+	}
+}
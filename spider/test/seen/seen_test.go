@@ -0,0 +1,158 @@
+package seen_test
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/seen"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	filter := seen.NewBloomFilter(10000, 0.01)
+
+	for i := 0; i < 10000; i++ {
+		filter.Add(fmt.Sprintf("https://example.com/page/%d", i))
+	}
+	for i := 0; i < 10000; i++ {
+		if !filter.MayContain(fmt.Sprintf("https://example.com/page/%d", i)) {
+			t.Fatalf("Added item %d reported as missing", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if filter.MayContain(fmt.Sprintf("https://other.com/page/%d", i)) {
+			falsePositives++
+		}
+	}
+	rate := float64(falsePositives) / 10000
+	t.Logf("False positive rate: %.4f with %d bytes", rate, filter.SizeBytes())
+	if rate > 0.03 {
+		t.Errorf("False positive rate %.4f too far above the configured 0.01", rate)
+	}
+}
+
+func TestDiskSetIsExact(t *testing.T) {
+	dbPath := "./test_seen.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.SavePage(&storage.Page{URL: "https://example.com/crawled", Title: "Crawled", StatusCode: 200, CrawledAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save page: %v", err)
+	}
+
+	// A tiny filter saturates quickly, so lookups go through to the store
+	set := seen.NewDiskSet(db, seen.Config{Capacity: 10, FlushSize: 100, RecentSize: 10})
+	set.Preload("https://example.com/crawled")
+
+	if set.Add("https://example.com/crawled") {
+		t.Error("Crawled URL should already be seen")
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !set.Add(fmt.Sprintf("https://example.com/page/%d", i)) {
+			t.Fatalf("URL %d should be new", i)
+		}
+	}
+	set.Flush()
+
+	for i := 0; i < 1000; i++ {
+		if set.Add(fmt.Sprintf("https://example.com/page/%d", i)) {
+			t.Fatalf("URL %d added twice", i)
+		}
+	}
+	if set.Has("https://example.com/never-added") {
+		t.Error("Saturated filter must not turn into false positives")
+	}
+
+	// The next run starts over, except for crawled pages
+	if err := db.ResetSeenURLs(); err != nil {
+		t.Fatalf("Failed to reset: %v", err)
+	}
+	next := seen.NewDiskSet(db, seen.Config{})
+	next.Preload("https://example.com/crawled")
+	if !next.Add("https://example.com/page/1") {
+		t.Error("Queued-but-uncrawled URL should be new after a restart")
+	}
+	if next.Has("https://example.com/crawled") != true {
+		t.Error("Crawled URL should stay seen after a restart")
+	}
+}
+
+// blockingStore holds every lookup and write until release is closed.
+type blockingStore struct {
+	release chan struct{}
+	calls   chan string
+	mu      sync.Mutex
+	written []string
+}
+
+func (s *blockingStore) HasSeenURL(url string) (bool, error) {
+	s.calls <- "has " + url
+	<-s.release
+	return false, nil
+}
+
+func (s *blockingStore) AddSeenURLs(urls []string) error {
+	s.calls <- "add"
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.written = append(s.written, urls...)
+	return nil
+}
+
+func TestDiskSetDoesNotHoldLockOnStore(t *testing.T) {
+	store := &blockingStore{release: make(chan struct{}), calls: make(chan string, 10)}
+	set := seen.NewDiskSet(store, seen.Config{FlushSize: 2})
+
+	// Fills the buffer, so the second Add writes a batch and blocks
+	done := make(chan struct{})
+	go func() {
+		set.Add("https://example.com/a")
+		set.Add("https://example.com/b")
+		close(done)
+	}()
+	if call := <-store.calls; call != "add" {
+		t.Fatalf("Expected a batch write, got %q", call)
+	}
+
+	// A "maybe seen" answer blocks on the store lookup
+	set.Preload("https://example.com/crawled")
+	go set.Has("https://example.com/crawled")
+	if call := <-store.calls; call != "has https://example.com/crawled" {
+		t.Fatalf("Expected a lookup, got %q", call)
+	}
+
+	added := make(chan bool)
+	go func() {
+		added <- set.Add("https://example.com/c") && !set.Add("https://example.com/a")
+	}()
+	select {
+	case ok := <-added:
+		if !ok {
+			t.Error("Expected a new URL added and a URL being written still seen")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Add blocked behind the store")
+	}
+
+	close(store.release)
+	<-done
+	set.Flush()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.written) != 3 {
+		t.Errorf("Expected 3 URLs written, got %v", store.written)
+	}
+}
@@ -0,0 +1,146 @@
+package shard_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/shard"
+)
+
+func TestOwnerIsStableAndSpread(t *testing.T) {
+	counts := make([]int, 4)
+	for i := 0; i < 4000; i++ {
+		host := fmt.Sprintf("site%d.example.com", i)
+		owner := shard.Owner(host, 4)
+		if owner != shard.Owner(strings.ToUpper(host), 4) {
+			t.Fatalf("Owner of %s depends on case", host)
+		}
+		counts[owner]++
+	}
+	t.Logf("Hosts per shard: %v", counts)
+
+	for i, count := range counts {
+		if count < 800 || count > 1200 {
+			t.Errorf("Shard %d owns %d of 4000 hosts, expected about 1000", i, count)
+		}
+	}
+
+	config := &shard.Config{}
+	if !config.Owns("anything.com") {
+		t.Error("An unsharded config should own every host")
+	}
+}
+
+func TestForwarderDeliversAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	var received []shard.Link
+	up := false
+
+	peer := httptest.NewServer(shard.Handler("secret", func(batch shard.Batch) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, batch.Links...)
+	}))
+	defer peer.Close()
+
+	// The peer refuses batches until it is "up"
+	gate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ready := up
+		mu.Unlock()
+		if !ready {
+			http.Error(w, "starting", http.StatusServiceUnavailable)
+			return
+		}
+		peer.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer gate.Close()
+
+	config := &shard.Config{ID: 0, Peers: []string{"127.0.0.1:1", strings.TrimPrefix(gate.URL, "http://")}, Token: "secret"}
+
+	// Three hosts the other shard owns, one this shard owns
+	var hosts []string
+	local := ""
+	for i := 0; len(hosts) < 3 || local == ""; i++ {
+		host := fmt.Sprintf("host%d.com", i)
+		if !config.Owns(host) {
+			hosts = append(hosts, host)
+		} else if local == "" {
+			local = host
+		}
+	}
+	hosts = hosts[:3]
+
+	forwarder := shard.NewForwarder(config)
+	for _, host := range hosts {
+		forwarder.Forward(host, shard.Link{URL: "https://" + host + "/", FoundOn: "https://local.com/"})
+	}
+	forwarder.Forward(local, shard.Link{URL: "https://" + local + "/ignored"})
+
+	time.Sleep(1500 * time.Millisecond)
+	stats := forwarder.Stats()
+	t.Logf("While the peer is down: %+v", stats)
+	if stats.Pending != 3 || stats.Failures == 0 {
+		t.Errorf("Expected 3 links held for retry, got %+v", stats)
+	}
+
+	mu.Lock()
+	up = true
+	mu.Unlock()
+	forwarder.Close()
+
+	stats = forwarder.Stats()
+	t.Logf("After the peer came up: %+v", stats)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 3 || stats.Forwarded != 3 || stats.Pending != 0 {
+		t.Errorf("Expected 3 delivered links, got %d (%+v)", len(received), stats)
+	}
+}
+
+func TestHandlerRequiresToken(t *testing.T) {
+	received := 0
+	handler := shard.Handler("secret", func(batch shard.Batch) {
+		received += len(batch.Links)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"bare token", "secret", http.StatusUnauthorized},
+		{"shared token", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, shard.LinksPath, strings.NewReader(`{"from":1,"links":[{"url":"https://a.com/"}]}`))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+
+	if received != 1 {
+		t.Errorf("Expected only the authorized batch to be received, got %d links", received)
+	}
+
+	config := &shard.Config{Peers: []string{"a:1", "b:1"}}
+	if err := config.Validate(); err == nil {
+		t.Error("A sharded config without a token should not validate")
+	}
+}
@@ -0,0 +1,72 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestCrawlAttemptReport(t *testing.T) {
+	dbPath := "./test_attempts.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	attempts := []storage.CrawlAttempt{
+		{URL: "https://a.com/1", Host: "a.com", AttemptedAt: now.Add(-time.Hour), Outcome: "crawled", StatusCode: 200, LatencyMs: 100, Bytes: 5000, FetchMode: "http"},
+		{URL: "https://a.com/2", Host: "a.com", AttemptedAt: now.Add(-time.Hour), Outcome: "http_error", StatusCode: 500, Error: "non-200 status: 500", LatencyMs: 300, FetchMode: "http"},
+		{URL: "https://b.com/1", Host: "b.com", AttemptedAt: now.Add(-2 * time.Hour), Outcome: "robots_disallowed", Error: "disallowed by robots.txt", FetchMode: "http"},
+		{URL: "https://b.com/2", Host: "b.com", AttemptedAt: now.Add(-2 * time.Hour), Outcome: "robots_disallowed", Error: "disallowed by robots.txt", FetchMode: "http"},
+		// Outside the reported range
+		{URL: "https://c.com/1", Host: "c.com", AttemptedAt: now.Add(-72 * time.Hour), Outcome: "fetch_error", FetchMode: "http"},
+	}
+	for i := range attempts {
+		if err := db.SaveCrawlAttempt(&attempts[i]); err != nil {
+			t.Fatalf("Failed to save attempt: %v", err)
+		}
+	}
+
+	from, to := now.Add(-24*time.Hour), now
+	totals, err := db.GetAttemptTotals(from, to, "")
+	if err != nil {
+		t.Fatalf("Failed to get totals: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, total := range totals {
+		counts[total.Outcome] = total.Count
+		t.Logf("%s: %d (avg %.0fms)", total.Outcome, total.Count, total.AvgLatencyMs)
+	}
+	if counts["crawled"] != 1 || counts["http_error"] != 1 || counts["robots_disallowed"] != 2 {
+		t.Errorf("Unexpected totals: %v", counts)
+	}
+	if counts["fetch_error"] != 0 {
+		t.Error("Attempts outside the range should not be counted")
+	}
+
+	failures, err := db.GetFailuresByHost(from, to, 10)
+	if err != nil {
+		t.Fatalf("Failed to get failures: %v", err)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failure groups, got %d", len(failures))
+	}
+	if failures[0].Host != "b.com" || failures[0].Outcome != "robots_disallowed" || failures[0].Count != 2 {
+		t.Errorf("Expected b.com robots_disallowed x2 first, got %+v", failures[0])
+	}
+
+	hostTotals, err := db.GetAttemptTotals(from, to, "a.com")
+	if err != nil {
+		t.Fatalf("Failed to get host totals: %v", err)
+	}
+	if len(hostTotals) != 2 {
+		t.Errorf("Expected 2 outcomes for a.com, got %d", len(hostTotals))
+	}
+}
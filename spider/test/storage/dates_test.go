@@ -0,0 +1,64 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestPageDates(t *testing.T) {
+	dbPath := "./test_dates.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	published := time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC)
+	err = db.SavePageDates(&storage.PageDates{
+		URL:                 "https://example.com/story",
+		PublishedAt:         published,
+		PublishedSource:     "json_ld",
+		PublishedConfidence: 0.9,
+	})
+	if err != nil {
+		t.Fatalf("Failed to save dates: %v", err)
+	}
+
+	dates, err := db.GetPageDates("https://example.com/story")
+	if err != nil || dates == nil {
+		t.Fatalf("Failed to read dates: %v", err)
+	}
+	t.Logf("Published %v from %s (%.2f), modified %v", dates.PublishedAt, dates.PublishedSource, dates.PublishedConfidence, dates.ModifiedAt)
+
+	if !dates.PublishedAt.Equal(published) || dates.PublishedSource != "json_ld" || dates.PublishedConfidence != 0.9 {
+		t.Errorf("Published date did not round-trip: %+v", dates)
+	}
+	if !dates.ModifiedAt.IsZero() {
+		t.Errorf("Expected no modified date, got %v", dates.ModifiedAt)
+	}
+
+	stats, err := db.GetCrawlStats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.DatedPages != 1 {
+		t.Errorf("Expected 1 dated page, got %d", stats.DatedPages)
+	}
+
+	// A re-crawl that finds no dates clears them
+	if err := db.SavePageDates(&storage.PageDates{URL: "https://example.com/story"}); err != nil {
+		t.Fatalf("Failed to clear dates: %v", err)
+	}
+	dates, err = db.GetPageDates("https://example.com/story")
+	if err != nil {
+		t.Fatalf("Failed to read dates: %v", err)
+	}
+	if dates != nil {
+		t.Errorf("Expected dates to be removed, got %+v", dates)
+	}
+}
@@ -0,0 +1,68 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestLanguageClusters(t *testing.T) {
+	dbPath := "./test_languages.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// The German page lists only itself and the English one; the French
+	// page lists itself and the German one. Both end up in one cluster.
+	err = db.SaveLanguageVariants([]storage.LanguageVariant{
+		{URL: "https://example.com/de/about", Lang: "de"},
+		{URL: "https://example.com/en/about", Lang: "en"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save variants: %v", err)
+	}
+	err = db.SaveLanguageVariants([]storage.LanguageVariant{
+		{URL: "https://example.com/fr/about", Lang: "fr"},
+		{URL: "https://example.com/de/about", Lang: "de-de"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save variants: %v", err)
+	}
+	err = db.SaveLanguageVariants([]storage.LanguageVariant{
+		{URL: "https://other.com/", Lang: "en"},
+		{URL: "https://other.com/es", Lang: "es"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save variants: %v", err)
+	}
+
+	variants, err := db.GetLanguageVariants("https://example.com/fr/about")
+	if err != nil {
+		t.Fatalf("Failed to get variants: %v", err)
+	}
+	for _, v := range variants {
+		t.Logf("%-6s %-30s cluster %s", v.Lang, v.URL, v.Cluster)
+	}
+
+	if len(variants) != 3 {
+		t.Fatalf("Expected 3 variants in the merged cluster, got %d", len(variants))
+	}
+	for _, v := range variants {
+		if v.Cluster != "https://example.com/de/about" {
+			t.Errorf("Expected the smallest URL as cluster ID, got %s", v.Cluster)
+		}
+		if v.URL == "https://example.com/de/about" && v.Lang != "de-de" {
+			t.Errorf("Expected the newer, more specific language, got %s", v.Lang)
+		}
+	}
+
+	none, err := db.GetLanguageVariants("https://unknown.com/")
+	if err != nil || len(none) != 0 {
+		t.Errorf("Expected no variants for an unknown page, got %v (%v)", none, err)
+	}
+}
@@ -0,0 +1,63 @@
+package storage_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestOutlinksMigrateOldSchema(t *testing.T) {
+	dbPath := "./test_links.db"
+	defer os.Remove(dbPath)
+
+	// A links table as created by earlier crawls, before anchor text was stored
+	raw, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open raw database: %v", err)
+	}
+	if _, err := raw.Exec(`CREATE TABLE links (from_url TEXT, to_url TEXT, PRIMARY KEY (from_url, to_url))`); err != nil {
+		t.Fatalf("Failed to create old links table: %v", err)
+	}
+	if _, err := raw.Exec(`INSERT INTO links VALUES ('https://a.com', 'https://b.com')`); err != nil {
+		t.Fatalf("Failed to insert old link: %v", err)
+	}
+	raw.Close()
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open migrated database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.SaveOutlinks("https://a.com", []storage.Link{
+		{ToURL: "https://b.com", AnchorText: "B homepage", Rel: "nofollow", Region: "content"},
+		{ToURL: "https://a.com/about", AnchorText: "About", Region: "nav", IsInternal: true},
+		{ToURL: "https://a.com/about", AnchorText: "About us", Region: "footer", IsInternal: true},
+	})
+	if err != nil {
+		t.Fatalf("SaveOutlinks error: %v", err)
+	}
+
+	inlinks, err := db.GetInlinks("https://b.com")
+	if err != nil {
+		t.Fatalf("GetInlinks error: %v", err)
+	}
+	if len(inlinks) != 1 || inlinks[0].AnchorText != "B homepage" || inlinks[0].Rel != "nofollow" {
+		t.Errorf("Existing link should gain anchor attributes, got %+v", inlinks)
+	}
+
+	inlinks, err = db.GetInlinks("https://a.com/about")
+	if err != nil {
+		t.Fatalf("GetInlinks error: %v", err)
+	}
+	if len(inlinks) != 1 {
+		t.Fatalf("Expected 1 inlink, got %d", len(inlinks))
+	}
+	if inlinks[0].AnchorText != "About" || inlinks[0].Region != "nav" || !inlinks[0].IsInternal {
+		t.Errorf("First anchor should be kept, got %+v", inlinks[0])
+	}
+	t.Logf("Inlink: %+v", inlinks[0])
+}
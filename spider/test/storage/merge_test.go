@@ -0,0 +1,109 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func newShard(t *testing.T, path string, pages []storage.Page, links []storage.Link) {
+	t.Helper()
+
+	db, err := storage.NewDatabase(path)
+	if err != nil {
+		t.Fatalf("Failed to create shard: %v", err)
+	}
+	defer db.Close()
+
+	for i := range pages {
+		if err := db.SavePage(&pages[i]); err != nil {
+			t.Fatalf("Failed to save page: %v", err)
+		}
+		err := db.SaveCrawlAttempt(&storage.CrawlAttempt{
+			URL: pages[i].URL, Host: "shard", AttemptedAt: pages[i].CrawledAt, Outcome: "crawled", FetchMode: "http",
+		})
+		if err != nil {
+			t.Fatalf("Failed to save attempt: %v", err)
+		}
+	}
+	if len(links) > 0 {
+		if err := db.SaveOutlinks(pages[0].URL, links); err != nil {
+			t.Fatalf("Failed to save links: %v", err)
+		}
+	}
+}
+
+func TestMergeShards(t *testing.T) {
+	paths := []string{"./test_merge.db", "./test_shard0.db", "./test_shard1.db"}
+	for _, path := range paths {
+		defer os.Remove(path)
+		defer os.Remove(path + "-wal")
+		defer os.Remove(path + "-shm")
+	}
+
+	old := time.Now().Add(-time.Hour)
+	now := time.Now()
+
+	newShard(t, paths[1], []storage.Page{
+		{URL: "https://a.com/", Title: "A", Content: "a", CrawledAt: now},
+		{URL: "https://shared.com/", Title: "Shared (old)", Content: "old", CrawledAt: old},
+	}, []storage.Link{{ToURL: "https://b.com/"}})
+	newShard(t, paths[2], []storage.Page{
+		{URL: "https://b.com/", Title: "B", Content: "b", CrawledAt: now},
+		{URL: "https://shared.com/", Title: "Shared (new)", Content: "new", CrawledAt: now},
+	}, nil)
+
+	db, err := storage.NewDatabase(paths[0])
+	if err != nil {
+		t.Fatalf("Failed to create target: %v", err)
+	}
+	defer db.Close()
+
+	for _, shard := range paths[1:] {
+		merged, err := db.Merge(shard)
+		if err != nil {
+			t.Fatalf("Merge %s failed: %v", shard, err)
+		}
+		t.Logf("Merged %s: %v", shard, merged)
+	}
+
+	// Merging again must not duplicate anything
+	if _, err := db.Merge(paths[1]); err != nil {
+		t.Fatalf("Second merge failed: %v", err)
+	}
+
+	stats, err := db.GetCrawlStats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	t.Logf("Merged database: %d pages, %d links", stats.Pages, stats.Links)
+
+	if stats.Pages != 3 {
+		t.Errorf("Expected 3 distinct pages, got %d", stats.Pages)
+	}
+	if stats.Links != 1 {
+		t.Errorf("Expected 1 link, got %d", stats.Links)
+	}
+
+	shared, err := db.GetPage("https://shared.com/")
+	if err != nil || shared == nil {
+		t.Fatalf("Shared page missing: %v", err)
+	}
+	if shared.Title != "Shared (new)" {
+		t.Errorf("Expected the newer copy of a page crawled by both shards, got %q", shared.Title)
+	}
+
+	totals, err := db.GetAttemptTotals(old.Add(-time.Minute), now.Add(time.Minute), "")
+	if err != nil {
+		t.Fatalf("Failed to get attempts: %v", err)
+	}
+	attempts := 0
+	for _, total := range totals {
+		attempts += total.Count
+	}
+	if attempts != 4 {
+		t.Errorf("Expected 4 attempts after merging a shard twice, got %d", attempts)
+	}
+}
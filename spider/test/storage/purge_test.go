@@ -0,0 +1,51 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestPurgeHostMatchesOnlyThatHost(t *testing.T) {
+	dbPath := "./test_purge.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	urls := []string{
+		"https://my_site.com/a",
+		"https://my_site.com",
+		"https://myXsite.com/b",     // "_" would match any character
+		"https://my_site.com.evil/", // a different host with the same prefix
+		"https://100%.com/c",
+	}
+	for _, url := range urls {
+		if err := db.SavePage(&storage.Page{URL: url, Title: "Page", StatusCode: 200, CrawledAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to save %s: %v", url, err)
+		}
+	}
+
+	deleted, err := db.PurgeHost("my_site.com")
+	if err != nil {
+		t.Fatalf("PurgeHost failed: %v", err)
+	}
+	if deleted["pages"] != 2 {
+		t.Errorf("Expected 2 pages of my_site.com deleted, got %d", deleted["pages"])
+	}
+
+	for _, url := range urls[2:] {
+		if page, err := db.GetPage(url); err != nil || page == nil {
+			t.Errorf("Page %s of another host was purged (%v)", url, err)
+		}
+	}
+
+	if deleted, err := db.PurgeHost("100%"); err != nil || deleted["pages"] != 0 {
+		t.Errorf("Expected no pages for host 100%%, got %d (%v)", deleted["pages"], err)
+	}
+}
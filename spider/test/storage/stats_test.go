@@ -0,0 +1,46 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestCrawlStatsCountsHosts(t *testing.T) {
+	dbPath := "./test_stats.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	urls := []string{
+		"https://example.com/",
+		"https://example.com/about",
+		"https://example.com?page=2",
+		"http://example.com:8080/docs",
+		"https://blog.example.com#top",
+		"https://golang.org",
+	}
+	for _, u := range urls {
+		if err := db.SavePage(&storage.Page{URL: u, StatusCode: 200, CrawledAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to save page %s: %v", u, err)
+		}
+	}
+
+	stats, err := db.GetCrawlStats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Pages != len(urls) {
+		t.Errorf("Expected %d pages, got %d", len(urls), stats.Pages)
+	}
+	// example.com, example.com:8080, blog.example.com and golang.org
+	if stats.Hosts != 4 {
+		t.Errorf("Expected 4 hosts, got %d", stats.Hosts)
+	}
+}
@@ -0,0 +1,74 @@
+package storage_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestStripSiteTemplatesRehashesAndArchives(t *testing.T) {
+	dbPath := "./test_templates.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+	db.SetEventLog(true)
+
+	const footer = "Copyright 2024 Example Media Group. All rights reserved."
+	original := "Body of the article.\n" + footer
+	sum := sha256.Sum256([]byte(original))
+	url := "https://example.com/article"
+	err = db.SavePage(&storage.Page{URL: url, Title: "Article", Content: original, ContentHash: hex.EncodeToString(sum[:]), StatusCode: 200, CrawledAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to save page: %v", err)
+	}
+
+	if err := db.SaveSiteTemplates("example.com", []storage.SiteTemplate{{Host: "example.com", Hash: 1, Text: footer, PageCount: 10, HostPages: 10}}); err != nil {
+		t.Fatalf("Failed to save templates: %v", err)
+	}
+
+	updated, err := db.StripSiteTemplates("example.com")
+	if err != nil || updated != 1 {
+		t.Fatalf("Expected 1 page rewritten, got %d (%v)", updated, err)
+	}
+
+	page, err := db.GetPage(url)
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	sum = sha256.Sum256([]byte("Body of the article."))
+	newHash := hex.EncodeToString(sum[:])
+	if page.Content != "Body of the article." || page.ContentHash != newHash {
+		t.Errorf("Expected stripped content with a new hash, got %q (%s)", page.Content, page.ContentHash)
+	}
+
+	// The version key consumers detect changes by must move with the content
+	events, err := db.ListPageEvents(0, 10)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Expected new and updated events, got %+v (%v)", events, err)
+	}
+	if events[1].Change != storage.ChangeUpdated || events[1].ContentHash != newHash {
+		t.Errorf("Expected an updated event with the new hash, got %+v", events[1])
+	}
+
+	// The unstripped content is kept like any overwritten page
+	versions, err := db.ListPageVersions(url)
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("Expected the unstripped version archived, got %d (%v)", len(versions), err)
+	}
+	if archived, _ := db.GetPageVersion(versions[0].ID); archived == nil || archived.Content != original {
+		t.Errorf("Expected the archived version to hold the original content, got %+v", archived)
+	}
+
+	// Stripping again finds nothing left to remove
+	if updated, err := db.StripSiteTemplates("example.com"); err != nil || updated != 0 {
+		t.Errorf("Expected no pages rewritten the second time, got %d (%v)", updated, err)
+	}
+}
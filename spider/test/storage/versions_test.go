@@ -0,0 +1,86 @@
+package storage_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestPageVersionHistory(t *testing.T) {
+	dbPath := "./test_versions.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	db.SetVersionRetention(storage.VersionRetention{
+		RetentionPolicy: storage.RetentionPolicy{Keep: 2},
+		Hosts: map[string]storage.RetentionPolicy{
+			"news.com":   {Keep: 10},
+			"static.com": {Keep: 0},
+		},
+	})
+
+	start := time.Now().Add(-time.Hour)
+	save := func(url, content string, i int) {
+		t.Helper()
+		err := db.SavePage(&storage.Page{URL: url, Title: "Page", Content: content, CrawledAt: start.Add(time.Duration(i) * time.Minute)})
+		if err != nil {
+			t.Fatalf("Failed to save page: %v", err)
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		save("https://blog.com/post", fmt.Sprintf("revision %d", i), i)
+		save("https://www.news.com/story", fmt.Sprintf("revision %d", i), i)
+		save("https://static.com/", fmt.Sprintf("revision %d", i), i)
+	}
+	// An unchanged re-crawl makes no version
+	save("https://blog.com/post", "revision 4", 10)
+
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{"https://blog.com/post", 2},      // default policy
+		{"https://www.news.com/story", 4}, // subdomain of an overridden host
+		{"https://static.com/", 0},        // history off
+	}
+	for _, tt := range tests {
+		versions, err := db.ListPageVersions(tt.url)
+		if err != nil {
+			t.Fatalf("Failed to list versions: %v", err)
+		}
+		t.Logf("%s: %d versions", tt.url, len(versions))
+		if len(versions) != tt.want {
+			t.Errorf("Expected %d versions of %s, got %d", tt.want, tt.url, len(versions))
+		}
+	}
+
+	versions, _ := db.ListPageVersions("https://blog.com/post")
+	newest, err := db.GetPageVersion(versions[0].ID)
+	if err != nil || newest == nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	t.Logf("Newest archived version: %q (hash %s, %d bytes compressed)", newest.Content, newest.ContentHash[:12], newest.Size)
+	if newest.Content != "revision 3" {
+		t.Errorf("Expected the newest earlier version to be revision 3, got %q", newest.Content)
+	}
+
+	// Tightening the policy and pruning applies it to existing history
+	db.SetVersionRetention(storage.VersionRetention{RetentionPolicy: storage.RetentionPolicy{Keep: 1}})
+	deleted, err := db.PruneVersions()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	t.Logf("Pruned %d versions", deleted)
+	if deleted != 4 {
+		t.Errorf("Expected 4 versions pruned (1 from blog.com, 3 from news.com), got %d", deleted)
+	}
+}
@@ -0,0 +1,179 @@
+package storage_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/storage"
+)
+
+func TestWriterBatchesAndFlushesOnClose(t *testing.T) {
+	dbPath := "./test_writer.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// A small queue forces backpressure with several concurrent writers
+	writer := storage.NewWriter(db, storage.WriterConfig{BatchSize: 50, FlushInterval: time.Hour, QueueSize: 10})
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 120; i++ {
+				url := fmt.Sprintf("https://example.com/%d/%d", worker, i)
+				err := writer.Write(&storage.WriteRequest{
+					URL:      url,
+					Page:     &storage.Page{URL: url, Title: "Page", Content: "content", StatusCode: 200, CrawledAt: time.Now()},
+					Outlinks: []storage.Link{{ToURL: "https://example.com/", AnchorText: "Home"}},
+					Attempt:  &storage.CrawlAttempt{URL: url, Host: "example.com", AttemptedAt: time.Now(), Outcome: "crawled"},
+				})
+				if err != nil {
+					t.Errorf("Write failed: %v", err)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	// The flush interval is an hour, so the final partial batch only lands on Close
+	writer.Close()
+
+	count, err := db.GetPageCount()
+	if err != nil {
+		t.Fatalf("Failed to count pages: %v", err)
+	}
+	if count != 480 {
+		t.Errorf("Expected 480 pages after Close, got %d", count)
+	}
+
+	inlinks, err := db.GetInlinks("https://example.com/")
+	if err != nil {
+		t.Fatalf("Failed to get inlinks: %v", err)
+	}
+	if len(inlinks) != 480 {
+		t.Errorf("Expected 480 inlinks, got %d", len(inlinks))
+	}
+
+	stats := writer.Stats()
+	t.Logf("Written %d requests in %d batches, %d stalls", stats.Written, stats.Batches, stats.Stalls)
+	if stats.Written != 480 || stats.Failed != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.Batches < 480/50 {
+		t.Errorf("Expected at least %d batches, got %d", 480/50, stats.Batches)
+	}
+
+	if err := writer.Write(&storage.WriteRequest{}); !errors.Is(err, storage.ErrWriterClosed) {
+		t.Errorf("Expected ErrWriterClosed after Close, got %v", err)
+	}
+}
+
+func TestWriterFlushesOnInterval(t *testing.T) {
+	dbPath := "./test_writer_interval.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	writer := storage.NewWriter(db, storage.WriterConfig{BatchSize: 1000, FlushInterval: 50 * time.Millisecond})
+	defer writer.Close()
+
+	url := "https://example.com/only"
+	writer.Write(&storage.WriteRequest{URL: url, Page: &storage.Page{URL: url, StatusCode: 200, CrawledAt: time.Now()}})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if page, _ := db.GetPage(url); page != nil {
+			t.Logf("Page written after the flush interval")
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("Partial batch was not flushed by the interval")
+}
+
+func TestWriterStoresCrawlState(t *testing.T) {
+	dbPath := "./test_writer_state.db"
+	defer os.Remove(dbPath)
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	writer := storage.NewWriter(db, storage.WriterConfig{})
+	seenStore, err := db.NewSeenStore(writer)
+	if err != nil {
+		t.Fatalf("Failed to open seen store: %v", err)
+	}
+	defer seenStore.Close()
+
+	requests := []*storage.WriteRequest{
+		{URL: "example.com", SiteTemplates: &storage.SiteTemplates{Host: "example.com", Templates: []storage.SiteTemplate{
+			{Host: "example.com", Hash: 42, Text: "Copyright Example", PageCount: 10, HostPages: 12},
+		}}},
+		{URL: "trap.example.com", QuarantinedHost: &storage.QuarantinedHost{Host: "trap.example.com", Reason: "low_content_yield", PagesCrawled: 50, UniquePages: 2}},
+		{URL: "example.com", Probe: &storage.HostProbe{Host: "example.com", StatusCode: 404, ProbedAt: time.Now()}},
+	}
+	for _, req := range requests {
+		if err := writer.Write(req); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := seenStore.AddSeenURLs([]string{"https://example.com/queued"}); err != nil {
+		t.Fatalf("AddSeenURLs failed: %v", err)
+	}
+	writer.Close()
+
+	templates, err := db.LoadSiteTemplates()
+	if err != nil || len(templates) != 1 || templates[0].Text != "Copyright Example" {
+		t.Errorf("Expected the saved template, got %+v (%v)", templates, err)
+	}
+	hosts, err := db.LoadQuarantinedHosts()
+	if err != nil || len(hosts) != 1 || hosts[0].Host != "trap.example.com" {
+		t.Errorf("Expected the quarantined host, got %+v (%v)", hosts, err)
+	}
+	probes, err := db.LoadHostProbes(time.Now().Add(-time.Hour))
+	if err != nil || len(probes) != 1 || probes[0].StatusCode != 404 {
+		t.Errorf("Expected the host probe, got %+v (%v)", probes, err)
+	}
+
+	// Lookups must not wait for another connection's write transaction
+	other, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open second connection: %v", err)
+	}
+	defer other.Close()
+	tx, err := other.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("INSERT INTO seen_urls (url) VALUES ('https://example.com/locked')"); err != nil {
+		t.Fatalf("Failed to write in transaction: %v", err)
+	}
+
+	start := time.Now()
+	found, err := seenStore.HasSeenURL("https://example.com/queued")
+	if err != nil || !found {
+		t.Errorf("Expected the URL written through the writer to be seen, got %v (%v)", found, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Lookup waited %v on the open write transaction", elapsed)
+	}
+}
@@ -0,0 +1,72 @@
+package textdiff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/textdiff"
+)
+
+func TestLinesReconstructsBothSides(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"", ""},
+		{"", "one\ntwo"},
+		{"one\ntwo", ""},
+		{"a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc"},
+		{"same\nsame\nsame", "same\nsame\nsame"},
+	}
+
+	for _, tt := range tests {
+		edits := textdiff.Lines(tt.a, tt.b)
+
+		var a, b []string
+		changes := 0
+		for _, edit := range edits {
+			if edit.Op != textdiff.Insert {
+				a = append(a, edit.Line)
+			}
+			if edit.Op != textdiff.Delete {
+				b = append(b, edit.Line)
+			}
+			if edit.Op != textdiff.Equal {
+				changes++
+			}
+		}
+		t.Logf("%q -> %q: %d changed lines", tt.a, tt.b, changes)
+
+		if strings.Join(a, "\n") != tt.a || strings.Join(b, "\n") != tt.b {
+			t.Errorf("Edits don't reproduce the inputs: %v", edits)
+		}
+	}
+
+	// The classic example has an edit distance of 5
+	edits := textdiff.Lines("a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc")
+	changes := 0
+	for _, edit := range edits {
+		if edit.Op != textdiff.Equal {
+			changes++
+		}
+	}
+	if changes != 5 {
+		t.Errorf("Expected 5 changes, got %d", changes)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	old := "intro\none\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nprice: $10\nfooter"
+	new := "intro\none\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nprice: $12\nfooter\nupdated"
+
+	diff := textdiff.Unified(old, new, "old", "new", 2)
+	t.Logf("Diff:\n%s", diff)
+
+	expected := "--- old\n+++ new\n@@ -8,4 +8,5 @@\n seven\n eight\n-price: $10\n+price: $12\n footer\n+updated\n"
+	if diff != expected {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", diff, expected)
+	}
+
+	if textdiff.Unified(old, old, "a", "b", 3) != "" {
+		t.Error("Expected no diff for identical text")
+	}
+}
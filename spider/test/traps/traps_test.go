@@ -0,0 +1,99 @@
+package traps_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dangpham/deisearch/spider/internal/traps"
+)
+
+func TestCheckURLStructuralTraps(t *testing.T) {
+	d := traps.NewDetector(traps.Config{})
+
+	cases := []struct {
+		url    string
+		reason string
+	}{
+		{"https://example.com/blog/how-to-cook-rice", ""},
+		{"https://example.com/docs/docs/intro", ""},
+		{"https://example.com/a/b/a/b/a/b/page", traps.ReasonRepeatedSegments},
+		{"https://example.com/x/x/x/x", traps.ReasonRepeatedSegments},
+		{"https://example.com/" + strings.Repeat("deep/", 15), traps.ReasonPathTooDeep},
+		{"https://example.com/search?q=" + strings.Repeat("a", 600), traps.ReasonURLTooLong},
+		{"https://example.com/events/2024/05", ""},
+		{"https://example.com/events/2099/05", traps.ReasonCalendar},
+		{"https://example.com/calendar/1802-11-01", traps.ReasonCalendar},
+	}
+
+	for _, c := range cases {
+		if got := d.CheckURL(c.url); got != c.reason {
+			t.Errorf("CheckURL(%s) = %q, expected %q", c.url, got, c.reason)
+		}
+	}
+}
+
+func TestCheckURLTemplateExplosion(t *testing.T) {
+	d := traps.NewDetector(traps.Config{MaxTemplateValues: 50})
+
+	tripped := -1
+	for i := 0; i < 100; i++ {
+		if reason := d.CheckURL(fmt.Sprintf("https://shop.example.com/products/%d/reviews", i)); reason != "" {
+			if reason != traps.ReasonTemplateExplosion {
+				t.Fatalf("Expected template explosion, got %q", reason)
+			}
+			tripped = i
+			break
+		}
+	}
+	if tripped != 50 {
+		t.Fatalf("Expected template to trip after 50 distinct values, tripped at %d", tripped)
+	}
+	t.Logf("Template tripped at value %d", tripped)
+
+	// Repeating a known value does not count as a new one
+	d2 := traps.NewDetector(traps.Config{MaxTemplateValues: 5})
+	for i := 0; i < 20; i++ {
+		if reason := d2.CheckURL("https://shop.example.com/products/1/reviews"); reason != "" {
+			t.Fatalf("Same URL should never trip, got %q", reason)
+		}
+	}
+
+	// Other templates and hosts are unaffected
+	if reason := d.CheckURL("https://shop.example.com/about"); reason != "" {
+		t.Errorf("Unrelated path should pass, got %q", reason)
+	}
+	if reason := d.CheckURL("https://other.example.com/products/7/reviews"); reason != "" {
+		t.Errorf("Other host should pass, got %q", reason)
+	}
+}
+
+func TestLowContentYieldQuarantinesHost(t *testing.T) {
+	d := traps.NewDetector(traps.Config{MinYieldPages: 20, MinUniqueRatio: 0.5})
+
+	quarantinedAt := -1
+	for i := 0; i < 40; i++ {
+		// Every page of the host renders the same "no results" body
+		if d.RecordContent("trap.example.com", "same-hash") {
+			quarantinedAt = i + 1
+			break
+		}
+	}
+	if quarantinedAt != 20 {
+		t.Fatalf("Expected quarantine after 20 pages, got %d", quarantinedAt)
+	}
+	if !d.IsHostQuarantined("trap.example.com") {
+		t.Error("Host should be quarantined")
+	}
+	if reason := d.CheckURL("https://trap.example.com/anything"); reason != traps.ReasonLowContentYield {
+		t.Errorf("URLs of a quarantined host should be rejected, got %q", reason)
+	}
+
+	for i := 0; i < 40; i++ {
+		if d.RecordContent("good.example.com", fmt.Sprintf("hash-%d", i)) {
+			t.Fatal("Host with unique pages should not be quarantined")
+		}
+	}
+	pages, unique := d.HostYield("good.example.com")
+	t.Logf("good.example.com: %d unique of %d pages", unique, pages)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dangpham/deisearch/spider/internal/parser"
+	"github.com/dangpham/deisearch/spider/internal/storage"
+	"github.com/dangpham/deisearch/spider/internal/textdiff"
+)
+
+const currentVersion = "current"
+
+func runVersions(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected list, diff or prune")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("versions "+action, flag.ExitOnError)
+	common := addCommonFlags(fs)
+	context := fs.Int("context", 3, "unchanged lines shown around each change (diff)")
+	fs.Parse(args[1:])
+
+	if (action == "list" || action == "diff") && fs.NArg() == 0 {
+		return fmt.Errorf("versions %s needs a page URL", action)
+	}
+
+	_, db, err := common.openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "list":
+		return listVersions(db, parser.NormalizeURLString(fs.Arg(0)))
+
+	case "diff":
+		// Defaults to the newest archived version against the current page
+		from, to := "", currentVersion
+		if fs.NArg() > 1 {
+			from = fs.Arg(1)
+		}
+		if fs.NArg() > 2 {
+			to = fs.Arg(2)
+		}
+		return diffVersions(db, parser.NormalizeURLString(fs.Arg(0)), from, to, *context)
+
+	case "prune":
+		deleted, err := db.PruneVersions()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %d versions\n", deleted)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown versions action %q, expected list, diff or prune", action)
+	}
+}
+
+func listVersions(db *storage.Database, pageURL string) error {
+	page, err := db.GetPage(pageURL)
+	if err != nil {
+		return err
+	}
+	versions, err := db.ListPageVersions(pageURL)
+	if err != nil {
+		return err
+	}
+	if page == nil && len(versions) == 0 {
+		return fmt.Errorf("%s has not been crawled", pageURL)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tCRAWLED\tHASH\tSIZE\tTITLE")
+	if page != nil {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", currentVersion, page.CrawledAt.Local().Format(time.DateTime), shortHash(page.ContentHash), len(page.Content), page.Title)
+	}
+	for _, v := range versions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d (gz)\t%s\n", v.ID, v.CrawledAt.Local().Format(time.DateTime), shortHash(v.ContentHash), v.Size, v.Title)
+	}
+	return w.Flush()
+}
+
+func diffVersions(db *storage.Database, pageURL, from, to string, context int) error {
+	if from == "" {
+		versions, err := db.ListPageVersions(pageURL)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return fmt.Errorf("%s has no earlier versions", pageURL)
+		}
+		from = strconv.FormatInt(versions[0].ID, 10)
+	}
+
+	a, err := loadVersion(db, pageURL, from)
+	if err != nil {
+		return err
+	}
+	b, err := loadVersion(db, pageURL, to)
+	if err != nil {
+		return err
+	}
+
+	fromName := fmt.Sprintf("%s\t%s (version %s)", pageURL, a.CrawledAt.Local().Format(time.DateTime), from)
+	toName := fmt.Sprintf("%s\t%s (version %s)", pageURL, b.CrawledAt.Local().Format(time.DateTime), to)
+
+	if a.Title != b.Title {
+		fmt.Printf("Title: %q -> %q\n", a.Title, b.Title)
+	}
+	if a.Description != b.Description {
+		fmt.Printf("Description: %q -> %q\n", a.Description, b.Description)
+	}
+
+	diff := textdiff.Unified(a.Content, b.Content, fromName, toName, context)
+	if diff == "" {
+		fmt.Println("Content is identical")
+		return nil
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+// loadVersion reads an archived version by ID, or the page as it is now.
+func loadVersion(db *storage.Database, pageURL, version string) (*storage.PageVersion, error) {
+	if version == currentVersion {
+		page, err := db.GetPage(pageURL)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			return nil, fmt.Errorf("%s has not been crawled", pageURL)
+		}
+		return &storage.PageVersion{
+			URL:         page.URL,
+			Title:       page.Title,
+			Description: page.Description,
+			Content:     page.Content,
+			ContentHash: page.ContentHash,
+			CrawledAt:   page.CrawledAt,
+		}, nil
+	}
+
+	id, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("version must be an ID from \"versions list\" or %q", currentVersion)
+	}
+	v, err := db.GetPageVersion(id)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || v.URL != pageURL {
+		return nil, fmt.Errorf("%s has no version %d", pageURL, id)
+	}
+	return v, nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}